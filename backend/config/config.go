@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -11,6 +12,101 @@ import (
 type Config struct {
 	DatabaseUrl string `mapstructure:"DATABASE_URL"`
 	ExternalApi string `mapstructure:"EXTERNAL_API"`
+
+	// Применять неприменённые миграции при старте сервера
+	DbAutoMigrate bool `mapstructure:"DB_AUTO_MIGRATE"`
+
+	// Реплики для чтения через запятую; без них всё читается из основной БД
+	DatabaseReplicaUrls string `mapstructure:"DATABASE_REPLICA_URLS"`
+	// Допустимое отставание реплики; реплика с большим отставанием не используется
+	DbReplicaMaxLag time.Duration `mapstructure:"DB_REPLICA_MAX_LAG"`
+	// Период проверки здоровья и отставания реплик
+	DbReplicaCheckInterval time.Duration `mapstructure:"DB_REPLICA_CHECK_INTERVAL"`
+	// Сколько клиент читает из основной БД после своей записи (0 отключает); явно это просит заголовок X-Read-Primary
+	DbPrimaryReadsAfterWrite time.Duration `mapstructure:"DB_PRIMARY_READS_AFTER_WRITE"`
+
+	// Клиент внешнего API
+	ExternalApiTimeout          time.Duration `mapstructure:"EXTERNAL_API_TIMEOUT"`
+	ExternalApiRetries          int           `mapstructure:"EXTERNAL_API_RETRIES"`
+	ExternalApiBackoff          time.Duration `mapstructure:"EXTERNAL_API_BACKOFF"`
+	ExternalApiMaxBackoff       time.Duration `mapstructure:"EXTERNAL_API_MAX_BACKOFF"`
+	ExternalApiBreakerThreshold int           `mapstructure:"EXTERNAL_API_BREAKER_THRESHOLD"`
+	ExternalApiBreakerCooldown  time.Duration `mapstructure:"EXTERNAL_API_BREAKER_COOLDOWN"`
+
+	// Кэш ответов внешнего API
+	SongDetailsCacheTTL         time.Duration `mapstructure:"SONG_DETAILS_CACHE_TTL"`
+	SongDetailsCacheSize        int           `mapstructure:"SONG_DETAILS_CACHE_SIZE"`
+	SongDetailsCacheNegativeTTL time.Duration `mapstructure:"SONG_DETAILS_CACHE_NEGATIVE_TTL"`
+	SongDetailsCachePersistent  bool          `mapstructure:"SONG_DETAILS_CACHE_PERSISTENT"`
+	// Период удаления просроченных записей постоянного кэша
+	SongDetailsCachePurgeInterval time.Duration `mapstructure:"SONG_DETAILS_CACHE_PURGE_INTERVAL"`
+
+	// Фоновые задачи обогащения песен
+	JobWorkers      int           `mapstructure:"JOB_WORKERS"`
+	JobPollInterval time.Duration `mapstructure:"JOB_POLL_INTERVAL"`
+	JobLease        time.Duration `mapstructure:"JOB_LEASE"`
+	// Сколько раз запускать задачу, упавшую из-за временной ошибки
+	JobMaxAttempts int `mapstructure:"JOB_MAX_ATTEMPTS"`
+	// Пауза перед повтором; удваивается с каждой попыткой до JOB_RETRY_MAX_BACKOFF
+	JobRetryBackoff    time.Duration `mapstructure:"JOB_RETRY_BACKOFF"`
+	JobRetryMaxBackoff time.Duration `mapstructure:"JOB_RETRY_MAX_BACKOFF"`
+
+	// Корзина удалённых песен
+	SongTrashRetention     time.Duration `mapstructure:"SONG_TRASH_RETENTION"`
+	SongTrashPurgeInterval time.Duration `mapstructure:"SONG_TRASH_PURGE_INTERVAL"`
+
+	// Период перечитывания количества шардов после решардинга
+	ShardRefreshInterval time.Duration `mapstructure:"SHARD_REFRESH_INTERVAL"`
+	// Число шардов, опрашиваемых одним запросом одновременно
+	ShardFanOutLimit int `mapstructure:"SHARD_FANOUT_LIMIT"`
+	// Доля строк в одном шарде, при превышении которой отчёт о шардах предупреждает о перекосе
+	ShardSkewWarnShare float64 `mapstructure:"SHARD_SKEW_WARN_SHARE"`
+
+	// Дедлайн обработки запроса; клиент может сократить его заголовком X-Request-Timeout
+	RequestTimeout time.Duration `mapstructure:"REQUEST_TIMEOUT"`
+	// Дедлайн импорта песен, который читает тело потоком и идёт дольше обычных запросов
+	ImportTimeout time.Duration `mapstructure:"IMPORT_TIMEOUT"`
+}
+
+// defaults - значения необязательных параметров конфигурации.
+var defaults = map[string]interface{}{
+	"DB_AUTO_MIGRATE": true,
+
+	"DATABASE_REPLICA_URLS":        "",
+	"DB_REPLICA_MAX_LAG":           "5s",
+	"DB_REPLICA_CHECK_INTERVAL":    "5s",
+	"DB_PRIMARY_READS_AFTER_WRITE": "10s",
+
+	"EXTERNAL_API_TIMEOUT":           "5s",
+	"EXTERNAL_API_RETRIES":           3,
+	"EXTERNAL_API_BACKOFF":           "200ms",
+	"EXTERNAL_API_MAX_BACKOFF":       "5s",
+	"EXTERNAL_API_BREAKER_THRESHOLD": 5,
+	"EXTERNAL_API_BREAKER_COOLDOWN":  "30s",
+
+	"SONG_DETAILS_CACHE_TTL":            "24h",
+	"SONG_DETAILS_CACHE_SIZE":           1000,
+	"SONG_DETAILS_CACHE_NEGATIVE_TTL":   "0s",
+	"SONG_DETAILS_CACHE_PERSISTENT":     false,
+	"SONG_DETAILS_CACHE_PURGE_INTERVAL": "1h",
+
+	"JOB_WORKERS":       4,
+	"JOB_POLL_INTERVAL": "2s",
+	"JOB_LEASE":         "5m",
+
+	"JOB_MAX_ATTEMPTS":      5,
+	"JOB_RETRY_BACKOFF":     "10s",
+	"JOB_RETRY_MAX_BACKOFF": "10m",
+
+	"SONG_TRASH_RETENTION":      "720h",
+	"SONG_TRASH_PURGE_INTERVAL": "1h",
+
+	"SHARD_REFRESH_INTERVAL": "30s",
+	"SHARD_FANOUT_LIMIT":     8,
+	"SHARD_SKEW_WARN_SHARE":  0.5,
+
+	"REQUEST_TIMEOUT": "30s",
+	"IMPORT_TIMEOUT":  "1h",
 }
 
 func validateConfig(config *Config) error {
@@ -25,9 +121,65 @@ func validateConfig(config *Config) error {
 		}
 	}
 
+	positive := map[string]int64{
+		"DB_REPLICA_MAX_LAG":                int64(config.DbReplicaMaxLag),
+		"DB_REPLICA_CHECK_INTERVAL":         int64(config.DbReplicaCheckInterval),
+		"EXTERNAL_API_TIMEOUT":              int64(config.ExternalApiTimeout),
+		"EXTERNAL_API_BACKOFF":              int64(config.ExternalApiBackoff),
+		"EXTERNAL_API_MAX_BACKOFF":          int64(config.ExternalApiMaxBackoff),
+		"EXTERNAL_API_BREAKER_THRESHOLD":    int64(config.ExternalApiBreakerThreshold),
+		"EXTERNAL_API_BREAKER_COOLDOWN":     int64(config.ExternalApiBreakerCooldown),
+		"SONG_DETAILS_CACHE_TTL":            int64(config.SongDetailsCacheTTL),
+		"SONG_DETAILS_CACHE_PURGE_INTERVAL": int64(config.SongDetailsCachePurgeInterval),
+		"JOB_WORKERS":                       int64(config.JobWorkers),
+		"JOB_POLL_INTERVAL":                 int64(config.JobPollInterval),
+		"JOB_LEASE":                         int64(config.JobLease),
+		"JOB_MAX_ATTEMPTS":                  int64(config.JobMaxAttempts),
+		"JOB_RETRY_BACKOFF":                 int64(config.JobRetryBackoff),
+		"JOB_RETRY_MAX_BACKOFF":             int64(config.JobRetryMaxBackoff),
+		"SONG_TRASH_RETENTION":              int64(config.SongTrashRetention),
+		"SONG_TRASH_PURGE_INTERVAL":         int64(config.SongTrashPurgeInterval),
+		"SHARD_REFRESH_INTERVAL":            int64(config.ShardRefreshInterval),
+		"SHARD_FANOUT_LIMIT":                int64(config.ShardFanOutLimit),
+		"REQUEST_TIMEOUT":                   int64(config.RequestTimeout),
+		"IMPORT_TIMEOUT":                    int64(config.ImportTimeout),
+	}
+	for key, value := range positive {
+		if value <= 0 {
+			return fmt.Errorf("configuration field %s must be positive", key)
+		}
+	}
+
+	if !(config.ShardSkewWarnShare > 0 && config.ShardSkewWarnShare <= 1) {
+		return fmt.Errorf("configuration field SHARD_SKEW_WARN_SHARE must be in (0, 1]")
+	}
+
+	nonNegative := map[string]int64{
+		"DB_PRIMARY_READS_AFTER_WRITE":    int64(config.DbPrimaryReadsAfterWrite),
+		"EXTERNAL_API_RETRIES":            int64(config.ExternalApiRetries),
+		"SONG_DETAILS_CACHE_SIZE":         int64(config.SongDetailsCacheSize),
+		"SONG_DETAILS_CACHE_NEGATIVE_TTL": int64(config.SongDetailsCacheNegativeTTL),
+	}
+	for key, value := range nonNegative {
+		if value < 0 {
+			return fmt.Errorf("configuration field %s must not be negative", key)
+		}
+	}
+
 	return nil
 }
 
+// ReplicaUrls возвращает DSN реплик из DATABASE_REPLICA_URLS.
+func (c *Config) ReplicaUrls() []string {
+	var urls []string
+	for _, url := range strings.Split(c.DatabaseReplicaUrls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
 func LoadConfig(path string) (config *Config, err error) {
 	viper.AddConfigPath(path)
 	viper.SetConfigName("../.env")
@@ -36,6 +188,10 @@ func LoadConfig(path string) (config *Config, err error) {
 	// Automatically map environment variables
 	viper.AutomaticEnv()
 
+	for key, value := range defaults {
+		viper.SetDefault(key, value)
+	}
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return nil, err
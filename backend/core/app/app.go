@@ -1,11 +1,22 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"root/config"
 	_ "root/core/docs"
 	"root/database"
+	song_dto "root/module/song/dto"
 	"root/shared/logger"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,13 +33,28 @@ type App struct {
 	httpConfig config.HTTPConfig
 
 	db *gorm.DB
+	// cluster направляет чтения GET-запросов в реплики, если они настроены
+	cluster *database.Cluster
 
 	moduleProvider *moduleProvider
+
+	// ctx отменяется при остановке приложения и завершает фоновые воркеры
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// command - приложение запущено консольной командой, а не как сервер
+	command bool
 }
 
 func NewApp() *App {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &App{
-		app: fiber.New(),
+		// StreamRequestBody позволяет импорту читать большие тела потоком
+		app: fiber.New(fiber.Config{
+			StreamRequestBody: true,
+		}),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
@@ -36,8 +62,14 @@ func (app *App) Run() error {
 	app.app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:6969",
 		AllowCredentials: false,
+		ExposeHeaders:    "ETag, Location",
 	}))
 
+	app.app.Use(limitRequestBody)
+
+	app.app.Use(app.requestContext)
+	app.app.Use(app.readRouting)
+
 	err := app.initDeps()
 
 	if err != nil {
@@ -54,9 +86,11 @@ func (app *App) initDeps() error {
 		app.initLogger,
 
 		app.initDb,
+		app.initSchema,
 
 		app.initModuleProvider,
 		app.initRouter,
+		app.initWorkers,
 	}
 	for _, init := range inits {
 		err := init()
@@ -91,14 +125,45 @@ func (app *App) initDb() error {
 			return err
 		}
 		app.db = db
+	}
 
-		// true - запустить миграцию
-		// false - не запускать
-		if err := database.Migrate(db, true, app.logger); err != nil {
+	if app.cluster == nil {
+		// Консольные команды читают только из основной БД.
+		var replicas []*gorm.DB
+		if !app.command {
+			for _, url := range app.config.ReplicaUrls() {
+				replica, err := database.ConnectReplica(url)
+				if err != nil {
+					return fmt.Errorf("failed to connect to replica: %w", err)
+				}
+				replicas = append(replicas, replica)
+			}
+		}
+		app.cluster = database.NewCluster(app.db, replicas, app.config.DbReplicaMaxLag, app.logger)
+		if app.cluster.HasReplicas() {
+			app.logger.Infof("✅ Read replicas configured: %d", len(replicas))
+		}
+	}
+
+	return nil
+}
+
+// initSchema при DB_AUTO_MIGRATE применяет неприменённые миграции и загружает
+// количество шардов. Консольные команды схему не меняют: для этого есть migrate.
+func (app *App) initSchema() error {
+	if app.config.DbAutoMigrate && !app.command {
+		if err := database.Migrate(app.ctx, app.db, app.logger); err != nil {
 			return fmt.Errorf("%s", "✖ Failed to migrate database: "+err.Error())
 		}
+	} else {
+		app.logger.Info("ℹ️ Automatic migration is disabled, skipping migration")
 	}
 
+	shards, err := database.LoadShards(app.db)
+	if err != nil {
+		return fmt.Errorf("%s", "✖ Failed to load shard config, run migrate up: "+err.Error())
+	}
+	app.logger.Infof("✅ Songs are stored in %d shards", shards)
 	return nil
 }
 
@@ -129,22 +194,330 @@ func (app *App) runHttpServer() error {
 		app.httpConfig = cfg
 	}
 
+	app.handleShutdown()
+
 	app.logger.Infof("🌐 Server is running on %s", app.httpConfig.Address())
 	app.logger.Info("✅ Server started successfully")
 	if err := app.app.Listen(app.httpConfig.Address()); err != nil {
+		app.cancel()
 		app.logger.Errorf("%s", "✖ Failed to start server: "+err.Error())
 		return fmt.Errorf("✖ Failed to start server: %v", err)
 	}
 
+	app.moduleProvider.job.JobService().Wait()
+	app.moduleProvider.song.SongService().Wait()
+	app.logger.Info("✅ Server stopped")
 	return nil
 }
 
+// importPath - маршрут импорта, единственный, который читает тело потоком.
+const importPath = "/api/song/import"
+
+// limitRequestBody ограничивает тело запроса DefaultBodyLimit на всех
+// маршрутах, кроме импорта. С StreamRequestBody fasthttp сам лимит не
+// проверяет, а c.Body() дочитывает поток целиком, поэтому тело заранее
+// читается здесь через io.LimitReader: так ограничены и chunked-запросы без
+// Content-Length.
+func limitRequestBody(c *fiber.Ctx) error {
+	if c.Path() == importPath {
+		return c.Next()
+	}
+	if c.Request().Header.ContentLength() > fiber.DefaultBodyLimit {
+		return fiber.ErrRequestEntityTooLarge
+	}
+	if stream := c.Request().BodyStream(); stream != nil {
+		body, err := io.ReadAll(io.LimitReader(stream, fiber.DefaultBodyLimit+1))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "failed to read request body")
+		}
+		if len(body) > fiber.DefaultBodyLimit {
+			// Остаток тела не дочитывается, поэтому соединение закрывается.
+			c.Context().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		c.Request().SetBody(body)
+	}
+	return c.Next()
+}
+
+// requestTimeoutHeader - заголовок, которым клиент сокращает дедлайн запроса,
+// например "X-Request-Timeout: 2s".
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// requestContext передаёт обработчикам через UserContext контекст запроса с
+// дедлайном REQUEST_TIMEOUT, для импорта - IMPORT_TIMEOUT. Контекст
+// отменяется при отключении клиента и при остановке приложения, поэтому
+// брошенный запрос не продолжает опрашивать шарды.
+func (app *App) requestContext(c *fiber.Ctx) error {
+	timeout := app.config.RequestTimeout
+	if c.Path() == importPath {
+		timeout = app.config.ImportTimeout
+	}
+	if value := c.Get(requestTimeoutHeader); value != "" {
+		requested, err := time.ParseDuration(value)
+		if err != nil || requested <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid "+requestTimeoutHeader+" header: expected a positive duration like 2s")
+		}
+		timeout = min(timeout, requested)
+	}
+
+	ctx, cancel := context.WithTimeout(app.ctx, timeout)
+	defer cancel()
+
+	route := c.Method() + " " + c.Path()
+	stop := watchDisconnect(c.Context().Conn(), func() {
+		app.logger.Warnf("requestContext: client closed the connection, cancelling %s", route)
+		cancel()
+	})
+	defer stop()
+
+	c.SetUserContext(ctx)
+	return c.Next()
+}
+
+// primaryReadsCookie - cookie, которую получает клиент после запроса на
+// запись. Пока она жива, его GET-запросы читают из основной БД, чтобы он
+// увидел свою запись, даже если реплики её ещё не применили.
+const primaryReadsCookie = "read_primary"
+
+// primaryReadsHeader - заголовок, которым клиент явно просит читать из
+// основной БД, например "X-Read-Primary: true".
+const primaryReadsHeader = "X-Read-Primary"
+
+// readRouting разрешает GET- и HEAD-запросам читать из реплик, если клиент не
+// просил читать из основной БД. После остальных запросов клиент на
+// DB_PRIMARY_READS_AFTER_WRITE получает cookie primaryReadsCookie.
+func (app *App) readRouting(c *fiber.Ctx) error {
+	if !app.cluster.HasReplicas() {
+		return c.Next()
+	}
+
+	if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+		primary := c.Cookies(primaryReadsCookie) != ""
+		if value := c.Get(primaryReadsHeader); value != "" {
+			requested, err := strconv.ParseBool(value)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid "+primaryReadsHeader+" header: expected true or false")
+			}
+			primary = primary || requested
+		}
+		if !primary {
+			c.SetUserContext(database.WithReplicaReads(c.UserContext()))
+		}
+		return c.Next()
+	}
+
+	err := c.Next()
+	if window := app.config.DbPrimaryReadsAfterWrite; window > 0 && c.Method() != fiber.MethodOptions {
+		c.Cookie(&fiber.Cookie{
+			Name:     primaryReadsCookie,
+			Value:    "1",
+			Path:     "/",
+			MaxAge:   int((window + time.Second - 1) / time.Second),
+			Expires:  time.Now().Add(window),
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteLaxMode,
+		})
+	}
+	return err
+}
+
+// handleShutdown по SIGINT/SIGTERM останавливает фоновые воркеры и HTTP-сервер.
+func (app *App) handleShutdown() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-quit
+		app.logger.Info("🛑 Shutting down server...")
+		app.cancel()
+		if err := app.app.Shutdown(); err != nil {
+			app.logger.Errorf("✖ Failed to shutdown server: %v", err)
+		}
+	}()
+}
+
 func (app *App) initRouter() error {
 	api := app.app.Group("/api")
 
 	app.app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
 	app.moduleProvider.song.InitRoutes(api)
+	app.moduleProvider.job.InitRoutes(api)
+	app.moduleProvider.group.InitRoutes(api)
+
+	admin := api.Group("/admin")
+	app.moduleProvider.song.InitAdminRoutes(admin)
+
+	return nil
+}
+
+func (app *App) initWorkers() error {
+	app.moduleProvider.job.JobService().Start(app.ctx, app.moduleProvider.song.SongService().ProcessJob)
+	app.moduleProvider.song.SongService().StartTrashPurge(app.ctx)
+	app.moduleProvider.song.MusicClient().Start(app.ctx)
+	database.WatchShards(app.ctx, app.db, app.config.ShardRefreshInterval, app.logger)
+	app.cluster.WatchReplicas(app.ctx, app.config.DbReplicaCheckInterval)
+	return nil
+}
+
+// Reshard переносит песни в shards таблиц шардов или, если abort, отменяет
+// незавершённый решардинг. По SIGINT/SIGTERM решардинг останавливается после
+// текущей пачки и продолжается следующим запуском с тем же shards.
+func (app *App) Reshard(shards int, abort bool) error {
+	stop, err := app.initCommand(app.initConfig, app.initDb, app.initSchema)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if abort {
+		return database.AbortReshard(app.ctx, app.db, app.logger)
+	}
+	return database.Reshard(app.ctx, app.db, shards, app.logger)
+}
+
+// ShardStats пишет в w отчёт о шардах песен: таблицей или, если asJSON, в JSON.
+func (app *App) ShardStats(w io.Writer, top int, warnShare float64, asJSON bool) error {
+	stop, err := app.initCommand(app.initConfig, app.initDb, app.initSchema, app.initModuleProvider)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	report, err := app.moduleProvider.song.SongService().GetShardStats(app.ctx, top, warnShare)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return writeShardReport(w, report)
+}
 
+// Migrate выполняет команду миграций: up [до версии arg], down [arg шагов,
+// по умолчанию 1], goto arg или status, который пишет состояние версий в w.
+func (app *App) Migrate(w io.Writer, command string, arg int64) error {
+	stop, err := app.initCommand(app.initConfig, app.initDb)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(app.ctx, app.db, arg, app.logger)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Applied %d migrations\n", applied)
+	case "down":
+		reverted, err := database.MigrateDown(app.ctx, app.db, max(int(arg), 1), app.logger)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Reverted %d migrations\n", reverted)
+	case "goto":
+		if err := database.MigrateGoto(app.ctx, app.db, arg, app.logger); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Schema is at version %d\n", arg)
+	case "status":
+		statuses, err := database.MigrationStatuses(app.ctx, app.db)
+		if err != nil {
+			return err
+		}
+		return writeMigrationStatuses(w, statuses)
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+	return nil
+}
+
+// writeMigrationStatuses печатает состояние версий схемы таблицей.
+func writeMigrationStatuses(w io.Writer, statuses []database.MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSCOPE\tAPPLIED AT\tNOTE")
+	for _, status := range statuses {
+		scope := "once"
+		if status.Shards {
+			scope = "shards"
+		}
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		note := ""
+		switch {
+		case status.Missing:
+			scope, note = "-", "applied, but missing in this build"
+		case status.Modified:
+			note = "changed after it was applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, scope, appliedAt, note)
+	}
+	return tw.Flush()
+}
+
+// initCommand готовит зависимости консольной команды. Логи команды пишутся в
+// stderr, чтобы не смешиваться с её выводом. Команда прерывается по
+// SIGINT/SIGTERM через app.ctx; stop снимает обработчик сигналов.
+func (app *App) initCommand(inits ...func() error) (stop func(), err error) {
+	app.command = true
+	if err := app.initLogger(); err != nil {
+		return nil, err
+	}
+	app.logger.SetOutput(os.Stderr)
+
+	for _, init := range inits {
+		if err := init(); err != nil {
+			return nil, fmt.Errorf("%s", "✖ Failed to initialize dependencies: "+err.Error())
+		}
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-quit:
+			app.logger.Info("🛑 Stopping command...")
+			app.cancel()
+		case <-app.ctx.Done():
+		}
+	}()
+
+	return func() {
+		signal.Stop(quit)
+		app.cancel()
+	}, nil
+}
+
+// writeShardReport печатает отчёт о шардах таблицей.
+func writeShardReport(w io.Writer, report *song_dto.ShardReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tROWS\tLIVE\tTRASHED\tSHARE\tTABLE SIZE\tINDEX SIZE\tLAST WRITE\tTOP GROUPS")
+	for _, shard := range report.Shards {
+		lastWrite := "-"
+		if shard.LastWriteAt != nil {
+			lastWrite = shard.LastWriteAt.Format(time.RFC3339)
+		}
+		groups := make([]string, len(shard.TopGroups))
+		for i, group := range shard.TopGroups {
+			groups[i] = fmt.Sprintf("%s (%d): %d", group.Group, group.GroupID, group.Songs)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\t%d\t%d\t%s\t%s\n",
+			shard.Table, shard.Rows, shard.LiveRows, shard.TrashedRows, shard.Share*100,
+			shard.TableBytes, shard.IndexBytes, lastWrite, strings.Join(groups, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nTotal: %d songs, skew %.2f, max share %.1f%%\n", report.TotalRows, report.Skew, report.MaxShare*100)
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "⚠️ %s\n", warning)
+	}
 	return nil
 }
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newLimitedApp() *fiber.App {
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
+	app.Use(limitRequestBody)
+	app.Post("/api/song", func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	})
+	app.Post(importPath, func(c *fiber.Ctx) error {
+		n, err := io.Copy(io.Discard, c.Request().BodyStream())
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.FormatInt(n, 10))
+	})
+	return app
+}
+
+func TestLimitRequestBody(t *testing.T) {
+	app := newLimitedApp()
+	cases := []struct {
+		name string
+		path string
+		size int
+		want int
+	}{
+		{"small body", "/api/song", 10, fiber.StatusOK},
+		{"chunked body over limit", "/api/song", fiber.DefaultBodyLimit + 1, fiber.StatusRequestEntityTooLarge},
+		{"import is streamed", importPath, fiber.DefaultBodyLimit + 1, fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tc.path, strings.NewReader(strings.Repeat("a", tc.size)))
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+			req.Close = true
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("app.Test error = %v", err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
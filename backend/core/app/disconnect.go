@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// disconnectPollInterval - как часто проверяется, не закрыл ли клиент
+// соединение, пока обработчик работает.
+const disconnectPollInterval = 250 * time.Millisecond
+
+// watchDisconnect вызывает cancel, когда клиент закрывает соединение conn, и
+// возвращает функцию, останавливающую наблюдение. fasthttp узнаёт о закрытии
+// соединения, только когда читает из него сам, то есть после ответа, поэтому
+// сокет периодически проверяется чтением с MSG_PEEK: оно не забирает байты,
+// которые потом прочитает fasthttp. Соединения без доступа к сокету (TLS,
+// app.Test) и платформы без MSG_PEEK не наблюдаются.
+func watchDisconnect(conn net.Conn, cancel context.CancelFunc) (stop func()) {
+	raw := rawConn(conn)
+	if raw == nil || !canPeek {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		buf := make([]byte, 1)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if peerClosed(raw, buf) {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		// После возврата fasthttp снова читает соединение сам
+		wg.Wait()
+	}
+}
+
+// rawConn возвращает сокет соединения или nil, если доступа к нему нет.
+func rawConn(conn net.Conn) syscall.RawConn {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	return raw
+}
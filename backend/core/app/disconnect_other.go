@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package app
+
+import "syscall"
+
+const canPeek = false
+
+func peerClosed(raw syscall.RawConn, buf []byte) bool {
+	return false
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// tcpPair возвращает серверную и клиентскую стороны TCP-соединения.
+func tcpPair(t *testing.T) (server, client net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err = net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	server, err = listener.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return server, client
+}
+
+func TestWatchDisconnectCancelsOnClose(t *testing.T) {
+	if !canPeek {
+		t.Skip("MSG_PEEK is not supported on this platform")
+	}
+	server, client := tcpPair(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := watchDisconnect(server, cancel)
+	defer stop()
+	client.Close()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * disconnectPollInterval):
+		t.Fatal("context was not cancelled after the client closed the connection")
+	}
+}
+
+func TestWatchDisconnectKeepsPendingData(t *testing.T) {
+	if !canPeek {
+		t.Skip("MSG_PEEK is not supported on this platform")
+	}
+	server, client := tcpPair(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := watchDisconnect(server, cancel)
+	// Следующий запрос в том же соединении не означает отключения
+	if _, err := client.Write([]byte("GET")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	time.Sleep(3 * disconnectPollInterval)
+	stop()
+
+	if ctx.Err() != nil {
+		t.Fatal("context was cancelled while the client is connected")
+	}
+	buf := make([]byte, 3)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(server, buf); err != nil || string(buf) != "GET" {
+		t.Errorf("read after watching = %q, %v; want the pending bytes intact", buf, err)
+	}
+}
+
+func TestWatchDisconnectWithoutSocket(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	stop := watchDisconnect(server, func() { t.Error("cancel called for a connection without a socket") })
+	stop()
+}
@@ -0,0 +1,30 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package app
+
+import (
+	"errors"
+	"syscall"
+)
+
+const canPeek = true
+
+// peerClosed без блокировки заглядывает в сокет: клиент закрыл соединение,
+// если чтение вернуло конец потока или ошибку, кроме отсутствия данных.
+// Данные в сокете (следующий запрос в том же соединении) закрытием не
+// считаются.
+func peerClosed(raw syscall.RawConn, buf []byte) bool {
+	var closed bool
+	err := raw.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case err == nil:
+			closed = n == 0
+		case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EWOULDBLOCK), errors.Is(err, syscall.EINTR):
+		default:
+			closed = true
+		}
+		return true
+	})
+	return closed || err != nil
+}
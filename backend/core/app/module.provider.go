@@ -1,11 +1,15 @@
 package app
 
 import (
+	group_module "root/module/group"
+	job_module "root/module/job"
 	song_module "root/module/song"
 )
 
 type moduleProvider struct {
-	song *song_module.SongModule
+	job   *job_module.JobModule
+	song  *song_module.SongModule
+	group *group_module.GroupModule
 
 	app *App
 }
@@ -24,7 +28,9 @@ func NewModuleProvider(app *App) (*moduleProvider, error) {
 
 func (p *moduleProvider) initDeps() error {
 	inits := []func() error{
+		p.JobModule,
 		p.SongModule,
+		p.GroupModule,
 	}
 	for _, init := range inits {
 		err := init()
@@ -35,7 +41,17 @@ func (p *moduleProvider) initDeps() error {
 	return nil
 }
 
+func (p *moduleProvider) JobModule() error {
+	p.job = job_module.NewJobModule(p.app.logger, p.app.config, p.app.db)
+	return nil
+}
+
 func (p *moduleProvider) SongModule() error {
-	p.song = song_module.NewSongModule(p.app.logger, p.app.config, p.app.db)
+	p.song = song_module.NewSongModule(p.app.logger, p.app.config, p.app.cluster, p.job.JobService())
+	return nil
+}
+
+func (p *moduleProvider) GroupModule() error {
+	p.group = group_module.NewGroupModule(p.app.logger, p.app.config, p.app.cluster, p.song.SongService())
 	return nil
 }
@@ -23,9 +23,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/song": {
-            "get": {
-                "description": "Возвращает список песен с поддержкой фильтрации и пагинации. Фильтрация доступна по группе, названию песни, дате релиза, тексту песни и ссылке. Пагинация позволяет указать смещение и количество записей на странице.",
+        "/api/admin/cache/song-details": {
+            "delete": {
+                "description": "Удаляет закэшированный ответ внешнего API (включая закэшированный ответ 404) для указанной группы и песни из постоянного хранилища и из памяти всех экземпляров сервера. Сравнение выполняется без учёта регистра и крайних пробелов.",
                 "consumes": [
                     "application/json"
                 ],
@@ -33,15 +33,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Песни"
+                    "Администрирование"
                 ],
-                "summary": "Получение списка песен",
+                "summary": "Сброс кэша деталей песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название группы.",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название песни.",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Кэш сброшен.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Не указано название группы или песни.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/songs/duplicates": {
+            "get": {
+                "description": "Возвращает пары песен одной группы, названия которых похожи по триграммам pg_trgm не меньше порога threshold, начиная с самых похожих. Точные дубликаты (без учёта регистра и крайних пробелов) не допускаются уникальным ключом, поэтому здесь находятся опечатки и варианты написания. Песни в корзине не учитываются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Поиск возможных дубликатов песен",
                 "parameters": [
                     {
+                        "type": "number",
+                        "default": 0.6,
+                        "description": "Минимальное сходство названий от 0 до 1. По умолчанию 0.6.",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Искать только в указанной группе.",
+                        "name": "group_id",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
                         "minimum": 0,
                         "type": "integer",
                         "default": 0,
-                        "description": "Смещение для пагинации. Указывает, сколько записей пропустить перед началом выборки. По умолчанию 0.",
+                        "description": "Смещение для пагинации. По умолчанию 0.",
                         "name": "offset",
                         "in": "query"
                     },
@@ -50,70 +112,176 @@ const docTemplate = `{
                         "minimum": 1,
                         "type": "integer",
                         "default": 10,
-                        "description": "Количество записей на странице. Определяет, сколько записей вернуть в ответе. По умолчанию 10.",
+                        "description": "Количество пар на странице. По умолчанию 10.",
                         "name": "limit",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пары похожих песен со степенью сходства.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.DuplicateCandidate"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Фильтр по названию группы. Возвращает песни, где название группы содержит указанную строку.",
-                        "name": "group",
-                        "in": "query"
+                    "400": {
+                        "description": "Некорректный порог, ID группы, offset или limit.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
                     },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/songs/shards": {
+            "get": {
+                "description": "Для каждой таблицы songs_N возвращает количество песен (всего, вне корзины и в корзине), долю от всех песен, размер таблицы и индексов в байтах, самые большие группы и время последнего изменения песен по истории. Skew - отношение самого большого шарда к среднему (1 - равномерно). Шарды, доля которых больше warn_share, перечисляются в warnings.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование"
+                ],
+                "summary": "Статистика и перекос шардов",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Фильтр по названию песни. Возвращает песни, где название песни содержит указанную строку.",
-                        "name": "song",
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Количество самых больших групп на шард. По умолчанию 5.",
+                        "name": "top",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Фильтр по дате релиза. Возвращает песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD.",
-                        "name": "release_date",
+                        "type": "number",
+                        "description": "Доля песен в шарде от 0 до 1, выше которой выдаётся предупреждение. По умолчанию SHARD_SKEW_WARN_SHARE.",
+                        "name": "warn_share",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статистика шардов.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ShardReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный top или warn_share.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
                     },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/group": {
+            "get": {
+                "description": "Возвращает группы, упорядоченные по названию, и общее число групп.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Группы"
+                ],
+                "summary": "Получение списка групп",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Фильтр по тексту песни. Возвращает песни, где текст песни содержит указанную строку.",
-                        "name": "text",
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. По умолчанию 0.",
+                        "name": "offset",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Фильтр по ссылке. Возвращает песни, где ссылка содержит указанную строку.",
-                        "name": "link",
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество записей на странице. По умолчанию 10.",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список песен. Каждая песня представлена в виде объекта с полями: id, group_id, group, song, release_date, text, link.",
+                        "description": "Страница групп.",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.GroupPage"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Неверный запрос. Возможные причины:",
+                        "description": "Некорректный формат параметров offset или limit.",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера. Возможные причины:",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Добавляет новую песню в систему. Для добавления необходимо указать название группы и название песни.",
+                "description": "Создаёт группу без песен. Название должно быть уникальным.",
                 "consumes": [
                     "application/json"
                 ],
@@ -121,48 +289,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Песни"
+                    "Группы"
                 ],
-                "summary": "Добавление новой песни",
+                "summary": "Создание группы",
                 "parameters": [
                     {
-                        "description": "Данные для добавления песни. Должен быть объектом JSON, содержащим поля group и song.",
+                        "description": "Название группы.",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.GroupInput"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Успешный ответ. Возвращает сообщение об успешном добавлении.",
+                        "description": "Созданная группа.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Неверный запрос. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Группа с таким названием уже существует.",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     }
                 }
             }
         },
-        "/api/song/{id}": {
+        "/api/group/{id}": {
             "get": {
-                "description": "Возвращает текст песни с поддержкой пагинации. Текст разбит на секции (куплеты, припевы), и можно указать страницу и количество строк на странице.",
+                "description": "Возвращает группу по её идентификатору.",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,64 +353,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Песни"
+                    "Группы"
                 ],
-                "summary": "Получение текста песни",
+                "summary": "Получение группы",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "type": "integer",
+                        "description": "ID группы.",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Страница текста. Указывает, какую страницу текста вернуть. По умолчанию 1.",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 10,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Количество строк текста на странице. Определяет, сколько строк текста вернуть. По умолчанию 1.",
-                        "name": "limit",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ. Возвращает текст песни, разбитый на секции.",
+                        "description": "Группа.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Неверный запрос. Возможные причины:",
+                        "description": "Некорректный ID группы.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена. Возможные причины:",
+                        "description": "Группа с указанным ID не найдена.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Обновляет данные песни по её уникальному идентификатору (ID). Можно обновить одно или несколько полей песни.",
+                "description": "Переименовывает группу и в той же транзакции обновляет название группы у всех её песен во всех шардах. Изменение записывается в историю каждой песни.",
                 "consumes": [
                     "application/json"
                 ],
@@ -235,57 +413,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Песни"
+                    "Группы"
                 ],
-                "summary": "Обновление данных песни",
+                "summary": "Переименование группы",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "type": "integer",
+                        "description": "ID группы.",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные для обновления. Должен быть объектом JSON, содержащим поля для обновления (например, group, song, release_date, text, link).",
+                        "description": "Новое название группы.",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.GroupInput"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песен. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ. Возвращает сообщение об успешном обновлении.",
+                        "description": "Переименованная группа.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Group"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Неверный запрос. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена. Возможные причины:",
+                        "description": "Группа с указанным ID не найдена.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Другая группа с таким названием уже существует.",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Удаляет песню по её уникальному идентификатору (ID). После удаления песня больше не будет доступна в системе.",
+                "description": "Удаляет группу. Если у группы есть песни, удаление отклоняется, пока не указан cascade=true; в этом случае песни переносятся в корзину с записью в историю. Восстановление песни из корзины возвращает и её группу.",
                 "consumes": [
                     "application/json"
                 ],
@@ -293,59 +494,2172 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Песни"
+                    "Группы"
                 ],
-                "summary": "Удаление песни",
+                "summary": "Удаление группы",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "type": "integer",
+                        "description": "ID группы.",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Удалить группу вместе с её песнями. По умолчанию false.",
+                        "name": "cascade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песен. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ. Возвращает сообщение об успешном удалении.",
+                        "description": "Группа удалена. Возвращает число перенесённых в корзину песен.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Неверный запрос. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена. Возможные причины:",
+                        "description": "Группа с указанным ID не найдена.",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "У группы есть песни, а cascade не указан.",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     },
                     "500": {
                         "description": "Ошибка сервера. Возможные причины:",
                         "schema": {
-                            "$ref": "#/definitions/song_controller.Response"
+                            "$ref": "#/definitions/group_controller.Response"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "song_controller.Response": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "message": {
-                    "type": "string"
-                },
-                "success": {
-                    "type": "boolean"
-                }
-            }
+        },
+        "/api/group/{id}/songs": {
+            "get": {
+                "description": "Возвращает песни группы по id с курсорной пагинацией, как GET /api/song?group_id={id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Группы"
+                ],
+                "summary": "Получение песен группы",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID группы.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. Нельзя использовать вместе с cursor. По умолчанию 0.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество записей на странице. По умолчанию 10.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из поля next_cursor предыдущего ответа.",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница песен группы.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/group_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SongPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа с указанным ID не найдена.",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/group_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs/{id}": {
+            "get": {
+                "description": "Возвращает состояние фоновой задачи, созданной запросом POST /api/song: pending - ожидает в очереди, running - выполняется, succeeded - песня добавлена (song_id содержит её ID), failed - задача завершилась ошибкой (причина в поле error). Задача, упавшая из-за временной недоступности внешнего API или базы данных, возвращается в pending и повторяется с растущей паузой не раньше run_after; attempts - число сделанных попыток. В failed задача переходит, если песни нет во внешнем API, его ответ некорректен или попытки (JOB_MAX_ATTEMPTS) исчерпаны: такую задачу клиент может только отправить заново.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Задачи"
+                ],
+                "summary": "Статус задачи добавления песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи из ответа POST /api/song.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние задачи.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/job_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Job"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID задачи.",
+                        "schema": {
+                            "$ref": "#/definitions/job_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача с указанным ID не найдена.",
+                        "schema": {
+                            "$ref": "#/definitions/job_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/job_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song": {
+            "get": {
+                "description": "Возвращает список песен с поддержкой фильтрации, сортировки и пагинации. Текстовые поля group, song, text и link фильтруются по подстроке без учёта регистра; суффикс _exact задаёт точное совпадение, _prefix - совпадение по началу строки (например, song_prefix=Sup). Неизвестные параметры фильтрации и сортировки отклоняются. Для перехода на следующую страницу используйте курсор next_cursor из ответа; offset поддерживается для небольших смещений (не больше 1000).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Получение списка песен",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. Указывает, сколько записей пропустить перед началом выборки. Нельзя использовать вместе с cursor. По умолчанию 0.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество записей на странице. Определяет, сколько записей вернуть в ответе. По умолчанию 10.",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Непрозрачный курсор следующей страницы из поля next_cursor предыдущего ответа. Действителен только с той же сортировкой.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию группы (подстрока). Также доступны group_exact и group_prefix.",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию песни (подстрока). Также доступны song_exact и song_prefix.",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по тексту песни (подстрока). Также доступны text_exact и text_prefix.",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по ссылке (подстрока). Также доступны link_exact и link_prefix.",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Фильтр по ID группы.",
+                        "name": "group_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные не раньше указанной даты. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные не позже указанной даты. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id:asc",
+                        "description": "Сортировка в формате поле:asc|desc. Поля: id, group, song, release_date. По умолчанию id:asc.",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница песен и курсор следующей страницы (next_cursor отсутствует на последней странице).",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SongPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Ставит в очередь задачу на добавление песни и сразу возвращает её ID. Для добавления необходимо указать название группы и название песни. Дата релиза, текст и ссылка запрашиваются во внешнем API в фоне; результат можно узнать через GET /api/jobs/{id} (адрес также возвращается в заголовке Location). Если в группе уже есть песня с таким названием (без учёта регистра и крайних пробелов), задача не ставится: ответ 200 содержит существующую песню, её адрес в Content-Location и версию в ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Добавление новой песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для добавления песни. Должен быть объектом JSON, содержащим поля group и song.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня уже существует. Возвращает существующую песню.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Song"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "202": {
+                        "description": "Задача принята. Возвращает задачу в статусе pending.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Job"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/export": {
+            "get": {
+                "description": "Потоково выгружает все песни из всех шардов вместе с группой в формате CSV (с заголовком), NDJSON (по объекту на строку) или JSON-массива. Поддерживает те же фильтры и сортировку, что и GET /api/song, но без пагинации. Все шарды читаются одним согласованным снимком. Если клиент передаёт Accept-Encoding: gzip, ответ сжимается. Ответ начинается до окончания выборки, поэтому ошибка во время выгрузки обрывает тело: JSON-массив в этом случае остаётся незакрытым.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Выгрузка песен",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Формат выгрузки: csv, ndjson или json. По умолчанию json.",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию группы (подстрока). Также доступны group_exact и group_prefix.",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию песни (подстрока). Также доступны song_exact и song_prefix.",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по тексту песни (подстрока). Также доступны text_exact и text_prefix.",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по ссылке (подстрока). Также доступны link_exact и link_prefix.",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Фильтр по ID группы.",
+                        "name": "group_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные не раньше указанной даты. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Песни, выпущенные не позже указанной даты. Формат даты: YYYY-MM-DD.",
+                        "name": "release_date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id:asc",
+                        "description": "Сортировка в формате поле:asc|desc. Поля: id, group, song, release_date. По умолчанию id:asc.",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "gzip для сжатого ответа.",
+                        "name": "Accept-Encoding",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни в выбранном формате. Колонки CSV: id, group_id, group, song, release_date, text, link, version.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ExportSong"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/import": {
+            "post": {
+                "description": "Потоково читает тело запроса в формате CSV (с заголовком) или NDJSON (по объекту на строку) и записывает песни пачками в шарды их групп; отсутствующие группы создаются. Колонки и ключи: group, song, release_date (YYYY-MM-DD), text, link, enrich. Если enrich=true, пустые release_date, text и link запрашиваются во внешнем API. Песня той же группы с тем же названием (без учёта регистра) пропускается как дубликат. Ответ содержит результат каждой строки: created, skipped или error с причиной. В режиме dry_run строки только проверяются, ничего не записывается.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Массовый импорт песен",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат тела: csv или ndjson. По умолчанию определяется по Content-Type.",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Только проверить строки, не записывая их. По умолчанию false.",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Строки CSV или NDJSON.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчёт об импорте по строкам.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ImportReport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/search": {
+            "get": {
+                "description": "Ищет песни по названию и тексту во всех шардах с учётом морфологии русского и английского языков. Результаты упорядочены по релевантности (ts_rank), для каждого совпадения возвращается фрагмент текста, где найденные слова обёрнуты в \u003cb\u003e\u003c/b\u003e. Запрос поддерживает синтаксис websearch: фразы в кавычках, OR и исключение через минус.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Полнотекстовый поиск по текстам песен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос.",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. По умолчанию 0.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество результатов на странице. По умолчанию 10.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найденные песни с релевантностью и подсвеченным фрагментом текста.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.SongSearchHit"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/trash": {
+            "get": {
+                "description": "Возвращает удалённые песни всех шардов, начиная с удалённых последними. Песни хранятся в корзине в течение SONG_TRASH_RETENTION, затем удаляются окончательно.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Корзина удалённых песен",
+                "parameters": [
+                    {
+                        "maximum": 1000,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. По умолчанию 0.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество записей на странице. По умолчанию 10.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Удалённые песни с датой удаления deleted_at.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.Song"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат параметров offset или limit.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}": {
+            "get": {
+                "description": "Возвращает страницу текста песни. Текст разбит на секции по разметке, которая определяется автоматически: русские маркеры (Куплет 1, Припев), английские метки в квадратных скобках ([Verse 1], [Chorus], [Bridge]) или строфы, разделённые пустыми строками. У каждой секции есть тип (verse, chorus, bridge и т.д.), номер, исходная метка и текст. Ответ содержит название песни, группу, общее число секций, номер и размер страницы и признак has_next. Заголовок ETag содержит версию песни для условных запросов PATCH, PUT и DELETE с If-Match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Получение текста песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Страница текста. Указывает, какую страницу текста вернуть. По умолчанию 1.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 10,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Количество секций текста на странице. По умолчанию 1.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ. Возвращает страницу секций текста песни.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SongLyricsPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия песни."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Частично обновляет песню по её уникальному идентификатору (ID): изменяются только переданные поля. Допустимые поля: group_id, song, text, link, release_date. Поле id неизменяемо, название группы group следует за group_id (переименование группы - через PUT /api/group/{id}); неизвестные поля и null отклоняются. При смене group_id песня переносится в шард новой группы. PUT принимается как синоним PATCH. С заголовком If-Match изменение применяется, только если версия песни совпадает с ETag; каждое изменение увеличивает версию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Обновление данных песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни из ответа GET /api/song/{id}.",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля песни. Дата релиза в формате YYYY-MM-DD, ссылка - абсолютный http(s) URL.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ. Возвращает обновлённую песню.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Song"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "В группе уже есть песня с таким названием.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match: песню изменили после получения ETag.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Переносит песню в корзину по её уникальному идентификатору (ID). Песня пропадает из списков, поиска и текстов, но её можно восстановить через POST /api/song/{id}/restore, пока она не удалена окончательно по истечении срока хранения корзины. С заголовком If-Match песня удаляется, только если её версия совпадает с ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Удаление песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни из ответа GET /api/song/{id}.",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ. Возвращает сообщение об успешном удалении.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match: песню изменили после получения ETag.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Частично обновляет песню по её уникальному идентификатору (ID): изменяются только переданные поля. Допустимые поля: group_id, song, text, link, release_date. Поле id неизменяемо, название группы group следует за group_id (переименование группы - через PUT /api/group/{id}); неизвестные поля и null отклоняются. При смене group_id песня переносится в шард новой группы. PUT принимается как синоним PATCH. С заголовком If-Match изменение применяется, только если версия песни совпадает с ETag; каждое изменение увеличивает версию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Обновление данных песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни. Уникальный идентификатор песни в системе.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни из ответа GET /api/song/{id}.",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Изменяемые поля песни. Дата релиза в формате YYYY-MM-DD, ссылка - абсолютный http(s) URL.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ. Возвращает обновлённую песню.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Song"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "В группе уже есть песня с таким названием.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match: песню изменили после получения ETag.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/history": {
+            "get": {
+                "description": "Возвращает ревизии песни, начиная с последней. Каждая ревизия содержит полный снимок песни после изменения, изменённые поля (diff), действие, автора и время. Номер ревизии совпадает с версией песни (ETag); переименование группы увеличивает версию без ревизии, поэтому в номерах возможны пропуски.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "История изменений песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Смещение для пагинации. По умолчанию 0.",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество ревизий на странице. По умолчанию 10.",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ревизии песни.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.SongRevision"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/lyrics/synced": {
+            "get": {
+                "description": "Возвращает строки текста песни с моментом начала каждой строки и обычный текст, собранный из них. Источник - загруженный через PUT текст LRC (source=uploaded) или текст самой песни, размеченный метками [mm:ss.xx] (source=text). С параметром at вместо всех строк возвращается строка, звучащая в этот момент воспроизведения, и следующая за ней (data типа dto.SyncedLyricsAt); до первой строки поле line пустое.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Синхронизированный текст песни (LRC)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Позиция воспроизведения: секунды (83.5) или mm:ss.xx (01:23.50).",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Синхронизированный текст или строка в момент at.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SyncedLyrics"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID песни или параметр at.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена или у неё нет синхронизированного текста.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Разбирает тело запроса в формате LRC и заменяет им синхронизированный текст песни. Поддерживаются метки [mm:ss], [mm:ss.xx] и [mm:ss.xxx], несколько меток в одной строке, тег [offset:мс] и пословные метки \u003cmm:ss.xx\u003e, которые отбрасываются. Остальные теги метаданных и строки без меток пропускаются. Текст самой песни не меняется.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Загрузка синхронизированного текста (LRC)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст в формате LRC.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сохранённый синхронизированный текст.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SyncedLyrics"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID песни или в тексте нет строк с метками времени.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет загруженный через PUT текст LRC. Если текст самой песни размечен метками времени, GET продолжит отдавать строки из него.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Удаление синхронизированного текста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Синхронизированный текст удалён.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID песни.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "У песни нет загруженного синхронизированного текста.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/restore": {
+            "post": {
+                "description": "Возвращает удалённую песню из корзины. Версия песни увеличивается, новый ETag возвращается в заголовке.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Восстановление песни из корзины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная песня.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Song"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID песни.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня с указанным ID не найдена или уже удалена окончательно.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня не находится в корзине, в группе уже есть песня с таким названием или название удалённой группы песни заняла другая группа.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/revert/{revision}": {
+            "post": {
+                "description": "Возвращает группу, название, текст, ссылку и дату релиза песни к снимку указанной ревизии. Откат выполняется как обычное изменение: с проверкой If-Match, переносом в шард группы и новой ревизией с действием revert.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Песни"
+                ],
+                "summary": "Откат песни к ревизии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни.",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер ревизии из истории песни.",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни из ответа GET /api/song/{id}.",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории песни. По умолчанию anonymous.",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня после отката.",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/song_controller.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.Song"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия песни."
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный запрос. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдены.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "В группе уже есть песня с названием из ревизии.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия песни не совпадает с If-Match.",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера. Возможные причины:",
+                        "schema": {
+                            "$ref": "#/definitions/song_controller.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.DuplicateCandidate": {
+            "type": "object",
+            "properties": {
+                "duplicate_id": {
+                    "type": "string"
+                },
+                "duplicate_song": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "similarity": {
+                    "type": "number"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExportSong": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FieldChange": {
+            "type": "object",
+            "properties": {
+                "from": {},
+                "to": {}
+            }
+        },
+        "dto.Group": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GroupInput": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GroupPage": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Group"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ImportRowResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/dto.ImportStatus"
+                }
+            }
+        },
+        "dto.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "skipped",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "ImportCreated",
+                "ImportSkipped",
+                "ImportFailed"
+            ]
+        },
+        "dto.Job": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "run_after": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/dto.JobStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.JobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "JobPending",
+                "JobRunning",
+                "JobSucceeded",
+                "JobFailed"
+            ]
+        },
+        "dto.RevisionAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "restore",
+                "revert"
+            ],
+            "x-enum-varnames": [
+                "RevisionCreate",
+                "RevisionUpdate",
+                "RevisionDelete",
+                "RevisionRestore",
+                "RevisionRevert"
+            ]
+        },
+        "dto.RevisionDiff": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/dto.FieldChange"
+            }
+        },
+        "dto.ShardGroupStats": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ShardReport": {
+            "type": "object",
+            "properties": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "max_share": {
+                    "type": "number"
+                },
+                "shards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ShardStats"
+                    }
+                },
+                "skew": {
+                    "type": "number"
+                },
+                "total_rows": {
+                    "type": "integer"
+                },
+                "warn_share": {
+                    "type": "number"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.ShardStats": {
+            "type": "object",
+            "properties": {
+                "index_bytes": {
+                    "type": "integer"
+                },
+                "last_write_at": {
+                    "type": "string"
+                },
+                "live_rows": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "shard": {
+                    "type": "integer"
+                },
+                "share": {
+                    "type": "number"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "table_bytes": {
+                    "type": "integer"
+                },
+                "top_groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ShardGroupStats"
+                    }
+                },
+                "trashed_rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Song": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "groupid": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "releasedate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SongLyricsPage": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/song_lyrics.Section"
+                    }
+                },
+                "song": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "total_sections": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SongPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Song"
+                    }
+                }
+            }
+        },
+        "dto.SongPatch": {
+            "type": "object",
+            "properties": {
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SongRevision": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/dto.RevisionAction"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "$ref": "#/definitions/dto.RevisionDiff"
+                },
+                "snapshot": {
+                    "$ref": "#/definitions/dto.SongSnapshot"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SongSearchHit": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "groupid": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "releasedate": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SongSnapshot": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SyncedLyrics": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SyncedLyricsLine"
+                    }
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SyncedLyricsLine": {
+            "type": "object",
+            "properties": {
+                "at_ms": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "group_controller.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "job_controller.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "song_controller.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "song_lyrics.Section": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/song_lyrics.SectionKind"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "ordinal": {
+                    "type": "integer"
+                }
+            }
+        },
+        "song_lyrics.SectionKind": {
+            "type": "string",
+            "enum": [
+                "verse",
+                "pre-chorus",
+                "chorus",
+                "post-chorus",
+                "bridge",
+                "hook",
+                "intro",
+                "outro",
+                "interlude",
+                "stanza",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "KindVerse",
+                "KindPreChorus",
+                "KindChorus",
+                "KindPostChorus",
+                "KindBridge",
+                "KindHook",
+                "KindIntro",
+                "KindOutro",
+                "KindInterlude",
+                "KindStanza",
+                "KindOther"
+            ]
         }
     }
 }`
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
 	"root/core/app"
 
 	_ "github.com/lib/pq"
-	_"root/module/song/controller"
-
+	_ "root/module/song/controller"
 )
 
 // @title Song Library API
@@ -16,6 +20,23 @@ import (
 // @host localhost:3000
 // @BasePath /
 func main() {
+	// reshard -shards N переносит песни в N шардов, reshard -abort отменяет
+	// незавершённый решардинг
+	if len(os.Args) > 1 && os.Args[1] == "reshard" {
+		reshard(os.Args[2:])
+		return
+	}
+	// migrate up|down|goto|status управляет версиями схемы базы
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+	// shard-stats печатает статистику и перекос шардов
+	if len(os.Args) > 1 && os.Args[1] == "shard-stats" {
+		shardStats(os.Args[2:])
+		return
+	}
+
 	a := app.NewApp()
 	err := a.Run()
 	if err != nil {
@@ -24,4 +45,66 @@ func main() {
 
 }
 
+func reshard(args []string) {
+	flags := flag.NewFlagSet("reshard", flag.ExitOnError)
+	shards := flags.Int("shards", 0, "new number of song shards")
+	abort := flags.Bool("abort", false, "abort unfinished resharding")
+	flags.Parse(args)
 
+	if *shards == 0 && !*abort {
+		fmt.Fprintln(os.Stderr, "usage: reshard -shards N | reshard -abort")
+		os.Exit(2)
+	}
+
+	if err := app.NewApp().Reshard(*shards, *abort); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func shardStats(args []string) {
+	flags := flag.NewFlagSet("shard-stats", flag.ExitOnError)
+	top := flags.Int("top", 5, "number of largest groups per shard")
+	warnShare := flags.Float64("warn-share", 0, "share of songs in one shard to warn about, SHARD_SKEW_WARN_SHARE by default")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	flags.Parse(args)
+
+	if *top < 1 || *warnShare < 0 || *warnShare > 1 {
+		fmt.Fprintln(os.Stderr, "usage: shard-stats [-top N] [-warn-share 0..1] [-json]")
+		os.Exit(2)
+	}
+
+	if err := app.NewApp().ShardStats(os.Stdout, *top, *warnShare, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func migrate(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up [VERSION] | down [STEPS] | goto VERSION | status")
+		os.Exit(2)
+	}
+	if len(args) == 0 || len(args) > 2 {
+		usage()
+	}
+
+	var arg int64
+	if len(args) == 2 {
+		value, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || value < 0 {
+			usage()
+		}
+		arg = value
+	}
+	switch {
+	case args[0] == "goto" && len(args) != 2,
+		args[0] == "status" && len(args) != 1:
+		usage()
+	}
+
+	if err := app.NewApp().Migrate(os.Stdout, args[0], arg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"time"
 
 	"root/shared/logger"
@@ -12,7 +13,31 @@ import (
 	"gorm.io/sharding"
 )
 
-const NumShards = 4 // Количество шардов
+// ShardIndex возвращает номер шарда песен группы. Это единственное место, где
+// задано правило шардирования: его используют и плагин gorm.io/sharding,
+// и прямые запросы к таблицам songs_N.
+func ShardIndex(groupID int) int {
+	return groupID % NumShards()
+}
+
+// ShardTable возвращает имя таблицы songs для шарда с номером shard.
+func ShardTable(shard int) string {
+	return fmt.Sprintf("songs_%d", shard)
+}
+
+// GroupShardTable возвращает таблицу songs, в которой лежат песни группы.
+func GroupShardTable(groupID int) string {
+	return ShardTable(ShardIndex(groupID))
+}
+
+// ShardTables возвращает имена всех таблиц songs в порядке номеров шардов.
+func ShardTables() []string {
+	tables := make([]string, NumShards())
+	for i := range tables {
+		tables[i] = ShardTable(i)
+	}
+	return tables
+}
 
 func ConnectDb(url string, log *logger.Logger) (*gorm.DB, error) {
 	log.Debug("🔍 Starting database connection process")
@@ -40,12 +65,7 @@ func ConnectDb(url string, log *logger.Logger) (*gorm.DB, error) {
 
 	// Включаем шардирование
 	log.Debug("⚡ Enabling database sharding")
-	err = db.Use(sharding.Register(sharding.Config{
-		ShardingKey:         "group_id",
-		NumberOfShards:      NumShards,
-		PrimaryKeyGenerator: sharding.PKSnowflake, // Генератор уникальных ID
-	}, "songs"))
-	if err != nil {
+	if err := useSharding(db); err != nil {
 		log.Errorf("❌ Failed to register sharding: %v", err)
 		return nil, err
 	}
@@ -75,3 +95,44 @@ func ConnectDb(url string, log *logger.Logger) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// useSharding подключает к db плагин шардирования таблицы songs.
+//
+// Плагин запоминает NumberOfShards при регистрации и после решардинга его не
+// обновляет, поэтому маршрутизация на это поле не опирается: ShardingAlgorithm
+// и ShardingSuffixs на каждом запросе читают NumShards, который WatchShards
+// обновляет из shard_config. NumberOfShards задан максимальным, чтобы плагин
+// проверил ограничение Snowflake для любого будущего количества шардов.
+func useSharding(db *gorm.DB) error {
+	return db.Use(sharding.Register(sharding.Config{
+		ShardingKey:         "group_id",
+		NumberOfShards:      MaxNumShards,
+		ShardingAlgorithm:   shardingAlgorithm,
+		ShardingSuffixs:     shardingSuffixes,
+		PrimaryKeyGenerator: sharding.PKSnowflake, // Генератор уникальных ID
+	}, "songs"))
+}
+
+// shardingAlgorithm переводит значение group_id в суффикс таблицы по ShardIndex.
+func shardingAlgorithm(value any) (string, error) {
+	var groupID int
+	switch v := value.(type) {
+	case int:
+		groupID = v
+	case int64:
+		groupID = int(v)
+	case int32:
+		groupID = int(v)
+	default:
+		return "", fmt.Errorf("unsupported group_id type %T", value)
+	}
+	return fmt.Sprintf("_%d", ShardIndex(groupID)), nil
+}
+
+func shardingSuffixes() []string {
+	suffixes := make([]string, NumShards())
+	for i := range suffixes {
+		suffixes[i] = fmt.Sprintf("_%d", i)
+	}
+	return suffixes
+}
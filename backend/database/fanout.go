@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// FanOut выполняет query для каждой таблицы шарда параллельно, не больше
+// limit запросов одновременно (limit <= 0 - без ограничения). Результаты
+// возвращаются в порядке tables. Первая ошибка отменяет ctx остальных
+// запросов; отмена или дедлайн ctx вызывающего прерывают все запросы.
+func FanOut[T any](ctx context.Context, limit int, tables []string, query func(ctx context.Context, tableName string) (T, error)) ([]T, error) {
+	results := make([]T, len(tables))
+
+	group, ctx := errgroup.WithContext(ctx)
+	if limit > 0 {
+		group.SetLimit(limit)
+	}
+	for i, tableName := range tables {
+		group.Go(func() error {
+			// Запросы, дождавшиеся очереди после ошибки, не начинаются
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, err := query(ctx, tableName)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFanOutKeepsTableOrder(t *testing.T) {
+	tables := []string{"songs_0", "songs_1", "songs_2", "songs_3"}
+
+	var running, peak atomic.Int32
+	got, err := FanOut(context.Background(), 2, tables, func(ctx context.Context, tableName string) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return tableName, nil
+	})
+	if err != nil {
+		t.Fatalf("FanOut error = %v", err)
+	}
+	if !reflect.DeepEqual(got, tables) {
+		t.Errorf("results = %v, want %v", got, tables)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", peak.Load())
+	}
+}
+
+func TestFanOutCancelsOnFirstError(t *testing.T) {
+	failed := errors.New("shard is down")
+
+	_, err := FanOut(context.Background(), 0, []string{"songs_0", "songs_1"}, func(ctx context.Context, tableName string) (int, error) {
+		if tableName == "songs_0" {
+			return 0, failed
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			t.Error("query was not cancelled after another shard failed")
+			return 0, nil
+		}
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("FanOut error = %v, want %v", err, failed)
+	}
+}
+
+func TestFanOutHonoursDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := FanOut(ctx, 1, []string{"songs_0", "songs_1"}, func(ctx context.Context, tableName string) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("FanOut error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
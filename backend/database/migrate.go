@@ -1,41 +1,474 @@
 package database
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	song_model "root/module/song/dto"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
 	"root/shared/logger"
 
 	"gorm.io/gorm"
 )
 
+// migrationFiles - SQL-миграции, встроенные в бинарник. Имя файла:
+// <версия>_<название>[.shards].<up|down>.sql. Миграции с .shards применяются
+// к каждой таблице songs_N как шаблоны text/template с полями Table, Shard и
+// Shards; остальные выполняются один раз.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFile разбирает имя файла миграции.
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)(\.shards)?\.(up|down)\.sql$`)
+
+// migrationLockKey - ключ advisory-блокировки: мигрирует только одна реплика,
+// остальные ждут и затем не находят неприменённых миграций.
+const migrationLockKey = 726_000
+
+var (
+	ErrUnknownMigration      = errors.New("unknown migration version")
+	ErrIrreversibleMigration = errors.New("migration has no down script")
+	ErrMigrationReshard      = errors.New("shard migrations are not allowed while resharding is in progress")
+)
+
+// Migration - одна версия схемы.
+type Migration struct {
+	Version int64
+	Name    string
+	// Shards - миграция применяется к каждой таблице songs_N.
+	Shards bool
+	up     *template.Template
+	down   *template.Template
+	// checksum - хэш up-скрипта: изменение применённой миграции видно в статусе.
+	checksum string
+}
+
+// SchemaMigration - применённая миграция.
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	Checksum  string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus - состояние версии схемы. Missing - версия применена, но
+// её файла нет в бинарнике; Modified - файл изменён после применения.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Shards    bool
+	AppliedAt *time.Time
+	Missing   bool
+	Modified  bool
+}
+
+// shardTemplate - данные шаблона миграции шардов.
+type shardTemplate struct {
+	Table  string
+	Shard  int
+	Shards int
+}
+
+// Migrate применяет все неприменённые миграции при старте приложения.
+func Migrate(ctx context.Context, db *gorm.DB, log *logger.Logger) error {
+	log.Info("📦 Starting database migration...")
+	applied, err := MigrateUp(ctx, db, 0, log)
+	if err != nil {
+		log.Errorf("✖ Failed to migrate database: %v", err)
+		return err
+	}
+	if applied == 0 {
+		log.Info("✅ Database schema is up to date")
+		return nil
+	}
+	log.Infof("✅ Applied %d migrations", applied)
+	return nil
+}
+
+// MigrateUp применяет неприменённые миграции по возрастанию версий до target
+// включительно; target = 0 - до последней. Возвращает число применённых.
+func MigrateUp(ctx context.Context, db *gorm.DB, target int64, log *logger.Logger) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if target != 0 && findMigration(migrations, target) == nil {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownMigration, target)
+	}
+
+	applied := 0
+	err = withMigrationLock(ctx, db, func(conn *gorm.DB) error {
+		applied, err = migrateUp(ctx, conn, migrations, target, log)
+		return err
+	})
+	return applied, err
+}
+
+// MigrateDown откатывает steps последних применённых миграций.
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int, log *logger.Logger) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	err = withMigrationLock(ctx, db, func(conn *gorm.DB) error {
+		reverted, err = migrateDown(ctx, conn, migrations, steps, log)
+		return err
+	})
+	return reverted, err
+}
+
+// MigrateGoto приводит схему к версии version: применяет миграции до неё или
+// откатывает все более новые. version = 0 откатывает все миграции. Весь план
+// выполняется под одной блокировкой, чтобы между подъёмом и откатом не
+// вклинился другой мигратор.
+func MigrateGoto(ctx context.Context, db *gorm.DB, version int64, log *logger.Logger) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+	if version != 0 && findMigration(migrations, version) == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+	}
+
+	return withMigrationLock(ctx, db, func(conn *gorm.DB) error {
+		if version != 0 {
+			if _, err := migrateUp(ctx, conn, migrations, version, log); err != nil {
+				return err
+			}
+		}
+
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		newer := 0
+		for applied := range versions {
+			if applied > version {
+				newer++
+			}
+		}
+		if newer == 0 {
+			return nil
+		}
+		_, err = migrateDown(ctx, conn, migrations, newer, log)
+		return err
+	})
+}
+
+// migrateUp применяет миграции до target на conn, уже держащем блокировку.
+func migrateUp(ctx context.Context, conn *gorm.DB, migrations []Migration, target int64, log *logger.Logger) (int, error) {
+	versions, err := appliedVersions(conn)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for i := range migrations {
+		migration := &migrations[i]
+		if target != 0 && migration.Version > target {
+			break
+		}
+		if _, ok := versions[migration.Version]; ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+
+		if err := applyMigration(conn, migration, true); err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied++
+		log.Infof("✅ Migration %d_%s applied", migration.Version, migration.Name)
+	}
+	return applied, nil
+}
+
+// migrateDown откатывает steps последних применённых миграций на conn, уже
+// держащем блокировку.
+func migrateDown(ctx context.Context, conn *gorm.DB, migrations []Migration, steps int, log *logger.Logger) (int, error) {
+	versions, err := appliedVersions(conn)
+	if err != nil {
+		return 0, err
+	}
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		migration := &migrations[i]
+		if _, ok := versions[migration.Version]; !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return reverted, err
+		}
+
+		if err := applyMigration(conn, migration, false); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted++
+		log.Infof("✅ Migration %d_%s reverted", migration.Version, migration.Name)
+	}
+	return reverted, nil
+}
+
+// MigrationStatuses возвращает все известные и применённые версии по возрастанию.
+func MigrationStatuses(ctx context.Context, db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaMigrations(db.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := db.WithContext(ctx).Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("не удалось загрузить применённые миграции: %w", err)
+	}
+	applied := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Shards: migration.Shards}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			status.Modified = row.Checksum != migration.checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Missing: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// LoadMigrations читает встроенные миграции, упорядоченные по версии.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		shards := match[3] != ""
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2], Shards: shards}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] || migration.Shards != shards {
+			return nil, fmt.Errorf("файлы миграции %d описывают разные миграции", version)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		script, err := template.New(entry.Name()).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("некорректный шаблон миграции %s: %w", entry.Name(), err)
+		}
+		if match[4] == "up" {
+			sum := sha256.Sum256(content)
+			migration.up, migration.checksum = script, hex.EncodeToString(sum[:])
+		} else {
+			migration.down = script
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == nil {
+			return nil, fmt.Errorf("у миграции %d_%s нет up-скрипта", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
 
-func Migrate(db *gorm.DB, trigger bool, log *logger.Logger) error {
-	if trigger {
-		log.Info("📦 Starting database migration...")
+// ApplyShardMigrations применяет к новой таблице шарда все применённые
+// миграции шардов. Так решардинг создаёт таблицы той же схемы, что и songs_N.
+func ApplyShardMigrations(db *gorm.DB, tableName string, shard, shards int) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
 
-		// Миграция основной таблицы Group
-		log.Debug("🔍 Migrating Group model")
-		if err := db.AutoMigrate(&song_model.Group{}); err != nil {
-			log.Errorf("✖ Failed to migrate Group table: %v", err)
+	return db.Transaction(func(tx *gorm.DB) error {
+		versions, err := appliedVersions(tx)
+		if err != nil {
 			return err
 		}
+		for _, migration := range migrations {
+			if _, ok := versions[migration.Version]; !ok || !migration.Shards {
+				continue
+			}
+			err := execTemplate(tx, migration.up, shardTemplate{Table: tableName, Shard: shard, Shards: shards})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s for %s: %w", migration.Version, migration.Name, tableName, err)
+			}
+		}
+		return nil
+	})
+}
 
-		// Миграция шардированных таблиц Song
-		log.Debug("🔍 Migrating Song model across shards")
-		for i := 0; i < NumShards; i++ {
-			tableName := fmt.Sprintf("songs_%d", i)
-			if err := db.Table(tableName).AutoMigrate(&song_model.Song{}); err != nil {
-				log.Errorf("✖ Failed to migrate shard table %s: %v", tableName, err)
+// applyMigration выполняет up- или down-скрипт и записывает версию одной
+// транзакцией: прерванная миграция не оставляет схему в промежуточном состоянии.
+func applyMigration(conn *gorm.DB, migration *Migration, up bool) error {
+	script := migration.up
+	if !up {
+		script = migration.down
+	}
+	if script == nil {
+		return ErrIrreversibleMigration
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if !migration.Shards {
+			if err := execTemplate(tx, script, nil); err != nil {
+				return err
+			}
+		} else {
+			shards, err := migrationShards(tx)
+			if err != nil {
 				return err
 			}
-			log.Infof("✅ Shard table %s migrated successfully", tableName)
+			for i := 0; i < shards; i++ {
+				if err := execTemplate(tx, script, shardTemplate{Table: ShardTable(i), Shard: i, Shards: shards}); err != nil {
+					return fmt.Errorf("%s: %w", ShardTable(i), err)
+				}
+			}
 		}
 
-		log.Info("✅ Database migration completed successfully")
-	} else {
-		log.Info("ℹ️ Migration trigger is disabled, skipping migration")
+		if !up {
+			return tx.Where("version = ?", migration.Version).Delete(&SchemaMigration{}).Error
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Checksum:  migration.checksum,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+// migrationShards возвращает количество шардов для миграции шардов. Во время
+// решардинга часть таблиц ещё не создана или будет заменена, поэтому такие
+// миграции откладываются до его завершения.
+func migrationShards(tx *gorm.DB) (int, error) {
+	var resharding bool
+	err := tx.Raw(`SELECT to_regclass('reshard_state') IS NOT NULL AND EXISTS (SELECT 1 FROM reshard_state)`).
+		Scan(&resharding).Error
+	if err != nil {
+		return 0, err
+	}
+	if resharding {
+		return 0, ErrMigrationReshard
+	}
+	return LoadShards(tx)
+}
+
+func execTemplate(tx *gorm.DB, script *template.Template, data any) error {
+	var sql bytes.Buffer
+	if err := script.Execute(&sql, data); err != nil {
+		return err
+	}
+	if strings.TrimSpace(sql.String()) == "" {
+		return nil
 	}
+	return tx.Exec(sql.String()).Error
+}
 
-	log.Info("✅ Database connection established successfully")
+func ensureSchemaMigrations(db *gorm.DB) error {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		checksum text NOT NULL,
+		applied_at timestamptz
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("не удалось создать таблицу schema_migrations: %w", err)
+	}
 	return nil
 }
+
+func appliedVersions(db *gorm.DB) (map[int64]struct{}, error) {
+	if err := ensureSchemaMigrations(db); err != nil {
+		return nil, err
+	}
+	var versions []int64
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, fmt.Errorf("не удалось загрузить применённые миграции: %w", err)
+	}
+	applied := make(map[int64]struct{}, len(versions))
+	for _, version := range versions {
+		applied[version] = struct{}{}
+	}
+	return applied, nil
+}
+
+func findMigration(migrations []Migration, version int64) *Migration {
+	for i := range migrations {
+		if migrations[i].Version == version {
+			return &migrations[i]
+		}
+	}
+	return nil
+}
+
+// withMigrationLock выполняет fn на отдельном соединении, дождавшись
+// advisory-блокировки миграций.
+func withMigrationLock(ctx context.Context, db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+		}
+		// Контекст может быть уже отменён, а блокировку нужно снять в любом случае
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+		return fn(conn)
+	})
+}
+
+// SongTitleKeySuffix - суффикс имени уникального индекса названий песен в
+// таблице шарда (миграция 0002), по нему узнаётся нарушение уникальности.
+const SongTitleKeySuffix = "_group_song_key"
+
+// GroupNameKey - уникальный индекс названий групп (миграция 0004).
+const GroupNameKey = "groups_name_key"
+
+// SearchConfig - конфигурация полнотекстового поиска Postgres, по которой
+// миграция 0002 строит search_vector. В конфигурации russian слова кириллицей
+// проходят через russian_stem, а латиница через english_stem, поэтому одна
+// конфигурация покрывает оба языка.
+const SearchConfig = "russian"
@@ -0,0 +1,254 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"root/shared/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is out of order after %d", migration.Version, migrations[i-1].Version)
+		}
+		if migration.checksum == "" {
+			t.Errorf("migration %d has no checksum", migration.Version)
+		}
+
+		var data any
+		if migration.Shards {
+			data = shardTemplate{Table: ShardTable(3), Shard: 3, Shards: 8}
+		}
+		for _, up := range []bool{true, false} {
+			sql, err := render(migration, up, data)
+			if err != nil {
+				t.Errorf("migration %d_%s (up: %v): %v", migration.Version, migration.Name, up, err)
+				continue
+			}
+			if migration.Shards && !strings.Contains(sql, "songs_3") {
+				t.Errorf("migration %d_%s (up: %v) does not use the shard table", migration.Version, migration.Name, up)
+			}
+		}
+	}
+}
+
+func TestShardMigrationNeedsTable(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("LoadMigrations error = %v", err)
+	}
+	for _, migration := range migrations {
+		if !migration.Shards {
+			continue
+		}
+		// Шаблон шардов без данных шарда не должен молча давать пустое имя таблицы
+		if _, err := render(migration, true, nil); err == nil {
+			t.Errorf("migration %d_%s rendered without shard data", migration.Version, migration.Name)
+		}
+	}
+}
+
+func render(migration Migration, up bool, data any) (string, error) {
+	script := migration.up
+	if !up {
+		script = migration.down
+	}
+	if script == nil {
+		return "", ErrIrreversibleMigration
+	}
+	var sql bytes.Buffer
+	err := script.Execute(&sql, data)
+	return sql.String(), err
+}
+
+// baselineSong - модель песни до версионных миграций: такие таблицы songs_N
+// создавал AutoMigrate, без версий и корзины.
+type baselineSong struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	GroupID     int       `gorm:"not null;index"`
+	Group       string    `gorm:"not null;index"`
+	Song        string    `gorm:"not null"`
+	Text        string    `gorm:"type:text;index"`
+	Link        string    `gorm:"type:text;index"`
+	ReleaseDate time.Time `gorm:"type:date;index"`
+}
+
+type baselineGroup struct {
+	ID   int `gorm:"primaryKey"`
+	Name string
+}
+
+func (baselineGroup) TableName() string {
+	return "groups"
+}
+
+// newBaselineDB создаёт во временной схеме базу, какой её оставлял AutoMigrate
+// до версионных миграций. Нужен Postgres: TEST_DATABASE_URL.
+func newBaselineDB(t *testing.T) (*gorm.DB, string) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	admin, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  url,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	// Одно соединение, чтобы search_path действовал на все запросы теста
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Exec("SET search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+
+	if err := db.AutoMigrate(&baselineGroup{}); err != nil {
+		t.Fatalf("baseline groups: %v", err)
+	}
+	for i := 0; i < DefaultNumShards; i++ {
+		if err := db.Table(ShardTable(i)).AutoMigrate(&baselineSong{}); err != nil {
+			t.Fatalf("baseline %s: %v", ShardTable(i), err)
+		}
+	}
+	return db, schema
+}
+
+// TestMigrateAdoptsBaselineSchema применяет миграции к базе, созданной
+// AutoMigrate до версионных миграций.
+func TestMigrateAdoptsBaselineSchema(t *testing.T) {
+	db, schema := newBaselineDB(t)
+	err := db.Table(ShardTable(1)).Create(&baselineSong{ID: uuid.New(), GroupID: 1, Group: "Muse", Song: "Uprising"}).Error
+	if err != nil {
+		t.Fatalf("baseline song: %v", err)
+	}
+
+	if err := Migrate(context.Background(), db, logger.GetLogger()); err != nil {
+		t.Fatalf("Migrate error = %v", err)
+	}
+
+	for i := 0; i < DefaultNumShards; i++ {
+		table := ShardTable(i)
+		var columns []string
+		err := db.Raw(`SELECT column_name FROM information_schema.columns
+			WHERE table_schema = ? AND table_name = ? AND column_name IN ('version', 'deleted_at')`, schema, table).
+			Scan(&columns).Error
+		if err != nil {
+			t.Fatalf("columns of %s: %v", table, err)
+		}
+		if len(columns) != 2 {
+			t.Errorf("%s columns = %v, want version and deleted_at", table, columns)
+		}
+
+		var indexes []string
+		err = db.Raw(`SELECT indexname FROM pg_indexes WHERE schemaname = ? AND tablename = ?`, schema, table).
+			Scan(&indexes).Error
+		if err != nil {
+			t.Fatalf("indexes of %s: %v", table, err)
+		}
+		for _, name := range indexes {
+			if name == "idx_"+table+"_text" || name == "idx_"+table+"_link" {
+				t.Errorf("%s still has btree index %s", table, name)
+			}
+		}
+	}
+
+	var located int64
+	if err := db.Table("song_locations").Count(&located).Error; err != nil {
+		t.Fatalf("song_locations: %v", err)
+	}
+	if located != 1 {
+		t.Errorf("song_locations rows = %d, want 1", located)
+	}
+}
+
+// TestMigrateRejectsDuplicateTitles проверяет, что миграция не удаляет
+// дубликаты названий сама, а падает со списком конфликтов и ничего не меняет.
+func TestMigrateRejectsDuplicateTitles(t *testing.T) {
+	db, _ := newBaselineDB(t)
+	for _, title := range []string{"Uprising", " uprising"} {
+		err := db.Table(ShardTable(1)).Create(&baselineSong{ID: uuid.New(), GroupID: 1, Group: "Muse", Song: title}).Error
+		if err != nil {
+			t.Fatalf("baseline song: %v", err)
+		}
+	}
+
+	err := Migrate(context.Background(), db, logger.GetLogger())
+	if err == nil || !strings.Contains(err.Error(), "(1, 'uprising')") {
+		t.Fatalf("Migrate error = %v, want the duplicate (1, 'uprising')", err)
+	}
+
+	var songs int64
+	if err := db.Table(ShardTable(1)).Count(&songs).Error; err != nil {
+		t.Fatalf("count songs: %v", err)
+	}
+	if songs != 2 {
+		t.Errorf("songs = %d, want both duplicates kept", songs)
+	}
+	var applied int64
+	if err := db.Table("schema_migrations").Where("version = 2").Count(&applied).Error; err != nil {
+		t.Fatalf("schema_migrations: %v", err)
+	}
+	if applied != 0 {
+		t.Error("migration 2 is recorded as applied despite the conflict")
+	}
+}
+
+// TestMigrateRejectsDuplicateGroupNames проверяет, что уникальный индекс
+// названий групп не создаётся поверх дубликатов и миграция их перечисляет.
+func TestMigrateRejectsDuplicateGroupNames(t *testing.T) {
+	db, _ := newBaselineDB(t)
+	for _, group := range []baselineGroup{{ID: 1, Name: "Muse"}, {ID: 2, Name: "Muse"}} {
+		if err := db.Create(&group).Error; err != nil {
+			t.Fatalf("baseline group: %v", err)
+		}
+	}
+
+	err := Migrate(context.Background(), db, logger.GetLogger())
+	if err == nil || !strings.Contains(err.Error(), "'Muse': 1, 2") {
+		t.Fatalf("Migrate error = %v, want the duplicate 'Muse': 1, 2", err)
+	}
+	var groups int64
+	if err := db.Model(&baselineGroup{}).Count(&groups).Error; err != nil {
+		t.Fatalf("count groups: %v", err)
+	}
+	if groups != 2 {
+		t.Errorf("groups = %d, want both duplicates kept", groups)
+	}
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS song_details_cache;
+DROP TABLE IF EXISTS song_synced_lines;
+DROP TABLE IF EXISTS song_revisions;
+DROP TABLE IF EXISTS song_locations;
+DROP TABLE IF EXISTS reshard_progress;
+DROP TABLE IF EXISTS reshard_state;
+DROP TABLE IF EXISTS shard_config;
+DROP TABLE IF EXISTS groups;
@@ -0,0 +1,106 @@
+-- Общие таблицы. IF NOT EXISTS позволяет принять базу, созданную до
+-- версионных миграций через AutoMigrate: её таблицы и индексы уже на месте.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS groups (
+	id bigserial PRIMARY KEY,
+	name text
+);
+
+-- До версионных миграций GORM называл эти таблицы во множественном числе
+DO $$
+BEGIN
+	IF to_regclass('shard_configs') IS NOT NULL AND to_regclass('shard_config') IS NULL THEN
+		ALTER TABLE shard_configs RENAME TO shard_config;
+	END IF;
+	IF to_regclass('reshard_states') IS NOT NULL AND to_regclass('reshard_state') IS NULL THEN
+		ALTER TABLE reshard_states RENAME TO reshard_state;
+	END IF;
+	IF to_regclass('reshard_progresses') IS NOT NULL AND to_regclass('reshard_progress') IS NULL THEN
+		ALTER TABLE reshard_progresses RENAME TO reshard_progress;
+	END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS shard_config (
+	id bigint PRIMARY KEY,
+	shards bigint NOT NULL,
+	updated_at timestamptz
+);
+-- Существующая база получает 4 шарда: столько таблиц songs_N создавалось до решардинга
+INSERT INTO shard_config (id, shards, updated_at) VALUES (1, 4, now()) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS reshard_state (
+	id bigint PRIMARY KEY,
+	source bigint NOT NULL,
+	target bigint NOT NULL,
+	phase text NOT NULL,
+	updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS reshard_progress (
+	source_table text PRIMARY KEY,
+	last_id uuid,
+	copied bigint NOT NULL DEFAULT 0,
+	done boolean NOT NULL DEFAULT false
+);
+
+-- Индекс id песни -> группа для прямой маршрутизации по шардам
+CREATE TABLE IF NOT EXISTS song_locations (
+	song_id uuid PRIMARY KEY,
+	group_id bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_song_locations_group_id ON song_locations (group_id);
+
+-- История изменений песен
+CREATE TABLE IF NOT EXISTS song_revisions (
+	id bigserial PRIMARY KEY,
+	song_id uuid NOT NULL,
+	version bigint NOT NULL,
+	action varchar(16) NOT NULL,
+	actor text NOT NULL,
+	snapshot jsonb NOT NULL,
+	diff jsonb NOT NULL,
+	created_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_song_revisions_song_version ON song_revisions (song_id, version);
+
+-- Синхронизированные тексты песен (LRC)
+CREATE TABLE IF NOT EXISTS song_synced_lines (
+	song_id uuid NOT NULL,
+	position bigint NOT NULL,
+	at_ms bigint NOT NULL,
+	text text NOT NULL DEFAULT '',
+	PRIMARY KEY (song_id, position)
+);
+
+-- Постоянный кэш ответов внешнего API
+CREATE TABLE IF NOT EXISTS song_details_cache (
+	key text PRIMARY KEY,
+	"group" text NOT NULL,
+	song text NOT NULL,
+	release_date text NOT NULL DEFAULT '',
+	text text NOT NULL DEFAULT '',
+	link text NOT NULL DEFAULT '',
+	not_found boolean NOT NULL DEFAULT false,
+	expires_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_song_details_cache_expires_at ON song_details_cache (expires_at);
+
+-- Очередь задач добавления песен
+CREATE TABLE IF NOT EXISTS jobs (
+	id uuid PRIMARY KEY,
+	status varchar(16) NOT NULL,
+	"group" text NOT NULL,
+	song text NOT NULL,
+	actor text NOT NULL DEFAULT '',
+	song_id uuid,
+	error text,
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	started_at timestamptz,
+	finished_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs (created_at);
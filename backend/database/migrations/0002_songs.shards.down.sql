@@ -0,0 +1,2 @@
+DELETE FROM song_locations WHERE song_id IN (SELECT id FROM {{.Table}});
+DROP TABLE IF EXISTS {{.Table}};
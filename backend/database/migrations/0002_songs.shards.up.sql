@@ -0,0 +1,68 @@
+-- Таблица шарда песен {{.Table}} ({{.Shard}} из {{.Shards}})
+CREATE TABLE IF NOT EXISTS {{.Table}} (
+	id uuid PRIMARY KEY,
+	group_id bigint NOT NULL,
+	"group" text NOT NULL,
+	song text NOT NULL,
+	text text,
+	link text,
+	release_date date,
+	version bigint NOT NULL DEFAULT 1,
+	deleted_at timestamptz
+);
+-- Таблицы, созданные AutoMigrate до версий и корзины, этих колонок не имеют
+ALTER TABLE {{.Table}} ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+ALTER TABLE {{.Table}} ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_{{.Table}}_group_id ON {{.Table}} (group_id);
+CREATE INDEX IF NOT EXISTS idx_{{.Table}}_group ON {{.Table}} ("group");
+CREATE INDEX IF NOT EXISTS idx_{{.Table}}_release_date ON {{.Table}} (release_date);
+CREATE INDEX IF NOT EXISTS idx_{{.Table}}_deleted_at ON {{.Table}} (deleted_at);
+-- B-tree индексы текста и ссылки из AutoMigrate: полный текст песни может не
+-- поместиться в запись индекса, а текст ищется только через search_vector
+DROP INDEX IF EXISTS idx_{{.Table}}_text;
+DROP INDEX IF EXISTS idx_{{.Table}}_link;
+
+-- Полнотекстовый поиск: название с весом A, текст с весом B. В конфигурации
+-- russian латиница проходит через english_stem, поэтому она покрывает оба языка
+ALTER TABLE {{.Table}} ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('russian', coalesce(song, '')), 'A') ||
+		setweight(to_tsvector('russian', coalesce(text, '')), 'B')
+	) STORED;
+CREATE INDEX IF NOT EXISTS {{.Table}}_search_vector_idx ON {{.Table}} USING GIN (search_vector);
+
+-- Уникальный ключ названий не создаётся, пока в группе есть песни с
+-- одинаковым названием: миграция не выбирает за пользователя, какую оставить,
+-- и падает со списком конфликтов. Лишние песни нужно удалить или переименовать
+-- и повторить migrate up
+DO $$
+DECLARE
+	conflicts bigint;
+	sample text;
+BEGIN
+	SELECT count(*), string_agg(format('(%s, %L)', group_id, title), ', ' ORDER BY group_id, title)
+		FILTER (WHERE position <= 20)
+	INTO conflicts, sample
+	FROM (
+		SELECT group_id, title, row_number() OVER (ORDER BY group_id, title) AS position
+		FROM (
+			SELECT group_id, lower(btrim(song)) AS title FROM {{.Table}}
+			WHERE deleted_at IS NULL
+			GROUP BY group_id, lower(btrim(song))
+			HAVING count(*) > 1
+		) duplicates
+	) numbered;
+	IF conflicts > 0 THEN
+		RAISE EXCEPTION '{{.Table}}: песни с одинаковым названием в группе (group_id, название), пар: %: %', conflicts, sample
+			USING HINT = 'удалите или переименуйте лишние песни и повторите migrate up';
+	END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Table}}_group_song_key ON {{.Table}} (group_id, lower(btrim(song)))
+	WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS {{.Table}}_song_trgm_idx ON {{.Table}} USING GIN (lower(song) gin_trgm_ops);
+
+-- Песни, созданные до индекса song_locations
+INSERT INTO song_locations (song_id, group_id)
+SELECT id, group_id FROM {{.Table}}
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS run_after;
+ALTER TABLE jobs DROP COLUMN IF EXISTS attempts;
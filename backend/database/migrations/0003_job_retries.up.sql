@@ -0,0 +1,5 @@
+-- Повторы задач, упавших из-за временной недоступности внешнего API или БД:
+-- attempts - число запусков, run_after - не раньше какого момента задачу
+-- можно взять снова
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS attempts bigint NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS run_after timestamptz;
@@ -0,0 +1,23 @@
+-- Уникальность названий групп. Раньше её проверял только код перед вставкой,
+-- и параллельные запросы могли создать группы с одним названием. Миграция не
+-- объединяет такие группы сама: она падает со списком конфликтов, песни нужно
+-- перенести в одну из групп, остальные удалить и повторить migrate up
+DO $$
+DECLARE
+	conflicts bigint;
+	sample text;
+BEGIN
+	SELECT count(*), string_agg(format('%L: %s', name, ids), ', ' ORDER BY name) FILTER (WHERE position <= 20)
+	INTO conflicts, sample
+	FROM (
+		SELECT name, string_agg(id::text, ', ' ORDER BY id) AS ids, row_number() OVER (ORDER BY name) AS position
+		FROM groups
+		GROUP BY name
+		HAVING count(*) > 1
+	) duplicates;
+	IF conflicts > 0 THEN
+		RAISE EXCEPTION 'groups: названия с несколькими группами (название: id), названий: %: %', conflicts, sample
+			USING HINT = 'перенесите песни в одну группу, удалите остальные и повторите migrate up';
+	END IF;
+END $$;
+CREATE UNIQUE INDEX IF NOT EXISTS groups_name_key ON groups (name);
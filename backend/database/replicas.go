@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"root/shared/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// replicaReadsKey - ключ контекста, разрешающего чтение из реплик.
+type replicaReadsKey struct{}
+
+// WithReplicaReads разрешает запросам с возвращённым контекстом читать из
+// реплик. Без этой отметки Cluster.Reader отдаёт основную БД: записи,
+// фоновые задачи и команды читают только что записанное ими самими.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// ReplicaReads сообщает, разрешено ли запросам с ctx читать из реплик.
+func ReplicaReads(ctx context.Context) bool {
+	allowed, _ := ctx.Value(replicaReadsKey{}).(bool)
+	return allowed
+}
+
+// ReplicaStatus - состояние реплики по последней проверке.
+type ReplicaStatus struct {
+	Name    string
+	Healthy bool
+	Lag     time.Duration
+	Err     error
+}
+
+// replica - подключение к реплике и результат её последней проверки.
+type replica struct {
+	name   string
+	db     *gorm.DB
+	status atomic.Pointer[ReplicaStatus]
+}
+
+// usable сообщает, можно ли сейчас читать из реплики.
+func (r *replica) usable() bool {
+	status := r.status.Load()
+	return status != nil && status.Healthy
+}
+
+// Cluster разделяет чтение и запись: запись всегда идёт в основную БД, чтение
+// с отмеченным WithReplicaReads контекстом - в здоровую реплику с отставанием
+// не больше maxLag. Пока ни одна реплика не подходит, чтение идёт в основную БД.
+type Cluster struct {
+	primary  *gorm.DB
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+	log      *logger.Logger
+}
+
+// NewCluster собирает кластер из основной БД и реплик. Реплики не используются
+// до первой успешной проверки в CheckReplicas.
+func NewCluster(primary *gorm.DB, replicas []*gorm.DB, maxLag time.Duration, log *logger.Logger) *Cluster {
+	cluster := &Cluster{
+		primary: primary,
+		maxLag:  maxLag,
+		log:     log,
+	}
+	for i, db := range replicas {
+		cluster.replicas = append(cluster.replicas, &replica{
+			name: replicaName(db, i),
+			db:   db,
+		})
+	}
+	return cluster
+}
+
+// ConnectReplica открывает подключение к реплике. В отличие от ConnectDb
+// соединение не проверяется: недоступная при старте реплика не мешает
+// запуску, её подключит первая успешная проверка.
+func ConnectReplica(url string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  url,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{
+		Logger:               gormLogger.Default.LogMode(gormLogger.Warn),
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := useSharding(db); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(20)
+	sqlDB.SetMaxOpenConns(200)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+	return db, nil
+}
+
+// Writer возвращает основную БД.
+func (c *Cluster) Writer() *gorm.DB {
+	return c.primary
+}
+
+// Reader возвращает подключение для чтения с ctx. Подходящие реплики
+// выбираются по кругу.
+func (c *Cluster) Reader(ctx context.Context) *gorm.DB {
+	if len(c.replicas) == 0 || !ReplicaReads(ctx) {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		replica := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		if replica.usable() {
+			return replica.db
+		}
+	}
+	return c.primary
+}
+
+// HasReplicas сообщает, настроены ли реплики.
+func (c *Cluster) HasReplicas() bool {
+	return len(c.replicas) > 0
+}
+
+// Replicas возвращает состояние реплик по последней проверке.
+func (c *Cluster) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(c.replicas))
+	for i, replica := range c.replicas {
+		if status := replica.status.Load(); status != nil {
+			statuses[i] = *status
+		} else {
+			statuses[i] = ReplicaStatus{Name: replica.name, Err: fmt.Errorf("реплика ещё не проверялась")}
+		}
+	}
+	return statuses
+}
+
+// replicaLagQuery возвращает, находится ли сервер в восстановлении, получает
+// ли он WAL от основной БД, и отставание применения WAL в секундах. Реплика,
+// применившая всё полученное, не отстаёт, даже если на основной БД давно не
+// было записей, - но только пока WAL receiver на связи: без него полученная
+// позиция не растёт, и равенство позиций ничего не говорит об отставании.
+// Статус receiver'а виден ролям с pg_read_all_stats (например, pg_monitor).
+const replicaLagQuery = `SELECT pg_is_in_recovery() AS in_recovery,
+	COALESCE((SELECT status = 'streaming' FROM pg_stat_wal_receiver), false) AS streaming,
+	CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END AS lag_seconds`
+
+// CheckReplicas проверяет все реплики и обновляет их состояние. Реплика не
+// используется, если она недоступна, перестала быть репликой (например, после
+// переключения на неё основной БД), потеряла поток WAL от основной БД или
+// отстаёт больше чем на maxLag.
+func (c *Cluster) CheckReplicas(ctx context.Context, timeout time.Duration) {
+	for _, replica := range c.replicas {
+		status := c.checkReplica(ctx, replica, timeout)
+		previous := replica.status.Swap(&status)
+
+		wasHealthy := previous != nil && previous.Healthy
+		switch {
+		case status.Healthy && !wasHealthy:
+			c.log.Infof("CheckReplicas: replica %s is healthy, lag %v", replica.name, status.Lag)
+		case !status.Healthy && (wasHealthy || previous == nil):
+			c.log.Warnf("CheckReplicas: replica %s is not used for reads: %v", replica.name, status.Err)
+		}
+	}
+}
+
+func (c *Cluster) checkReplica(ctx context.Context, replica *replica, timeout time.Duration) ReplicaStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status := ReplicaStatus{Name: replica.name}
+	var row struct {
+		InRecovery bool
+		Streaming  bool
+		LagSeconds float64
+	}
+	if err := replica.db.WithContext(ctx).Raw(replicaLagQuery).Scan(&row).Error; err != nil {
+		status.Err = fmt.Errorf("ошибка при проверке реплики: %w", err)
+		return status
+	}
+	status.Lag = time.Duration(row.LagSeconds * float64(time.Second))
+	switch {
+	case !row.InRecovery:
+		status.Err = fmt.Errorf("сервер не находится в режиме реплики")
+	case !row.Streaming:
+		status.Err = fmt.Errorf("реплика не получает WAL от основной БД")
+	case status.Lag > c.maxLag:
+		status.Err = fmt.Errorf("отставание %v больше допустимого %v", status.Lag.Round(time.Millisecond), c.maxLag)
+	default:
+		status.Healthy = true
+	}
+	return status
+}
+
+// WatchReplicas проверяет реплики сразу и затем каждые interval, пока ctx не
+// отменён.
+func (c *Cluster) WatchReplicas(ctx context.Context, interval time.Duration) {
+	if !c.HasReplicas() {
+		return
+	}
+	c.CheckReplicas(ctx, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			c.CheckReplicas(ctx, interval)
+		}
+	}()
+}
+
+// replicaName возвращает имя реплики для логов: адрес и базу без учётных
+// данных.
+func replicaName(db *gorm.DB, index int) string {
+	if dialector, ok := db.Dialector.(*postgres.Dialector); ok {
+		if config, err := pgconn.ParseConfig(dialector.DSN); err == nil {
+			return fmt.Sprintf("%s:%d/%s", config.Host, config.Port, config.Database)
+		}
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
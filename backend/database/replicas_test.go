@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestDB() *gorm.DB {
+	return &gorm.DB{Config: &gorm.Config{}}
+}
+
+func TestReaderUsesPrimaryWithoutReplicaReads(t *testing.T) {
+	primary, replicaDB := newTestDB(), newTestDB()
+	cluster := NewCluster(primary, []*gorm.DB{replicaDB}, time.Second, nil)
+	cluster.replicas[0].status.Store(&ReplicaStatus{Healthy: true})
+
+	if got := cluster.Reader(context.Background()); got != primary {
+		t.Error("Reader without WithReplicaReads should return primary")
+	}
+	if got := cluster.Reader(WithReplicaReads(context.Background())); got != replicaDB {
+		t.Error("Reader with WithReplicaReads should return the healthy replica")
+	}
+}
+
+func TestReaderSkipsUnhealthyReplicas(t *testing.T) {
+	primary, first, second := newTestDB(), newTestDB(), newTestDB()
+	cluster := NewCluster(primary, []*gorm.DB{first, second}, time.Second, nil)
+	ctx := WithReplicaReads(context.Background())
+
+	// До первой проверки реплики не используются.
+	if got := cluster.Reader(ctx); got != primary {
+		t.Error("Reader should return primary before replicas are checked")
+	}
+
+	cluster.replicas[0].status.Store(&ReplicaStatus{Healthy: false, Lag: time.Minute})
+	cluster.replicas[1].status.Store(&ReplicaStatus{Healthy: true})
+	for i := 0; i < 4; i++ {
+		if got := cluster.Reader(ctx); got != second {
+			t.Fatalf("Reader call %d should skip the lagging replica", i)
+		}
+	}
+
+	cluster.replicas[0].status.Store(&ReplicaStatus{Healthy: true})
+	seen := map[*gorm.DB]bool{}
+	for i := 0; i < 4; i++ {
+		seen[cluster.Reader(ctx)] = true
+	}
+	if !seen[first] || !seen[second] || seen[primary] {
+		t.Errorf("Reader should alternate between healthy replicas, got %d distinct connections", len(seen))
+	}
+
+	cluster.replicas[0].status.Store(&ReplicaStatus{Healthy: false})
+	cluster.replicas[1].status.Store(&ReplicaStatus{Healthy: false})
+	if got := cluster.Reader(ctx); got != primary {
+		t.Error("Reader should fall back to primary when no replica is healthy")
+	}
+}
@@ -0,0 +1,581 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	song_model "root/module/song/dto"
+	"root/shared/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxNumShards - ограничение плагина gorm.io/sharding для генератора Snowflake.
+const MaxNumShards = 1024
+
+// Этапы решардинга. Этап сохраняется в reshard_state после завершения
+// предыдущего, поэтому прерванный решардинг продолжается с него же.
+const (
+	ReshardPhasePrepare = "prepare"
+	ReshardPhaseCopy    = "copy"
+	ReshardPhaseVerify  = "verify"
+	ReshardPhaseSwitch  = "switch"
+	ReshardPhaseCleanup = "cleanup"
+)
+
+var (
+	ErrInvalidShardCount = fmt.Errorf("number of shards must be between 1 and %d", MaxNumShards)
+	ErrReshardLocked     = errors.New("another resharding is running")
+	ErrReshardInProgress = errors.New("another resharding is in progress")
+	ErrReshardMismatch   = errors.New("copied songs do not match source tables")
+	ErrReshardSwitched   = errors.New("resharding is already switched and can only be completed")
+	ErrNoReshard         = errors.New("no resharding is in progress")
+)
+
+const (
+	// reshardLockKey - ключ advisory-блокировки, не дающей запустить два решардинга.
+	reshardLockKey = 726_001
+	// reshardBatchSize - количество песен, копируемых одной транзакцией.
+	reshardBatchSize = 1000
+	// reshardLockTimeout ограничивает ожидание блокировки таблиц при переключении,
+	// чтобы решардинг не держал очередь запросов приложения.
+	reshardLockTimeout = "10s"
+
+	nextShardPrefix = "songs_next_"
+	oldShardPrefix  = "songs_old_"
+)
+
+// songColumns - колонки таблицы шарда без вычисляемой search_vector.
+var songColumns = []string{"id", "group_id", `"group"`, "song", "text", "link", "release_date", "version", "deleted_at"}
+
+// ReshardState - незавершённый решардинг. В таблице не больше одной строки.
+type ReshardState struct {
+	ID        int    `gorm:"primaryKey;autoIncrement:false"`
+	Source    int    `gorm:"not null"`
+	Target    int    `gorm:"not null"`
+	Phase     string `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (ReshardState) TableName() string {
+	return "reshard_state"
+}
+
+// ReshardProgress - позиция копирования одной старой таблицы шарда.
+type ReshardProgress struct {
+	SourceTable string     `gorm:"primaryKey"`
+	LastID      *uuid.UUID `gorm:"type:uuid"`
+	Copied      int64      `gorm:"not null;default:0"`
+	Done        bool       `gorm:"not null;default:false"`
+}
+
+func (ReshardProgress) TableName() string {
+	return "reshard_progress"
+}
+
+const reshardStateID = 1
+
+// shardChecksum - количество и контрольная сумма песен одного нового шарда.
+type shardChecksum struct {
+	Shard    int
+	Rows     int64
+	Checksum string
+}
+
+// Reshard переносит песни в target таблиц шардов без остановки приложения:
+//
+//  1. prepare - создаёт таблицы songs_next_N и триггеры, повторяющие в них
+//     все изменения старых таблиц;
+//  2. copy - копирует песни пачками по пересчитанному шарду group_id,
+//     запоминая позицию в reshard_progress;
+//  3. verify - сверяет количество и контрольные суммы песен каждого шарда;
+//  4. switch - одной транзакцией переименовывает таблицы и меняет shard_config;
+//  5. cleanup - удаляет старые таблицы.
+//
+// Если решардинг прерван, повторный вызов с тем же target продолжает его
+// с сохранённого этапа.
+func Reshard(ctx context.Context, db *gorm.DB, target int, log *logger.Logger) error {
+	if target < 1 || target > MaxNumShards {
+		return ErrInvalidShardCount
+	}
+
+	return withReshardLock(ctx, db, func(conn *gorm.DB) error {
+		source, err := LoadShards(conn)
+		if err != nil {
+			return err
+		}
+
+		state, err := loadReshardState(conn)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			if source == target {
+				log.Infof("Reshard: songs are already stored in %d shards", target)
+				return nil
+			}
+			state = &ReshardState{ID: reshardStateID, Source: source, Target: target, Phase: ReshardPhasePrepare}
+			if err := conn.Create(state).Error; err != nil {
+				return fmt.Errorf("не удалось сохранить состояние решардинга: %w", err)
+			}
+			log.Infof("Reshard: started resharding songs from %d to %d shards", source, target)
+		} else {
+			if state.Target != target {
+				return fmt.Errorf("%w: from %d to %d shards, resume it or abort", ErrReshardInProgress, state.Source, state.Target)
+			}
+			log.Infof("Reshard: resuming resharding from %d to %d shards at %s", state.Source, state.Target, state.Phase)
+		}
+
+		phases := []struct {
+			phase string
+			run   func(context.Context, *gorm.DB, *ReshardState, *logger.Logger) error
+		}{
+			{ReshardPhasePrepare, prepareReshard},
+			{ReshardPhaseCopy, copyReshard},
+			{ReshardPhaseVerify, verifyReshard},
+			{ReshardPhaseSwitch, switchReshard},
+			{ReshardPhaseCleanup, cleanupReshard},
+		}
+		started := false
+		for i, phase := range phases {
+			if phase.phase == state.Phase {
+				started = true
+			}
+			if !started {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			log.Infof("Reshard: %s", phase.phase)
+			if err := phase.run(ctx, conn, state, log); err != nil {
+				return fmt.Errorf("reshard %s: %w", phase.phase, err)
+			}
+			// switch и cleanup сами сохраняют переход на следующий этап
+			if i+1 < len(phases) && phase.phase != ReshardPhaseSwitch && phase.phase != ReshardPhaseCleanup {
+				if err := saveReshardPhase(conn, phases[i+1].phase); err != nil {
+					return err
+				}
+				state.Phase = phases[i+1].phase
+			}
+		}
+		if !started {
+			return fmt.Errorf("неизвестный этап решардинга %q", state.Phase)
+		}
+
+		log.Infof("Reshard: songs are now stored in %d shards", state.Target)
+		return nil
+	})
+}
+
+// AbortReshard отменяет решардинг, который ещё не переключил таблицы: удаляет
+// триггеры и их функцию, новые таблицы и сохранённое состояние.
+func AbortReshard(ctx context.Context, db *gorm.DB, log *logger.Logger) error {
+	return withReshardLock(ctx, db, func(conn *gorm.DB) error {
+		state, err := loadReshardState(conn)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return ErrNoReshard
+		}
+		if state.Phase == ReshardPhaseCleanup {
+			return ErrReshardSwitched
+		}
+
+		err = conn.Transaction(func(tx *gorm.DB) error {
+			if err := dropMirrorTriggers(tx, state.Source); err != nil {
+				return err
+			}
+			if err := tx.Exec("DROP FUNCTION IF EXISTS reshard_mirror_songs()").Error; err != nil {
+				return err
+			}
+			for i := 0; i < state.Target; i++ {
+				if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", nextShardTable(i))).Error; err != nil {
+					return err
+				}
+			}
+			return clearReshardState(tx)
+		})
+		if err != nil {
+			return fmt.Errorf("не удалось отменить решардинг: %w", err)
+		}
+
+		log.Infof("AbortReshard: resharding from %d to %d shards aborted", state.Source, state.Target)
+		return nil
+	})
+}
+
+// withReshardLock выполняет fn на отдельном соединении под advisory-блокировкой.
+func withReshardLock(ctx context.Context, db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", reshardLockKey).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("не удалось получить блокировку решардинга: %w", err)
+		}
+		if !locked {
+			return ErrReshardLocked
+		}
+		// Контекст может быть уже отменён, а блокировку нужно снять в любом случае
+		defer conn.WithContext(context.Background()).Exec("SELECT pg_advisory_unlock(?)", reshardLockKey)
+
+		return fn(conn)
+	})
+}
+
+func loadReshardState(db *gorm.DB) (*ReshardState, error) {
+	state := new(ReshardState)
+	err := db.Where("id = ?", reshardStateID).Take(state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить состояние решардинга: %w", err)
+	}
+	return state, nil
+}
+
+func saveReshardPhase(db *gorm.DB, phase string) error {
+	err := db.Model(&ReshardState{}).Where("id = ?", reshardStateID).Update("phase", phase).Error
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить этап решардинга: %w", err)
+	}
+	return nil
+}
+
+func clearReshardState(tx *gorm.DB) error {
+	if err := tx.Where("1 = 1").Delete(&ReshardProgress{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id = ?", reshardStateID).Delete(&ReshardState{}).Error
+}
+
+// prepareReshard создаёт новые таблицы шардов и включает зеркалирование в них
+// изменений старых таблиц. Зеркалирование включается до копирования, поэтому
+// ни одна запись, сделанная во время решардинга, не теряется.
+func prepareReshard(_ context.Context, conn *gorm.DB, state *ReshardState, log *logger.Logger) error {
+	for i := 0; i < state.Target; i++ {
+		if err := ApplyShardMigrations(conn, nextShardTable(i), i, state.Target); err != nil {
+			return err
+		}
+		log.Infof("Reshard: created %s", nextShardTable(i))
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mirrorFunctionSQL()).Error; err != nil {
+			return fmt.Errorf("не удалось создать функцию зеркалирования: %w", err)
+		}
+		if err := dropMirrorTriggers(tx, state.Source); err != nil {
+			return err
+		}
+		for i := 0; i < state.Source; i++ {
+			tableName := ShardTable(i)
+			err := tx.Exec(fmt.Sprintf(`CREATE TRIGGER %[1]s_reshard_mirror
+				AFTER INSERT OR UPDATE OR DELETE ON %[1]s
+				FOR EACH ROW EXECUTE FUNCTION reshard_mirror_songs(%[2]d)`, tableName, state.Target)).Error
+			if err != nil {
+				return fmt.Errorf("не удалось создать триггер зеркалирования для %s: %w", tableName, err)
+			}
+
+			progress := &ReshardProgress{SourceTable: tableName}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(progress).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyReshard копирует песни старых таблиц в новые пачками по id. Каждая пачка
+// вместе с позицией копирования сохраняется одной транзакцией.
+func copyReshard(ctx context.Context, conn *gorm.DB, state *ReshardState, log *logger.Logger) error {
+	var progresses []ReshardProgress
+	if err := conn.Order("source_table").Find(&progresses).Error; err != nil {
+		return fmt.Errorf("не удалось загрузить прогресс решардинга: %w", err)
+	}
+
+	for i := range progresses {
+		progress := &progresses[i]
+		for !progress.Done {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := copyReshardBatch(conn, progress, state.Target); err != nil {
+				return fmt.Errorf("не удалось скопировать песни из %s: %w", progress.SourceTable, err)
+			}
+			log.Debugf("Reshard: copied %d songs from %s", progress.Copied, progress.SourceTable)
+		}
+		log.Infof("Reshard: copied %d songs from %s", progress.Copied, progress.SourceTable)
+	}
+	return nil
+}
+
+// copyReshardBatch копирует следующую пачку песен таблицы. Строки пачки
+// блокируются FOR SHARE: изменение, начатое до копирования, успевает
+// отразиться триггером, а начатое после ждёт и затем перезаписывает копию.
+// Песни, уже записанные триггером, не перезаписываются.
+func copyReshardBatch(conn *gorm.DB, progress *ReshardProgress, target int) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		query := tx.Table(progress.SourceTable).Unscoped().Select(songColumns).
+			Clauses(clause.Locking{Strength: "SHARE"}).Order("id").Limit(reshardBatchSize)
+		if progress.LastID != nil {
+			query = query.Where("id > ?", *progress.LastID)
+		}
+
+		var songs []song_model.Song
+		if err := query.Find(&songs).Error; err != nil {
+			return err
+		}
+		if len(songs) == 0 {
+			progress.Done = true
+			return tx.Save(progress).Error
+		}
+
+		shards := make(map[int][]song_model.Song)
+		for _, song := range songs {
+			shard := song.GroupID % target
+			shards[shard] = append(shards[shard], song)
+		}
+		for shard, rows := range shards {
+			onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoNothing: true}
+			if err := tx.Table(nextShardTable(shard)).Clauses(onConflict).Create(&rows).Error; err != nil {
+				return err
+			}
+		}
+
+		lastID := songs[len(songs)-1].ID
+		progress.LastID = &lastID
+		progress.Copied += int64(len(songs))
+		return tx.Save(progress).Error
+	})
+}
+
+// verifyReshard сверяет каждую новую таблицу с песнями того же шарда во всех
+// старых таблицах. Сверка идёт по одному снимку: триггеры пишут в новые
+// таблицы в той же транзакции, что и приложение в старые.
+func verifyReshard(ctx context.Context, conn *gorm.DB, state *ReshardState, log *logger.Logger) error {
+	return conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY").Error; err != nil {
+			return err
+		}
+
+		sources := make([]string, state.Source)
+		for i := range sources {
+			sources[i] = fmt.Sprintf("SELECT %s FROM %s", strings.Join(songColumns, ", "), ShardTable(i))
+		}
+		var expected []shardChecksum
+		err := tx.Raw(fmt.Sprintf(`SELECT group_id %% ? AS shard, count(*) AS rows, %s AS checksum
+			FROM (%s) AS songs GROUP BY 1`, checksumSQL(), strings.Join(sources, " UNION ALL ")), state.Target).
+			Scan(&expected).Error
+		if err != nil {
+			return err
+		}
+		want := make(map[int]shardChecksum, len(expected))
+		for _, sum := range expected {
+			want[sum.Shard] = sum
+		}
+
+		for i := 0; i < state.Target; i++ {
+			var got shardChecksum
+			err := tx.Raw(fmt.Sprintf("SELECT count(*) AS rows, %s AS checksum FROM %s", checksumSQL(), nextShardTable(i))).
+				Scan(&got).Error
+			if err != nil {
+				return err
+			}
+			if got.Rows != want[i].Rows || got.Checksum != want[i].Checksum {
+				return fmt.Errorf("%w: %s has %d songs (checksum %q), expected %d (checksum %q)",
+					ErrReshardMismatch, nextShardTable(i), got.Rows, got.Checksum, want[i].Rows, want[i].Checksum)
+			}
+			log.Infof("Reshard: %s verified, %d songs", nextShardTable(i), got.Rows)
+			delete(want, i)
+		}
+		if len(want) > 0 {
+			return fmt.Errorf("%w: %d shards of old tables have no new table", ErrReshardMismatch, len(want))
+		}
+		return nil
+	})
+}
+
+// switchReshard одной транзакцией заменяет старые таблицы новыми и меняет
+// количество шардов в shard_config. Новые таблицы получают ограничение CHECK
+// на номер шарда: экземпляры приложения, ещё не перечитавшие shard_config,
+// не смогут записать песню в чужую таблицу.
+func switchReshard(ctx context.Context, conn *gorm.DB, state *ReshardState, log *logger.Logger) error {
+	err := conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%s'", reshardLockTimeout)).Error; err != nil {
+			return err
+		}
+
+		tables := make([]string, 0, state.Source+state.Target)
+		for i := 0; i < state.Source; i++ {
+			tables = append(tables, ShardTable(i))
+		}
+		for i := 0; i < state.Target; i++ {
+			tables = append(tables, nextShardTable(i))
+		}
+		if err := tx.Exec(fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", strings.Join(tables, ", "))).Error; err != nil {
+			return fmt.Errorf("не удалось заблокировать таблицы шардов: %w", err)
+		}
+
+		// Под блокировкой достаточно сверить количество: содержимое сверено
+		// на этапе verify, а дальше изменения шли только через триггеры
+		var sourceRows, targetRows int64
+		if err := tx.Raw(fmt.Sprintf("SELECT %s", countSQL(tables[:state.Source]))).Scan(&sourceRows).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(fmt.Sprintf("SELECT %s", countSQL(tables[state.Source:]))).Scan(&targetRows).Error; err != nil {
+			return err
+		}
+		if sourceRows != targetRows {
+			return fmt.Errorf("%w: %d songs in new tables, %d in old ones", ErrReshardMismatch, targetRows, sourceRows)
+		}
+
+		if err := dropMirrorTriggers(tx, state.Source); err != nil {
+			return err
+		}
+		for i := 0; i < state.Source; i++ {
+			if err := renameShardTable(tx, ShardTable(i), oldShardTable(i)); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < state.Target; i++ {
+			if err := renameShardTable(tx, nextShardTable(i), ShardTable(i)); err != nil {
+				return err
+			}
+			err := tx.Exec(fmt.Sprintf(`ALTER TABLE %[1]s ADD CONSTRAINT %[1]s_shard_check
+				CHECK (group_id %% %[2]d = %[3]d) NOT VALID`, ShardTable(i), state.Target, i)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&ShardConfig{}).Where("id = ?", shardConfigID).Update("shards", state.Target).Error
+		if err != nil {
+			return err
+		}
+		return saveReshardPhase(tx, ReshardPhaseCleanup)
+	})
+	if err != nil {
+		return err
+	}
+
+	numShards.Store(int64(state.Target))
+	state.Phase = ReshardPhaseCleanup
+	log.Infof("Reshard: switched songs to %d shards", state.Target)
+	return nil
+}
+
+// cleanupReshard удаляет старые таблицы и проверяет ограничения новых.
+func cleanupReshard(ctx context.Context, conn *gorm.DB, state *ReshardState, log *logger.Logger) error {
+	for i := 0; i < state.Target; i++ {
+		// VALIDATE не блокирует запись, в отличие от проверки при добавлении
+		err := conn.WithContext(ctx).Exec(fmt.Sprintf("ALTER TABLE %[1]s VALIDATE CONSTRAINT %[1]s_shard_check", ShardTable(i))).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < state.Source; i++ {
+			if err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", oldShardTable(i))).Error; err != nil {
+				return err
+			}
+			log.Infof("Reshard: dropped %s", oldShardTable(i))
+		}
+		if err := tx.Exec("DROP FUNCTION IF EXISTS reshard_mirror_songs()").Error; err != nil {
+			return err
+		}
+		return clearReshardState(tx)
+	})
+}
+
+// renameShardTable переименовывает таблицу вместе с индексами: имена индексов
+// содержат имя таблицы и должны освободиться для таблицы, занявшей её место.
+func renameShardTable(tx *gorm.DB, from, to string) error {
+	var indexes []string
+	err := tx.Raw(`SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = ?`, from).
+		Scan(&indexes).Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", from, to)).Error; err != nil {
+		return fmt.Errorf("не удалось переименовать %s в %s: %w", from, to, err)
+	}
+	for _, index := range indexes {
+		renamed := strings.Replace(index, from, to, 1)
+		if renamed == index {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("ALTER INDEX %s RENAME TO %s", index, renamed)).Error; err != nil {
+			return fmt.Errorf("не удалось переименовать индекс %s: %w", index, err)
+		}
+	}
+	return nil
+}
+
+func dropMirrorTriggers(tx *gorm.DB, shards int) error {
+	for i := 0; i < shards; i++ {
+		err := tx.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %[1]s_reshard_mirror ON %[1]s", ShardTable(i))).Error
+		if err != nil {
+			return fmt.Errorf("не удалось удалить триггер зеркалирования: %w", err)
+		}
+	}
+	return nil
+}
+
+// mirrorFunctionSQL - триггерная функция, повторяющая изменение строки старой
+// таблицы в новой таблице её шарда. Количество новых шардов передаётся
+// аргументом триггера. Песня, сменившая шард, удаляется из прежней таблицы.
+func mirrorFunctionSQL() string {
+	columns := strings.Join(songColumns, ", ")
+	updates := make([]string, 0, len(songColumns)-1)
+	for _, column := range songColumns[1:] {
+		updates = append(updates, fmt.Sprintf("%[1]s = EXCLUDED.%[1]s", column))
+	}
+
+	return `CREATE OR REPLACE FUNCTION reshard_mirror_songs() RETURNS trigger AS $$
+DECLARE
+	shards int := TG_ARGV[0]::int;
+BEGIN
+	IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND OLD.group_id % shards <> NEW.group_id % shards) THEN
+		EXECUTE format('DELETE FROM %I WHERE id = $1', '` + nextShardPrefix + `' || OLD.group_id % shards) USING OLD.id;
+	END IF;
+	IF TG_OP = 'DELETE' THEN
+		RETURN OLD;
+	END IF;
+
+	EXECUTE format('INSERT INTO %I (` + columns + `) SELECT ` + columns + ` FROM (SELECT ($1).*) AS r
+		ON CONFLICT (id) DO UPDATE SET ` + strings.Join(updates, ", ") + `', '` + nextShardPrefix + `' || NEW.group_id % shards) USING NEW;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`
+}
+
+// checksumSQL - агрегат контрольной суммы песен, не зависящий от порядка таблиц.
+func checksumSQL() string {
+	return fmt.Sprintf(`coalesce(md5(string_agg(md5(ROW(%s)::text), '' ORDER BY id)), '')`, strings.Join(songColumns, ", "))
+}
+
+// countSQL - выражение с суммарным количеством строк таблиц.
+func countSQL(tables []string) string {
+	counts := make([]string, len(tables))
+	for i, tableName := range tables {
+		counts[i] = fmt.Sprintf("(SELECT count(*) FROM %s)", tableName)
+	}
+	return strings.Join(counts, " + ")
+}
+
+func nextShardTable(shard int) string {
+	return fmt.Sprintf("%s%d", nextShardPrefix, shard)
+}
+
+func oldShardTable(shard int) string {
+	return fmt.Sprintf("%s%d", oldShardPrefix, shard)
+}
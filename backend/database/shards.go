@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"root/shared/logger"
+
+	"gorm.io/gorm"
+)
+
+// DefaultNumShards - количество шардов до первого решардинга.
+const DefaultNumShards = 4
+
+// numShards - текущее количество шардов песен. Загружается из shard_config
+// при старте и периодически обновляется, поэтому после решардинга работающие
+// экземпляры переходят на новые таблицы без перезапуска.
+var numShards atomic.Int64
+
+func init() {
+	numShards.Store(DefaultNumShards)
+}
+
+// NumShards возвращает текущее количество шардов песен.
+func NumShards() int {
+	return int(numShards.Load())
+}
+
+// ShardConfig - действующая конфигурация шардирования. В таблице одна строка
+// с ID = 1, её меняет только переключение в Reshard.
+type ShardConfig struct {
+	ID        int `gorm:"primaryKey;autoIncrement:false"`
+	Shards    int `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (ShardConfig) TableName() string {
+	return "shard_config"
+}
+
+const shardConfigID = 1
+
+// LoadShards читает количество шардов из shard_config и делает его текущим.
+func LoadShards(db *gorm.DB) (int, error) {
+	config := new(ShardConfig)
+	if err := db.Where("id = ?", shardConfigID).Take(config).Error; err != nil {
+		return 0, fmt.Errorf("не удалось загрузить конфигурацию шардов: %w", err)
+	}
+	if config.Shards < 1 {
+		return 0, fmt.Errorf("некорректное количество шардов в shard_config: %d", config.Shards)
+	}
+	numShards.Store(int64(config.Shards))
+	return config.Shards, nil
+}
+
+// WatchShards перечитывает shard_config каждые interval, пока ctx не отменён.
+// До обновления экземпляр работает со старым количеством шардов: его записи в
+// чужие таблицы отклоняет ограничение CHECK, добавляемое при переключении.
+func WatchShards(ctx context.Context, db *gorm.DB, interval time.Duration, log *logger.Logger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			before := NumShards()
+			shards, err := LoadShards(db.WithContext(ctx))
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Warnf("WatchShards: %v", err)
+				}
+				continue
+			}
+			if shards != before {
+				log.Infof("WatchShards: number of song shards changed from %d to %d", before, shards)
+			}
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShardingFollowsNumShards(t *testing.T) {
+	previous := NumShards()
+	t.Cleanup(func() { numShards.Store(int64(previous)) })
+
+	numShards.Store(2)
+	if suffix, err := shardingAlgorithm(5); err != nil || suffix != "_1" {
+		t.Fatalf("shardingAlgorithm(5) with 2 shards = %q, %v; want _1", suffix, err)
+	}
+
+	// Переключение решардинга меняет маршрутизацию без повторной регистрации плагина.
+	numShards.Store(4)
+	if suffix, err := shardingAlgorithm(int64(6)); err != nil || suffix != "_2" {
+		t.Errorf("shardingAlgorithm(6) with 4 shards = %q, %v; want _2", suffix, err)
+	}
+	if got, want := shardingSuffixes(), []string{"_0", "_1", "_2", "_3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shardingSuffixes() = %v, want %v", got, want)
+	}
+	if _, err := shardingAlgorithm("6"); err == nil {
+		t.Error("shardingAlgorithm should reject non-integer group_id")
+	}
+}
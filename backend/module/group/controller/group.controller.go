@@ -0,0 +1,346 @@
+package group_controller
+
+import (
+	"errors"
+	"root/module/group/dto"
+	group_service "root/module/group/service"
+	song_dto "root/module/song/dto"
+	"root/shared/logger"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// actorHeader - заголовок с автором изменения для истории песен группы.
+const actorHeader = "X-Actor"
+
+type IGroupController interface {
+	GetGroups(c *fiber.Ctx) error
+	GetGroup(c *fiber.Ctx) error
+	CreateGroup(c *fiber.Ctx) error
+	RenameGroup(c *fiber.Ctx) error
+	DeleteGroup(c *fiber.Ctx) error
+	GetGroupSongs(c *fiber.Ctx) error
+}
+
+type GroupController struct {
+	logger       *logger.Logger
+	groupService group_service.IGroupService
+}
+
+type Response struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func NewGroupController(logger *logger.Logger, groupService group_service.IGroupService) IGroupController {
+	return &GroupController{
+		logger:       logger,
+		groupService: groupService,
+	}
+}
+
+// GetGroups возвращает список групп
+// @Summary Получение списка групп
+// @Description Возвращает группы, упорядоченные по названию, и общее число групп.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param offset query int false "Смещение для пагинации. По умолчанию 0." default(0) minimum(0)
+// @Param limit query int false "Количество записей на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Success 200 {object} Response{data=dto.GroupPage} "Страница групп."
+// @Failure 400 {object} Response "Некорректный формат параметров offset или limit."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group [get]
+func (gc *GroupController) GetGroups(c *fiber.Ctx) error {
+	gc.logger.Info("GetGroups: started")
+	defer gc.logger.Info("GetGroups: completed")
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		gc.logger.Warn("GetGroups: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		gc.logger.Warn("GetGroups: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid limit number",
+		})
+	}
+
+	groups, err := gc.groupService.GetGroups(c.UserContext(), offset, limit)
+	if err != nil {
+		return errorResponse(c, err, "Failed to fetch groups")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Groups fetched successfully",
+		Data:    groups,
+	})
+}
+
+// GetGroup возвращает группу по её ID
+// @Summary Получение группы
+// @Description Возвращает группу по её идентификатору.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы."
+// @Success 200 {object} Response{data=song_dto.Group} "Группа."
+// @Failure 400 {object} Response "Некорректный ID группы."
+// @Failure 404 {object} Response "Группа с указанным ID не найдена."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group/{id} [get]
+func (gc *GroupController) GetGroup(c *fiber.Ctx) error {
+	gc.logger.Info("GetGroup: started")
+	defer gc.logger.Info("GetGroup: completed")
+
+	group, err := gc.groupService.GetGroup(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return errorResponse(c, err, "Failed to fetch group")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Group fetched successfully",
+		Data:    group,
+	})
+}
+
+// CreateGroup создаёт группу
+// @Summary Создание группы
+// @Description Создаёт группу без песен. Название должно быть уникальным.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param data body dto.GroupInput true "Название группы."
+// @Success 201 {object} Response{data=song_dto.Group} "Созданная группа."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный формат данных в теле запроса.
+// - Пустое или слишком длинное название группы."
+// @Failure 409 {object} Response "Группа с таким названием уже существует."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group [post]
+func (gc *GroupController) CreateGroup(c *fiber.Ctx) error {
+	gc.logger.Info("CreateGroup: started")
+	defer gc.logger.Info("CreateGroup: completed")
+
+	var input dto.GroupInput
+	if err := c.BodyParser(&input); err != nil {
+		gc.logger.Errorf("CreateGroup: failed to parse request body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid input",
+		})
+	}
+
+	group, err := gc.groupService.CreateGroup(c.UserContext(), input.Name)
+	if err != nil {
+		return errorResponse(c, err, "Failed to create group")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(Response{
+		Success: true,
+		Message: "Group created successfully",
+		Data:    group,
+	})
+}
+
+// RenameGroup переименовывает группу
+// @Summary Переименование группы
+// @Description Переименовывает группу и в той же транзакции обновляет название группы у всех её песен во всех шардах. Изменение записывается в историю каждой песни.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы."
+// @Param data body dto.GroupInput true "Новое название группы."
+// @Param X-Actor header string false "Автор изменения для истории песен. По умолчанию anonymous."
+// @Success 200 {object} Response{data=song_dto.Group} "Переименованная группа."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный ID группы.
+// - Некорректный формат данных в теле запроса.
+// - Пустое или слишком длинное название группы."
+// @Failure 404 {object} Response "Группа с указанным ID не найдена."
+// @Failure 409 {object} Response "Другая группа с таким названием уже существует."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group/{id} [put]
+func (gc *GroupController) RenameGroup(c *fiber.Ctx) error {
+	gc.logger.Info("RenameGroup: started")
+	defer gc.logger.Info("RenameGroup: completed")
+
+	var input dto.GroupInput
+	if err := c.BodyParser(&input); err != nil {
+		gc.logger.Errorf("RenameGroup: failed to parse request body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid input",
+		})
+	}
+
+	group, err := gc.groupService.RenameGroup(c.UserContext(), c.Params("id"), input.Name, requestActor(c))
+	if err != nil {
+		return errorResponse(c, err, "Failed to rename group")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Group renamed successfully",
+		Data:    group,
+	})
+}
+
+// DeleteGroup удаляет группу
+// @Summary Удаление группы
+// @Description Удаляет группу. Если у группы есть песни, удаление отклоняется, пока не указан cascade=true; в этом случае песни переносятся в корзину с записью в историю. Восстановление песни из корзины возвращает и её группу.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы."
+// @Param cascade query bool false "Удалить группу вместе с её песнями. По умолчанию false." default(false)
+// @Param X-Actor header string false "Автор изменения для истории песен. По умолчанию anonymous."
+// @Success 200 {object} Response{data=int} "Группа удалена. Возвращает число перенесённых в корзину песен."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный ID группы.
+// - Некорректное значение cascade."
+// @Failure 404 {object} Response "Группа с указанным ID не найдена."
+// @Failure 409 {object} Response "У группы есть песни, а cascade не указан."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group/{id} [delete]
+func (gc *GroupController) DeleteGroup(c *fiber.Ctx) error {
+	gc.logger.Info("DeleteGroup: started")
+	defer gc.logger.Info("DeleteGroup: completed")
+
+	cascade, err := strconv.ParseBool(c.Query("cascade", "false"))
+	if err != nil {
+		gc.logger.Warn("DeleteGroup: invalid cascade")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid cascade value",
+		})
+	}
+
+	deleted, err := gc.groupService.DeleteGroup(c.UserContext(), c.Params("id"), cascade, requestActor(c))
+	if err != nil {
+		return errorResponse(c, err, "Failed to delete group")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Group deleted successfully",
+		Data:    deleted,
+	})
+}
+
+// GetGroupSongs возвращает песни группы
+// @Summary Получение песен группы
+// @Description Возвращает песни группы по id с курсорной пагинацией, как GET /api/song?group_id={id}.
+// @Tags Группы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID группы."
+// @Param offset query int false "Смещение для пагинации. Нельзя использовать вместе с cursor. По умолчанию 0." default(0) minimum(0) maximum(1000)
+// @Param limit query int false "Количество записей на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Param cursor query string false "Курсор следующей страницы из поля next_cursor предыдущего ответа."
+// @Success 200 {object} Response{data=song_dto.SongPage} "Страница песен группы."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный ID группы.
+// - Некорректный формат параметров offset, limit или cursor."
+// @Failure 404 {object} Response "Группа с указанным ID не найдена."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/group/{id}/songs [get]
+func (gc *GroupController) GetGroupSongs(c *fiber.Ctx) error {
+	gc.logger.Info("GetGroupSongs: started")
+	defer gc.logger.Info("GetGroupSongs: completed")
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		gc.logger.Warn("GetGroupSongs: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		gc.logger.Warn("GetGroupSongs: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid limit number",
+		})
+	}
+
+	page := song_dto.PageRequest{
+		Offset: offset,
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+	}
+
+	songs, err := gc.groupService.GetGroupSongs(c.UserContext(), c.Params("id"), page)
+	if err != nil {
+		return errorResponse(c, err, "Failed to fetch group songs")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Songs fetched successfully",
+		Data:    songs,
+	})
+}
+
+// requestActor возвращает автора изменения из заголовка X-Actor.
+func requestActor(c *fiber.Ctx) string {
+	return song_dto.NormalizeActor(c.Get(actorHeader))
+}
+
+// errorStatus сопоставляет ошибкам сервиса HTTP-статус, неизвестные ошибки - 500.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, dto.ErrInvalidGroupID),
+		errors.Is(err, dto.ErrInvalidGroupName),
+		errors.Is(err, song_dto.ErrInvalidCursor),
+		errors.Is(err, song_dto.ErrOffsetTooLarge),
+		errors.Is(err, song_dto.ErrCursorAndOffset):
+		return fiber.StatusBadRequest
+	case errors.Is(err, dto.ErrGroupNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, dto.ErrGroupExists),
+		errors.Is(err, dto.ErrGroupHasSongs):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// errorResponse отвечает статусом из errorStatus. Текст ошибок клиента отдаётся
+// как есть, для внутренних ошибок используется message.
+func errorResponse(c *fiber.Ctx, err error, message string) error {
+	status := errorStatus(err)
+	if status != fiber.StatusInternalServerError {
+		message = err.Error()
+	}
+	return c.Status(status).JSON(Response{
+		Success: false,
+		Message: message,
+	})
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"errors"
+
+	song_dto "root/module/song/dto"
+)
+
+// GroupInput - тело запросов создания и переименования группы.
+type GroupInput struct {
+	Name string `json:"name"`
+}
+
+var (
+	ErrInvalidGroupID   = errors.New("invalid group id")
+	ErrInvalidGroupName = errors.New("group name must be between 1 and 255 characters")
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrGroupExists      = errors.New("group with this name already exists")
+	ErrGroupHasSongs    = errors.New("group still has songs, use cascade=true to delete them")
+)
+
+// GroupPage - страница списка групп и общее число групп.
+type GroupPage struct {
+	Groups []song_dto.Group `json:"groups"`
+	Total  int64            `json:"total"`
+}
@@ -0,0 +1,87 @@
+package group_module
+
+import (
+	"root/config"
+	"root/database"
+	group_controller "root/module/group/controller"
+	group_repo "root/module/group/repository"
+	group_service "root/module/group/service"
+	song_service "root/module/song/service"
+	"root/shared/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GroupModule struct {
+	groupController group_controller.IGroupController
+	groupService    group_service.IGroupService
+	groupRepository group_repo.IGroupRepository
+	songService     song_service.ISongService
+	logger          *logger.Logger
+	config          *config.Config
+	cluster         *database.Cluster
+}
+
+func NewGroupModule(logger *logger.Logger, config *config.Config, cluster *database.Cluster, songService song_service.ISongService) *GroupModule {
+	return &GroupModule{
+		logger:      logger,
+		config:      config,
+		cluster:     cluster,
+		songService: songService,
+	}
+}
+
+func (m *GroupModule) GroupRepository() group_repo.IGroupRepository {
+	if m.groupRepository == nil {
+		m.groupRepository = group_repo.NewGroupRepository(m.logger, m.cluster)
+	}
+	return m.groupRepository
+}
+
+func (m *GroupModule) GroupController() group_controller.IGroupController {
+	if m.groupController == nil {
+		m.groupController = group_controller.NewGroupController(m.logger, m.GroupService())
+	}
+	return m.groupController
+}
+
+func (m *GroupModule) GroupService() group_service.IGroupService {
+	if m.groupService == nil {
+		m.groupService = group_service.NewGroupService(m.logger, m.GroupRepository(), m.songService)
+	}
+	return m.groupService
+}
+
+func (m *GroupModule) InitRoutes(router fiber.Router) {
+	group := router.Group("/group")
+
+	//получить все
+	group.Get("/", func(c *fiber.Ctx) error {
+		return m.GroupController().GetGroups(c)
+	})
+
+	//получить по id
+	group.Get("/:id", func(c *fiber.Ctx) error {
+		return m.GroupController().GetGroup(c)
+	})
+
+	//песни группы
+	group.Get("/:id/songs", func(c *fiber.Ctx) error {
+		return m.GroupController().GetGroupSongs(c)
+	})
+
+	//создать
+	group.Post("/", func(c *fiber.Ctx) error {
+		return m.GroupController().CreateGroup(c)
+	})
+
+	//переименовать по id
+	group.Put("/:id", func(c *fiber.Ctx) error {
+		return m.GroupController().RenameGroup(c)
+	})
+
+	//удалить по id
+	group.Delete("/:id", func(c *fiber.Ctx) error {
+		return m.GroupController().DeleteGroup(c)
+	})
+}
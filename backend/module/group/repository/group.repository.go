@@ -0,0 +1,213 @@
+package group_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"root/database"
+	"root/module/group/dto"
+	song_dto "root/module/song/dto"
+	"root/shared/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ IGroupRepository = (*GroupRepository)(nil)
+
+const (
+	// uniqueViolation - код ошибки Postgres при нарушении уникального индекса.
+	uniqueViolation = "23505"
+	// revisionBatchSize - число ревизий песен, вставляемых одним запросом.
+	revisionBatchSize = 500
+)
+
+type IGroupRepository interface {
+	ListGroups(ctx context.Context, offset, limit int) ([]song_dto.Group, int64, error)
+	GetGroup(ctx context.Context, id int) (*song_dto.Group, error)
+	CreateGroup(ctx context.Context, name string) (*song_dto.Group, error)
+	RenameGroup(ctx context.Context, id int, name, actor string) (*song_dto.Group, error)
+	DeleteGroup(ctx context.Context, id int, cascade bool, actor string) (int64, error)
+}
+
+type GroupRepository struct {
+	logger  *logger.Logger
+	db      *gorm.DB
+	cluster *database.Cluster
+}
+
+func NewGroupRepository(logger *logger.Logger, cluster *database.Cluster) *GroupRepository {
+	return &GroupRepository{
+		logger:  logger,
+		db:      cluster.Writer(),
+		cluster: cluster,
+	}
+}
+
+func (r *GroupRepository) ListGroups(ctx context.Context, offset, limit int) ([]song_dto.Group, int64, error) {
+	// Счётчик и страница читаются из одной реплики.
+	db := r.cluster.Reader(ctx).WithContext(ctx)
+	var total int64
+	if err := db.Model(&song_dto.Group{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при подсчёте групп: %w", err)
+	}
+
+	groups := []song_dto.Group{}
+	if err := db.Order("name, id").Offset(offset).Limit(limit).Find(&groups).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении групп: %w", err)
+	}
+	return groups, total, nil
+}
+
+func (r *GroupRepository) GetGroup(ctx context.Context, id int) (*song_dto.Group, error) {
+	group := new(song_dto.Group)
+	if err := r.cluster.Reader(ctx).WithContext(ctx).Where("id = ?", id).Take(group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("ошибка при поиске группы: %w", err)
+	}
+	return group, nil
+}
+
+// CreateGroup создаёт группу. Занятое название отклоняет уникальный индекс
+// groups_name_key, поэтому параллельные запросы не создают дубликатов.
+func (r *GroupRepository) CreateGroup(ctx context.Context, name string) (*song_dto.Group, error) {
+	group := &song_dto.Group{Name: name}
+	if err := r.db.WithContext(ctx).Create(group).Error; err != nil {
+		if isDuplicateName(err) {
+			return nil, dto.ErrGroupExists
+		}
+		return nil, fmt.Errorf("не удалось создать группу: %w", err)
+	}
+	return group, nil
+}
+
+// RenameGroup переименовывает группу и в той же транзакции обновляет
+// денормализованное название во всех шардах songs, чтобы ни один читатель не
+// увидел группу с новым именем и песни со старым. Песни группы должны лежать
+// в её шарде, но обновляются все шарды: строки, оставшиеся в чужом шарде,
+// тоже не должны расходиться с таблицей groups. Версии песен увеличиваются,
+// так как их представление изменилось, и каждой песне записывается ревизия
+// от имени actor.
+func (r *GroupRepository) RenameGroup(ctx context.Context, id int, name, actor string) (*song_dto.Group, error) {
+	group := new(song_dto.Group)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id, group); err != nil {
+			return err
+		}
+		if group.Name == name {
+			return nil
+		}
+		diff := song_dto.RevisionDiff{"group": {From: group.Name, To: name}}
+
+		if err := tx.Model(group).Update("name", name).Error; err != nil {
+			if isDuplicateName(err) {
+				return dto.ErrGroupExists
+			}
+			return fmt.Errorf("не удалось переименовать группу: %w", err)
+		}
+
+		for _, tableName := range database.ShardTables() {
+			renamed, err := reviseSongs(tx, `UPDATE `+tableName+` SET "group" = ?, version = version + 1
+				WHERE group_id = ? RETURNING *`, []interface{}{name, id}, diff, song_dto.RevisionUpdate, actor)
+			if err != nil {
+				return fmt.Errorf("не удалось обновить песни в %s: %w", tableName, err)
+			}
+			if renamed > 0 {
+				r.logger.Infof("RenameGroup: renamed group in %d songs of %s", renamed, tableName)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup удаляет группу. Если у группы есть песни, без cascade удаление
+// отклоняется, с cascade песни в той же транзакции переносятся в корзину с
+// ревизией удаления от имени actor, как при удалении одной песни. Песни в
+// корзине остаются восстановимыми: восстановление вернёт и группу. Возвращает
+// число перенесённых в корзину песен.
+func (r *GroupRepository) DeleteGroup(ctx context.Context, id int, cascade bool, actor string) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id, new(song_dto.Group)); err != nil {
+			return err
+		}
+
+		if !cascade {
+			for _, tableName := range database.ShardTables() {
+				var count int64
+				if err := tx.Table(tableName).Where("group_id = ? AND deleted_at IS NULL", id).Count(&count).Error; err != nil {
+					return fmt.Errorf("ошибка при подсчёте песен в %s: %w", tableName, err)
+				}
+				if count > 0 {
+					return dto.ErrGroupHasSongs
+				}
+			}
+		}
+
+		for _, tableName := range database.ShardTables() {
+			trashed, err := reviseSongs(tx, `UPDATE `+tableName+` SET deleted_at = ?, version = version + 1
+				WHERE group_id = ? AND deleted_at IS NULL RETURNING *`, []interface{}{time.Now(), id},
+				song_dto.RevisionDiff{}, song_dto.RevisionDelete, actor)
+			if err != nil {
+				return fmt.Errorf("не удалось удалить песни из %s: %w", tableName, err)
+			}
+			deleted += trashed
+		}
+
+		return tx.Where("id = ?", id).Delete(&song_dto.Group{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// reviseSongs выполняет изменение песен query с RETURNING * и записывает
+// каждой изменённой песне ревизию action. Возвращает число изменённых песен.
+func reviseSongs(tx *gorm.DB, query string, args []interface{}, diff song_dto.RevisionDiff, action song_dto.RevisionAction, actor string) (int64, error) {
+	var songs []song_dto.Song
+	if err := tx.Raw(query, args...).Scan(&songs).Error; err != nil {
+		return 0, err
+	}
+	if len(songs) == 0 {
+		return 0, nil
+	}
+
+	revisions := make([]song_dto.SongRevision, len(songs))
+	for i := range songs {
+		revisions[i] = song_dto.NewSongRevision(&songs[i], diff, action, actor)
+	}
+	if err := tx.CreateInBatches(&revisions, revisionBatchSize).Error; err != nil {
+		return 0, fmt.Errorf("не удалось записать ревизии песен: %w", err)
+	}
+	return int64(len(songs)), nil
+}
+
+// lockGroup читает группу с блокировкой строки до конца транзакции.
+func lockGroup(tx *gorm.DB, id int, group *song_dto.Group) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Take(group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrGroupNotFound
+		}
+		return fmt.Errorf("ошибка при поиске группы: %w", err)
+	}
+	return nil
+}
+
+// isDuplicateName сообщает, нарушает ли ошибка уникальный индекс названий групп.
+func isDuplicateName(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == uniqueViolation &&
+		pgErr.ConstraintName == database.GroupNameKey
+}
@@ -0,0 +1,166 @@
+package group_service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"root/module/group/dto"
+	group_repository "root/module/group/repository"
+	song_dto "root/module/song/dto"
+	song_service "root/module/song/service"
+	"root/shared/logger"
+)
+
+// maxGroupNameLength - ограничение длины названия группы в символах.
+const maxGroupNameLength = 255
+
+type IGroupService interface {
+	GetGroups(ctx context.Context, offset, limit int) (*dto.GroupPage, error)
+	GetGroup(ctx context.Context, groupID string) (*song_dto.Group, error)
+	CreateGroup(ctx context.Context, name string) (*song_dto.Group, error)
+	RenameGroup(ctx context.Context, groupID, name, actor string) (*song_dto.Group, error)
+	DeleteGroup(ctx context.Context, groupID string, cascade bool, actor string) (int64, error)
+	GetGroupSongs(ctx context.Context, groupID string, page song_dto.PageRequest) (*song_dto.SongPage, error)
+}
+
+type GroupService struct {
+	repo        group_repository.IGroupRepository
+	songService song_service.ISongService
+	logger      *logger.Logger
+}
+
+func NewGroupService(logger *logger.Logger, repo group_repository.IGroupRepository, songService song_service.ISongService) IGroupService {
+	return &GroupService{
+		logger:      logger,
+		repo:        repo,
+		songService: songService,
+	}
+}
+
+func (s *GroupService) GetGroups(ctx context.Context, offset, limit int) (*dto.GroupPage, error) {
+	s.logger.Info("GetGroups: started")
+	defer s.logger.Info("GetGroups: completed")
+
+	groups, total, err := s.repo.ListGroups(ctx, offset, limit)
+	if err != nil {
+		s.logger.Errorf("GetGroups: failed to fetch groups: %v", err)
+		return nil, err
+	}
+	return &dto.GroupPage{Groups: groups, Total: total}, nil
+}
+
+func (s *GroupService) GetGroup(ctx context.Context, groupID string) (*song_dto.Group, error) {
+	s.logger.Info("GetGroup: started")
+	defer s.logger.Info("GetGroup: completed")
+
+	id, err := parseGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetGroup(ctx, id)
+}
+
+func (s *GroupService) CreateGroup(ctx context.Context, name string) (*song_dto.Group, error) {
+	s.logger.Info("CreateGroup: started")
+	defer s.logger.Info("CreateGroup: completed")
+
+	name, err := normalizeGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.CreateGroup(ctx, name)
+	if err != nil {
+		s.logger.Errorf("CreateGroup: failed to create group %q: %v", name, err)
+		return nil, err
+	}
+
+	s.logger.Infof("CreateGroup: group %d created", group.ID)
+	return group, nil
+}
+
+// RenameGroup переименовывает группу вместе с названием группы у всех её песен.
+// Изменение песен записывается в их историю от имени actor.
+func (s *GroupService) RenameGroup(ctx context.Context, groupID, name, actor string) (*song_dto.Group, error) {
+	s.logger.Info("RenameGroup: started")
+	defer s.logger.Info("RenameGroup: completed")
+
+	id, err := parseGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = normalizeGroupName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.RenameGroup(ctx, id, name, actor)
+	if err != nil {
+		s.logger.Errorf("RenameGroup: failed to rename group %d: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.Infof("RenameGroup: group %d renamed to %q", id, name)
+	return group, nil
+}
+
+// DeleteGroup удаляет группу. Группа с песнями удаляется только с cascade,
+// песни при этом переносятся в корзину от имени actor. Возвращает число
+// удалённых песен.
+func (s *GroupService) DeleteGroup(ctx context.Context, groupID string, cascade bool, actor string) (int64, error) {
+	s.logger.Info("DeleteGroup: started")
+	defer s.logger.Info("DeleteGroup: completed")
+
+	id, err := parseGroupID(groupID)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.DeleteGroup(ctx, id, cascade, actor)
+	if err != nil {
+		s.logger.Errorf("DeleteGroup: failed to delete group %d: %v", id, err)
+		return 0, err
+	}
+
+	s.logger.Infof("DeleteGroup: group %d deleted with %d songs", id, deleted)
+	return deleted, nil
+}
+
+// GetGroupSongs возвращает страницу песен группы. Песни группы лежат в одном
+// шарде, поэтому запрос не расходится по всем шардам.
+func (s *GroupService) GetGroupSongs(ctx context.Context, groupID string, page song_dto.PageRequest) (*song_dto.SongPage, error) {
+	s.logger.Info("GetGroupSongs: started")
+	defer s.logger.Info("GetGroupSongs: completed")
+
+	id, err := parseGroupID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetGroup(ctx, id); err != nil {
+		return nil, err
+	}
+
+	filter := &song_dto.SongFilter{
+		GroupID: &id,
+		Sort:    song_dto.SongSort{Field: song_dto.SortByID},
+	}
+	return s.songService.GetSongs(ctx, filter, page)
+}
+
+func parseGroupID(groupID string) (int, error) {
+	id, err := strconv.Atoi(groupID)
+	if err != nil || id < 1 {
+		return 0, dto.ErrInvalidGroupID
+	}
+	return id, nil
+}
+
+func normalizeGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxGroupNameLength {
+		return "", dto.ErrInvalidGroupName
+	}
+	return name, nil
+}
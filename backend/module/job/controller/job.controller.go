@@ -0,0 +1,78 @@
+package job_controller
+
+import (
+	"errors"
+	"root/module/job/dto"
+	job_service "root/module/job/service"
+	"root/shared/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type IJobController interface {
+	GetJob(c *fiber.Ctx) error
+}
+
+type JobController struct {
+	logger     *logger.Logger
+	jobService job_service.IJobService
+}
+
+type Response struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data"`
+}
+
+func NewJobController(logger *logger.Logger, jobService job_service.IJobService) IJobController {
+	return &JobController{
+		logger:     logger,
+		jobService: jobService,
+	}
+}
+
+// GetJob возвращает состояние задачи добавления песни
+// @Summary Статус задачи добавления песни
+// @Description Возвращает состояние фоновой задачи, созданной запросом POST /api/song: pending - ожидает в очереди, running - выполняется, succeeded - песня добавлена (song_id содержит её ID), failed - задача завершилась ошибкой (причина в поле error). Задача, упавшая из-за временной недоступности внешнего API или базы данных, возвращается в pending и повторяется с растущей паузой не раньше run_after; attempts - число сделанных попыток. В failed задача переходит, если песни нет во внешнем API, его ответ некорректен или попытки (JOB_MAX_ATTEMPTS) исчерпаны: такую задачу клиент может только отправить заново.
+// @Tags Задачи
+// @Accept json
+// @Produce json
+// @Param id path string true "ID задачи из ответа POST /api/song."
+// @Success 200 {object} Response{data=dto.Job} "Состояние задачи."
+// @Failure 400 {object} Response "Некорректный ID задачи."
+// @Failure 404 {object} Response "Задача с указанным ID не найдена."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/jobs/{id} [get]
+func (jc *JobController) GetJob(c *fiber.Ctx) error {
+	jc.logger.Info("GetJob: started")
+	defer jc.logger.Info("GetJob: completed")
+
+	job, err := jc.jobService.GetJob(c.UserContext(), c.Params("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidJobID):
+			return c.Status(fiber.StatusBadRequest).JSON(Response{
+				Success: false,
+				Message: err.Error(),
+			})
+		case errors.Is(err, dto.ErrJobNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(Response{
+				Success: false,
+				Message: err.Error(),
+			})
+		}
+		jc.logger.Errorf("GetJob: failed to fetch job: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(Response{
+			Success: false,
+			Message: "Failed to fetch job",
+		})
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Job fetched successfully",
+		Data:    job,
+	})
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job - задача на обогащение песни данными внешнего API и её добавление в
+// библиотеку. Задачи хранятся в БД и переживают перезапуск приложения.
+// Задача, упавшая из-за временной ошибки, возвращается в pending и запускается
+// снова не раньше RunAfter; в failed она переходит после постоянной ошибки или
+// исчерпания попыток.
+type Job struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Status     JobStatus  `json:"status" gorm:"type:varchar(16);not null;index"`
+	Group      string     `json:"group" gorm:"not null"`
+	Song       string     `json:"song" gorm:"not null"`
+	Actor      string     `json:"actor" gorm:"not null;default:''"`
+	SongID     *uuid.UUID `json:"song_id,omitempty" gorm:"type:uuid"`
+	Error      string     `json:"error,omitempty" gorm:"type:text"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`
+	RunAfter   *time.Time `json:"run_after,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null;index"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+var (
+	ErrInvalidJobID = errors.New("invalid job id")
+	ErrJobNotFound  = errors.New("job not found")
+)
+
+// permanentError - ошибка задачи, которую повтор не исправит.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent помечает ошибку обработчика задачи как постоянную: задача сразу
+// переходит в failed без повторов. Остальные ошибки считаются временными.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent сообщает, помечена ли ошибка через Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
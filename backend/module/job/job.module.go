@@ -0,0 +1,59 @@
+package job_module
+
+import (
+	"root/config"
+	job_controller "root/module/job/controller"
+	job_repo "root/module/job/repository"
+	job_service "root/module/job/service"
+	"root/shared/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type JobModule struct {
+	jobController job_controller.IJobController
+	jobService    job_service.IJobService
+	jobRepository job_repo.IJobRepository
+	logger        *logger.Logger
+	config        *config.Config
+	db            *gorm.DB
+}
+
+func NewJobModule(logger *logger.Logger, config *config.Config, db *gorm.DB) *JobModule {
+	return &JobModule{
+		logger: logger,
+		config: config,
+		db:     db,
+	}
+}
+
+func (m *JobModule) JobRepository() job_repo.IJobRepository {
+	if m.jobRepository == nil {
+		m.jobRepository = job_repo.NewJobRepository(m.logger, m.db)
+	}
+	return m.jobRepository
+}
+
+func (m *JobModule) JobController() job_controller.IJobController {
+	if m.jobController == nil {
+		m.jobController = job_controller.NewJobController(m.logger, m.JobService())
+	}
+	return m.jobController
+}
+
+func (m *JobModule) JobService() job_service.IJobService {
+	if m.jobService == nil {
+		m.jobService = job_service.NewJobService(m.logger, m.config, m.JobRepository())
+	}
+	return m.jobService
+}
+
+func (m *JobModule) InitRoutes(router fiber.Router) {
+	jobs := router.Group("/jobs")
+
+	//статус задачи по id
+	jobs.Get("/:id", func(c *fiber.Ctx) error {
+		return m.JobController().GetJob(c)
+	})
+}
@@ -0,0 +1,155 @@
+package job_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"root/module/job/dto"
+	"root/shared/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ IJobRepository = (*JobRepository)(nil)
+
+type IJobRepository interface {
+	CreateJob(ctx context.Context, job *dto.Job) error
+	GetJob(ctx context.Context, id uuid.UUID) (*dto.Job, error)
+	ClaimNext(ctx context.Context) (*dto.Job, error)
+	CompleteJob(ctx context.Context, id uuid.UUID, songID uuid.UUID) error
+	FailJob(ctx context.Context, id uuid.UUID, reason string) error
+	RetryJob(ctx context.Context, id uuid.UUID, reason string, runAfter time.Time) error
+	RequeueJob(ctx context.Context, id uuid.UUID) error
+	RequeueStale(ctx context.Context, startedBefore time.Time) (int64, error)
+}
+
+type JobRepository struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewJobRepository(logger *logger.Logger, db *gorm.DB) *JobRepository {
+	return &JobRepository{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (r *JobRepository) CreateJob(ctx context.Context, job *dto.Job) error {
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("не удалось создать задачу: %w", err)
+	}
+	return nil
+}
+
+func (r *JobRepository) GetJob(ctx context.Context, id uuid.UUID) (*dto.Job, error) {
+	job := new(dto.Job)
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Take(job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrJobNotFound
+		}
+		return nil, fmt.Errorf("ошибка при поиске задачи: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimNext атомарно переводит самую старую ожидающую задачу, время повтора
+// которой наступило, в running, увеличивает число её попыток и возвращает её.
+// SKIP LOCKED позволяет нескольким воркерам и репликам разбирать очередь без
+// блокировок друг друга. Если задач нет, возвращает nil.
+func (r *JobRepository) ClaimNext(ctx context.Context) (*dto.Job, error) {
+	job := new(dto.Job)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (run_after IS NULL OR run_after <= ?)", dto.JobPending, time.Now()).
+			Order("created_at").
+			Take(job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = dto.JobRunning
+		job.StartedAt = &now
+		job.Attempts++
+		return tx.Model(job).Select("status", "started_at", "attempts", "updated_at").Updates(job).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка при получении задачи из очереди: %w", err)
+	}
+	return job, nil
+}
+
+func (r *JobRepository) CompleteJob(ctx context.Context, id uuid.UUID, songID uuid.UUID) error {
+	return r.finish(ctx, id, map[string]interface{}{
+		"status":  dto.JobSucceeded,
+		"song_id": songID,
+		"error":   "",
+	})
+}
+
+func (r *JobRepository) FailJob(ctx context.Context, id uuid.UUID, reason string) error {
+	return r.finish(ctx, id, map[string]interface{}{
+		"status": dto.JobFailed,
+		"error":  reason,
+	})
+}
+
+// RetryJob возвращает задачу, упавшую из-за временной ошибки, в очередь:
+// её снова возьмут не раньше runAfter. Причина ошибки сохраняется до повтора.
+func (r *JobRepository) RetryJob(ctx context.Context, id uuid.UUID, reason string, runAfter time.Time) error {
+	err := r.db.WithContext(ctx).Model(&dto.Job{}).
+		Where("id = ? AND status = ?", id, dto.JobRunning).
+		Updates(map[string]interface{}{
+			"status":     dto.JobPending,
+			"error":      reason,
+			"started_at": nil,
+			"run_after":  runAfter,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("не удалось вернуть задачу в очередь: %w", err)
+	}
+	return nil
+}
+
+// RequeueJob возвращает прерванную задачу в очередь, например при остановке
+// приложения. Прерванный запуск не считается попыткой.
+func (r *JobRepository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&dto.Job{}).
+		Where("id = ? AND status = ?", id, dto.JobRunning).
+		Updates(map[string]interface{}{
+			"status":     dto.JobPending,
+			"started_at": nil,
+			"attempts":   gorm.Expr("GREATEST(attempts - 1, 0)"),
+		}).Error
+}
+
+// RequeueStale возвращает в очередь задачи, которые слишком долго висят в running:
+// их воркер упал или приложение было остановлено без корректного завершения.
+func (r *JobRepository) RequeueStale(ctx context.Context, startedBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&dto.Job{}).
+		Where("status = ? AND started_at < ?", dto.JobRunning, startedBefore).
+		Updates(map[string]interface{}{"status": dto.JobPending, "started_at": nil})
+	if result.Error != nil {
+		return 0, fmt.Errorf("ошибка при возврате зависших задач: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *JobRepository) finish(ctx context.Context, id uuid.UUID, fields map[string]interface{}) error {
+	fields["finished_at"] = time.Now()
+	err := r.db.WithContext(ctx).Model(&dto.Job{}).
+		Where("id = ? AND status = ?", id, dto.JobRunning).
+		Updates(fields).Error
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить результат задачи: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package job_service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"root/config"
+	"root/module/job/dto"
+	job_repository "root/module/job/repository"
+	"root/shared/logger"
+
+	"github.com/google/uuid"
+)
+
+// Handler выполняет задачу и возвращает ID созданной песни.
+type Handler func(ctx context.Context, job *dto.Job) (uuid.UUID, error)
+
+type IJobService interface {
+	Enqueue(ctx context.Context, group, song, actor string) (*dto.Job, error)
+	GetJob(ctx context.Context, jobID string) (*dto.Job, error)
+	Start(ctx context.Context, handler Handler)
+	Wait()
+}
+
+type JobService struct {
+	repo   job_repository.IJobRepository
+	logger *logger.Logger
+	config *config.Config
+
+	// wake будит простаивающий воркер сразу после постановки задачи в очередь,
+	// не дожидаясь следующего опроса БД.
+	wake chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewJobService(logger *logger.Logger, config *config.Config, repo job_repository.IJobRepository) IJobService {
+	return &JobService{
+		logger: logger,
+		config: config,
+		repo:   repo,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Enqueue ставит в очередь добавление песни. actor - автор запроса, он
+// записывается в историю созданной песни.
+func (s *JobService) Enqueue(ctx context.Context, group, song, actor string) (*dto.Job, error) {
+	s.logger.Info("Enqueue: started")
+	defer s.logger.Info("Enqueue: completed")
+
+	job := &dto.Job{
+		ID:     uuid.New(),
+		Status: dto.JobPending,
+		Group:  group,
+		Song:   song,
+		Actor:  actor,
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		s.logger.Errorf("Enqueue: failed to create job: %v", err)
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.logger.Infof("Enqueue: job %s queued for %s - %s", job.ID, group, song)
+	return job, nil
+}
+
+func (s *JobService) GetJob(ctx context.Context, jobID string) (*dto.Job, error) {
+	s.logger.Info("GetJob: started")
+	defer s.logger.Info("GetJob: completed")
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		return nil, dto.ErrInvalidJobID
+	}
+	return s.repo.GetJob(ctx, id)
+}
+
+// Start запускает пул воркеров и периодический возврат зависших задач.
+// Воркеры работают до отмены ctx, дождаться их завершения можно через Wait.
+func (s *JobService) Start(ctx context.Context, handler Handler) {
+	s.logger.Infof("🧵 Starting %d job workers", s.config.JobWorkers)
+
+	s.requeueStale(ctx)
+
+	for i := 0; i < s.config.JobWorkers; i++ {
+		s.wg.Add(1)
+		go func(worker int) {
+			defer s.wg.Done()
+			s.runWorker(ctx, worker, handler)
+		}(i)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.config.JobLease / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.requeueStale(ctx)
+			}
+		}
+	}()
+}
+
+func (s *JobService) Wait() {
+	s.wg.Wait()
+}
+
+func (s *JobService) runWorker(ctx context.Context, worker int, handler Handler) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := s.repo.ClaimNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Errorf("runWorker %d: %v", worker, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			case <-time.After(s.config.JobPollInterval):
+			}
+			continue
+		}
+
+		s.process(ctx, worker, handler, job)
+	}
+}
+
+func (s *JobService) process(ctx context.Context, worker int, handler Handler, job *dto.Job) {
+	s.logger.Infof("runWorker %d: processing job %s", worker, job.ID)
+
+	songID, err := safeHandle(ctx, handler, job)
+
+	// Результат сохраняется и после отмены ctx, иначе задача останется в running
+	// до истечения аренды.
+	bookkeeping := context.Background()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		s.logger.Warnf("runWorker %d: job %s interrupted, returning to queue", worker, job.ID)
+		err = s.repo.RequeueJob(bookkeeping, job.ID)
+	case err != nil && !dto.IsPermanent(err) && job.Attempts < s.config.JobMaxAttempts:
+		delay := s.retryDelay(job.Attempts)
+		s.logger.Warnf("runWorker %d: job %s attempt %d failed, retrying in %v: %v", worker, job.ID, job.Attempts, delay, err)
+		err = s.repo.RetryJob(bookkeeping, job.ID, err.Error(), time.Now().Add(delay))
+	case err != nil:
+		s.logger.Errorf("runWorker %d: job %s failed after %d attempts: %v", worker, job.ID, job.Attempts, err)
+		err = s.repo.FailJob(bookkeeping, job.ID, err.Error())
+	default:
+		s.logger.Infof("runWorker %d: job %s succeeded, song %s", worker, job.ID, songID)
+		err = s.repo.CompleteJob(bookkeeping, job.ID, songID)
+	}
+	if err != nil {
+		s.logger.Errorf("runWorker %d: failed to save job %s result: %v", worker, job.ID, err)
+	}
+}
+
+// retryDelay возвращает паузу перед повтором после attempt-й попытки:
+// JOB_RETRY_BACKOFF, удваиваемый с каждой попыткой, но не больше
+// JOB_RETRY_MAX_BACKOFF.
+func (s *JobService) retryDelay(attempt int) time.Duration {
+	delay := s.config.JobRetryBackoff
+	for i := 1; i < attempt && delay < s.config.JobRetryMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.config.JobRetryMaxBackoff)
+}
+
+func (s *JobService) requeueStale(ctx context.Context) {
+	count, err := s.repo.RequeueStale(ctx, time.Now().Add(-s.config.JobLease))
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Errorf("requeueStale: %v", err)
+		}
+		return
+	}
+	if count > 0 {
+		s.logger.Warnf("requeueStale: returned %d stale jobs to queue", count)
+	}
+}
+
+// safeHandle не даёт панике в обработчике остановить воркер.
+func safeHandle(ctx context.Context, handler Handler, job *dto.Job) (songID uuid.UUID, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,88 @@
+package job_service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"root/config"
+	"root/module/job/dto"
+	job_repository "root/module/job/repository"
+	"root/shared/logger"
+
+	"github.com/google/uuid"
+)
+
+// fakeRepository запоминает, чем закончилась обработка задачи.
+type fakeRepository struct {
+	job_repository.IJobRepository
+	outcome  string
+	runAfter time.Time
+}
+
+func (r *fakeRepository) CompleteJob(ctx context.Context, id uuid.UUID, songID uuid.UUID) error {
+	r.outcome = "completed"
+	return nil
+}
+
+func (r *fakeRepository) FailJob(ctx context.Context, id uuid.UUID, reason string) error {
+	r.outcome = "failed"
+	return nil
+}
+
+func (r *fakeRepository) RetryJob(ctx context.Context, id uuid.UUID, reason string, runAfter time.Time) error {
+	r.outcome, r.runAfter = "retried", runAfter
+	return nil
+}
+
+func (r *fakeRepository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	r.outcome = "requeued"
+	return nil
+}
+
+func TestProcessRetriesTransientErrors(t *testing.T) {
+	unavailable := errors.New("external API is unavailable")
+	cases := []struct {
+		name     string
+		err      error
+		attempts int
+		want     string
+	}{
+		{"success", nil, 1, "completed"},
+		{"transient error", unavailable, 1, "retried"},
+		{"permanent error", dto.Permanent(unavailable), 1, "failed"},
+		{"attempts exhausted", unavailable, 3, "failed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			service := NewJobService(logger.GetLogger(), &config.Config{
+				JobMaxAttempts:     3,
+				JobRetryBackoff:    time.Second,
+				JobRetryMaxBackoff: time.Minute,
+			}, repo).(*JobService)
+
+			handler := func(ctx context.Context, job *dto.Job) (uuid.UUID, error) {
+				return uuid.New(), tc.err
+			}
+			service.process(context.Background(), 0, handler, &dto.Job{ID: uuid.New(), Attempts: tc.attempts})
+			if repo.outcome != tc.want {
+				t.Errorf("outcome = %q, want %q", repo.outcome, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	service := &JobService{config: &config.Config{
+		JobRetryBackoff:    10 * time.Second,
+		JobRetryMaxBackoff: time.Minute,
+	}}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, delay := range want {
+		if got := service.retryDelay(i + 1); got != delay {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, delay)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package song_client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"root/config"
+	"root/module/song/dto"
+	"root/shared/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IDetailsCache - управление кэшем деталей песен.
+type IDetailsCache interface {
+	Invalidate(ctx context.Context, group, song string) error
+}
+
+var (
+	_ IMusicClient  = (*CachedClient)(nil)
+	_ IDetailsCache = (*CachedClient)(nil)
+)
+
+type CacheConfig struct {
+	// TTL - время жизни успешного ответа.
+	TTL time.Duration
+	// Size - максимальное число записей в памяти, лишние вытесняются по LRU.
+	Size int
+	// NegativeTTL - время жизни ответа 404; ноль отключает негативное кэширование.
+	NegativeTTL time.Duration
+	// PurgeInterval - период удаления просроченных записей из постоянного уровня.
+	PurgeInterval time.Duration
+}
+
+func NewCacheConfig(cfg *config.Config) CacheConfig {
+	return CacheConfig{
+		TTL:           cfg.SongDetailsCacheTTL,
+		Size:          cfg.SongDetailsCacheSize,
+		NegativeTTL:   cfg.SongDetailsCacheNegativeTTL,
+		PurgeInterval: cfg.SongDetailsCachePurgeInterval,
+	}
+}
+
+// CacheEntry - закэшированный ответ API: детали песни или отметка 404.
+type CacheEntry struct {
+	Details   *dto.SongDetails
+	NotFound  bool
+	ExpiresAt time.Time
+}
+
+// IDetailsStore - постоянный уровень кэша. Get возвращает nil для отсутствующих
+// и просроченных записей, Purge удаляет записи, истёкшие до before.
+type IDetailsStore interface {
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Put(ctx context.Context, key, group, song string, entry *CacheEntry) error
+	Delete(ctx context.Context, key string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IInvalidationBus рассылает инвалидации записей кэша всем экземплярам
+// приложения. Listen блокируется до отмены ctx: вызывает invalidate для каждой
+// полученной инвалидации и connected при подключении и потере связи.
+type IInvalidationBus interface {
+	Publish(ctx context.Context, key string) error
+	Listen(ctx context.Context, invalidate func(key string), connected func(ok bool))
+}
+
+// CachedClient кэширует ответы IMusicClient в памяти и, если задан store,
+// в постоянном хранилище. Ошибки хранилища не мешают обращению к API.
+//
+// С bus инвалидация доходит до памяти всех экземпляров. Память используется,
+// только пока подписка на инвалидации активна: без неё экземпляр не узнает об
+// инвалидации на другом экземпляре, поэтому при подключении память очищается,
+// а до него и после потери связи ответы берутся из store или API.
+type CachedClient struct {
+	inner  IMusicClient
+	store  IDetailsStore
+	bus    IInvalidationBus
+	memory *memoryCache
+	config CacheConfig
+	logger *logger.Logger
+	now    func() time.Time
+
+	// subscribed - подписка на инвалидации bus активна
+	subscribed atomic.Bool
+}
+
+// NewCachedClient оборачивает inner кэшем. store и bus могут быть nil; без bus
+// инвалидация действует только на память этого экземпляра.
+func NewCachedClient(logger *logger.Logger, cfg CacheConfig, inner IMusicClient, store IDetailsStore, bus IInvalidationBus) *CachedClient {
+	return &CachedClient{
+		inner:  inner,
+		store:  store,
+		bus:    bus,
+		memory: newMemoryCache(cfg.Size),
+		config: cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Start подписывается на инвалидации других экземпляров и периодически удаляет
+// просроченные записи из store. Работает до отмены ctx.
+func (c *CachedClient) Start(ctx context.Context) {
+	if c.bus != nil {
+		go c.bus.Listen(ctx, c.memory.delete, func(ok bool) {
+			if ok {
+				c.memory.clear()
+			}
+			c.subscribed.Store(ok)
+		})
+	}
+
+	if c.store != nil {
+		go func() {
+			ticker := time.NewTicker(c.config.PurgeInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				c.purge(ctx)
+			}
+		}()
+	}
+}
+
+// purge удаляет просроченные записи постоянного уровня.
+func (c *CachedClient) purge(ctx context.Context) {
+	purged, err := c.store.Purge(ctx, c.now())
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Warnf("purge: failed to purge song details cache: %v", err)
+		}
+		return
+	}
+	if purged > 0 {
+		c.logger.Infof("purge: removed %d expired song details cache entries", purged)
+	}
+}
+
+// memoryTrusted сообщает, можно ли отвечать из памяти.
+func (c *CachedClient) memoryTrusted() bool {
+	return c.bus == nil || c.subscribed.Load()
+}
+
+func (c *CachedClient) GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error) {
+	key := cacheKey(group, song)
+
+	if c.memoryTrusted() {
+		if entry, ok := c.memory.get(key, c.now()); ok {
+			c.logger.Debugf("GetSongDetails: memory cache hit for %q", key)
+			return entry.result()
+		}
+	}
+
+	if c.store != nil {
+		entry, err := c.store.Get(ctx, key)
+		if err != nil {
+			c.logger.Warnf("GetSongDetails: persistent cache read failed: %v", err)
+		} else if entry != nil {
+			c.logger.Debugf("GetSongDetails: persistent cache hit for %q", key)
+			c.memory.put(key, entry)
+			return entry.result()
+		}
+	}
+
+	details, err := c.inner.GetSongDetails(ctx, group, song)
+	switch {
+	case err == nil:
+		c.save(ctx, key, group, song, &CacheEntry{Details: details, ExpiresAt: c.now().Add(c.config.TTL)})
+	case errors.Is(err, ErrNotFound) && c.config.NegativeTTL > 0:
+		c.save(ctx, key, group, song, &CacheEntry{NotFound: true, ExpiresAt: c.now().Add(c.config.NegativeTTL)})
+	}
+	return details, err
+}
+
+// Invalidate удаляет закэшированный ответ для группы и песни из всех уровней
+// и, если задан bus, из памяти остальных экземпляров.
+func (c *CachedClient) Invalidate(ctx context.Context, group, song string) error {
+	key := cacheKey(group, song)
+	c.memory.delete(key)
+	if c.store != nil {
+		if err := c.store.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to invalidate persistent cache: %w", err)
+		}
+	}
+	if c.bus != nil {
+		if err := c.bus.Publish(ctx, key); err != nil {
+			return fmt.Errorf("failed to broadcast cache invalidation: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *CachedClient) save(ctx context.Context, key, group, song string, entry *CacheEntry) {
+	c.memory.put(key, entry)
+	if c.store != nil {
+		if err := c.store.Put(ctx, key, group, song, entry); err != nil {
+			c.logger.Warnf("GetSongDetails: persistent cache write failed: %v", err)
+		}
+	}
+}
+
+// result возвращает копию закэшированного ответа, чтобы вызывающий не мог
+// изменить запись в кэше.
+func (e *CacheEntry) result() (*dto.SongDetails, error) {
+	if e.NotFound {
+		return nil, &APIError{Kind: ErrNotFound, Err: errors.New("cached")}
+	}
+	details := *e.Details
+	return &details, nil
+}
+
+// cacheKey нормализует группу и песню так же, как пользователь их воспринимает:
+// без учёта регистра и крайних пробелов.
+func cacheKey(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
+
+// memoryCache - потокобезопасный LRU-кэш с истечением записей по времени.
+type memoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+func newMemoryCache(capacity int) *memoryCache {
+	return &memoryCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (m *memoryCache) get(key string, now time.Time) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := element.Value.(*memoryItem)
+	if !now.Before(item.entry.ExpiresAt) {
+		m.order.Remove(element)
+		delete(m.items, key)
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return item.entry, true
+}
+
+func (m *memoryCache) put(key string, entry *CacheEntry) {
+	if m.capacity <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+func (m *memoryCache) clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items = make(map[string]*list.Element)
+	m.order.Init()
+}
+
+func (m *memoryCache) delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		m.order.Remove(element)
+		delete(m.items, key)
+	}
+}
+
+// PostgresStore хранит кэш в таблице song_details_cache.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+var _ IDetailsStore = (*PostgresStore)(nil)
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	var rows []dto.SongDetailsCacheEntry
+	err := s.db.WithContext(ctx).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	row := rows[0]
+	entry := &CacheEntry{NotFound: row.NotFound, ExpiresAt: row.ExpiresAt}
+	if !row.NotFound {
+		entry.Details = &dto.SongDetails{ReleaseDate: row.ReleaseDate, Text: row.Text, Link: row.Link}
+	}
+	return entry, nil
+}
+
+func (s *PostgresStore) Put(ctx context.Context, key, group, song string, entry *CacheEntry) error {
+	row := dto.SongDetailsCacheEntry{
+		Key:       key,
+		Group:     group,
+		Song:      song,
+		NotFound:  entry.NotFound,
+		ExpiresAt: entry.ExpiresAt,
+	}
+	if entry.Details != nil {
+		row.ReleaseDate = entry.Details.ReleaseDate
+		row.Text = entry.Details.Text
+		row.Link = entry.Details.Link
+	}
+
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&dto.SongDetailsCacheEntry{}).Error
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&dto.SongDetailsCacheEntry{})
+	return result.RowsAffected, result.Error
+}
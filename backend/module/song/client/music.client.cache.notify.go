@@ -0,0 +1,93 @@
+package song_client
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"root/shared/logger"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// invalidationChannel - канал NOTIFY для инвалидаций кэша деталей песен.
+const invalidationChannel = "song_details_cache_invalidate"
+
+// maxListenBackoff - наибольшая пауза между попытками переподключения.
+const maxListenBackoff = time.Minute
+
+var _ IInvalidationBus = (*PostgresBus)(nil)
+
+// PostgresBus рассылает инвалидации кэша через LISTEN/NOTIFY. Ключи кэша
+// содержат нулевой байт, который нельзя передать в тексте NOTIFY, поэтому они
+// передаются в hex.
+//
+// Подписке нужно сессионное соединение: через пул соединений в режиме
+// транзакций (например, pgbouncer с pool_mode=transaction) LISTEN не работает,
+// и для url нужен прямой адрес сервера.
+type PostgresBus struct {
+	db     *gorm.DB
+	url    string
+	logger *logger.Logger
+}
+
+// NewPostgresBus публикует инвалидации через db, а подписывается отдельным
+// соединением к url.
+func NewPostgresBus(logger *logger.Logger, db *gorm.DB, url string) *PostgresBus {
+	return &PostgresBus{db: db, url: url, logger: logger}
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, key string) error {
+	return b.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", invalidationChannel, hex.EncodeToString([]byte(key))).Error
+}
+
+// Listen держит подписку и переподключается с растущей паузой после обрыва.
+func (b *PostgresBus) Listen(ctx context.Context, invalidate func(key string), connected func(ok bool)) {
+	backoff := time.Second
+	for {
+		err := b.listen(ctx, invalidate, func() {
+			backoff = time.Second
+			connected(true)
+		})
+		connected(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.logger.Warnf("Listen: cache invalidation subscription lost, retrying in %v: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
+
+func (b *PostgresBus) listen(ctx context.Context, invalidate func(key string), subscribed func()) error {
+	conn, err := pgx.Connect(ctx, b.url)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+invalidationChannel); err != nil {
+		return err
+	}
+	b.logger.Info("Listen: subscribed to cache invalidations")
+	subscribed()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		key, err := hex.DecodeString(notification.Payload)
+		if err != nil {
+			b.logger.Warnf("Listen: malformed cache invalidation %q", notification.Payload)
+			continue
+		}
+		invalidate(string(key))
+	}
+}
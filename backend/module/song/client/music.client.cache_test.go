@@ -0,0 +1,262 @@
+package song_client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"root/module/song/dto"
+	"root/shared/logger"
+)
+
+type fakeClient struct {
+	calls   int
+	details *dto.SongDetails
+	err     error
+}
+
+func (f *fakeClient) GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	details := *f.details
+	return &details, nil
+}
+
+type fakeStore struct {
+	entries map[string]*CacheEntry
+}
+
+func (f *fakeStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	entry, ok := f.entries[key]
+	if !ok || !time.Now().Before(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+func (f *fakeStore) Put(ctx context.Context, key, group, song string, entry *CacheEntry) error {
+	f.entries[key] = entry
+	return nil
+}
+
+func (f *fakeStore) Delete(ctx context.Context, key string) error {
+	delete(f.entries, key)
+	return nil
+}
+
+func (f *fakeStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for key, entry := range f.entries {
+		if entry.ExpiresAt.Before(before) {
+			delete(f.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func testDetails() *dto.SongDetails {
+	return &dto.SongDetails{ReleaseDate: "16.07.2006", Text: "la la", Link: "https://example.com"}
+}
+
+func TestCachedClientServesRepeatedLookupsFromMemory(t *testing.T) {
+	inner := &fakeClient{details: testDetails()}
+	client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 10}, inner, nil, nil)
+
+	for _, group := range []string{"Muse", " muse ", "MUSE"} {
+		if _, err := client.GetSongDetails(context.Background(), group, "Uprising"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("inner calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestCachedClientExpiresEntries(t *testing.T) {
+	inner := &fakeClient{details: testDetails()}
+	client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Minute, Size: 10}, inner, nil, nil)
+
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	now = now.Add(2 * time.Minute)
+	client.GetSongDetails(context.Background(), "Muse", "Uprising")
+
+	if inner.calls != 2 {
+		t.Errorf("inner calls = %d, want 2 after expiry", inner.calls)
+	}
+}
+
+func TestCachedClientEvictsLeastRecentlyUsed(t *testing.T) {
+	inner := &fakeClient{details: testDetails()}
+	client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 2}, inner, nil, nil)
+	ctx := context.Background()
+
+	client.GetSongDetails(ctx, "Muse", "A")
+	client.GetSongDetails(ctx, "Muse", "B")
+	client.GetSongDetails(ctx, "Muse", "A") // A становится самой свежей
+	client.GetSongDetails(ctx, "Muse", "C") // вытесняет B
+
+	client.GetSongDetails(ctx, "Muse", "A")
+	if inner.calls != 3 {
+		t.Fatalf("inner calls = %d, A must stay cached", inner.calls)
+	}
+	client.GetSongDetails(ctx, "Muse", "B")
+	if inner.calls != 4 {
+		t.Errorf("inner calls = %d, B must be evicted", inner.calls)
+	}
+}
+
+func TestCachedClientNegativeCaching(t *testing.T) {
+	notFound := &APIError{Kind: ErrNotFound, StatusCode: 404}
+
+	t.Run("disabled", func(t *testing.T) {
+		inner := &fakeClient{err: notFound}
+		client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 10}, inner, nil, nil)
+
+		client.GetSongDetails(context.Background(), "Muse", "Unknown")
+		client.GetSongDetails(context.Background(), "Muse", "Unknown")
+		if inner.calls != 2 {
+			t.Errorf("inner calls = %d, 404 must not be cached", inner.calls)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		inner := &fakeClient{err: notFound}
+		client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 10, NegativeTTL: time.Minute}, inner, nil, nil)
+
+		client.GetSongDetails(context.Background(), "Muse", "Unknown")
+		_, err := client.GetSongDetails(context.Background(), "Muse", "Unknown")
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want cached ErrNotFound", err)
+		}
+		if inner.calls != 1 {
+			t.Errorf("inner calls = %d, want 1", inner.calls)
+		}
+	})
+
+	t.Run("other errors are never cached", func(t *testing.T) {
+		inner := &fakeClient{err: &APIError{Kind: ErrUnavailable, StatusCode: 503}}
+		client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 10, NegativeTTL: time.Minute}, inner, nil, nil)
+
+		client.GetSongDetails(context.Background(), "Muse", "Uprising")
+		client.GetSongDetails(context.Background(), "Muse", "Uprising")
+		if inner.calls != 2 {
+			t.Errorf("inner calls = %d, want 2", inner.calls)
+		}
+	})
+}
+
+func TestCachedClientPersistentTierAndInvalidate(t *testing.T) {
+	store := &fakeStore{entries: map[string]*CacheEntry{}}
+	inner := &fakeClient{details: testDetails()}
+	cfg := CacheConfig{TTL: time.Hour, Size: 10}
+	ctx := context.Background()
+
+	NewCachedClient(logger.GetLogger(), cfg, inner, store, nil).GetSongDetails(ctx, "Muse", "Uprising")
+
+	// Новый экземпляр с пустой памятью, как после перезапуска
+	restarted := NewCachedClient(logger.GetLogger(), cfg, inner, store, nil)
+	if _, err := restarted.GetSongDetails(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inner.calls != 1 {
+		t.Fatalf("inner calls = %d, persistent tier must serve the restart", inner.calls)
+	}
+
+	if err := restarted.Invalidate(ctx, "muse", "uprising"); err != nil {
+		t.Fatalf("invalidate: %v", err)
+	}
+	if len(store.entries) != 0 {
+		t.Errorf("store still has %d entries", len(store.entries))
+	}
+	restarted.GetSongDetails(ctx, "Muse", "Uprising")
+	if inner.calls != 2 {
+		t.Errorf("inner calls = %d, invalidated entry must be refetched", inner.calls)
+	}
+}
+
+// fakeBus доставляет инвалидации всем подписанным клиентам синхронно.
+type fakeBus struct {
+	mu        sync.Mutex
+	listeners []func(key string)
+}
+
+func (b *fakeBus) Publish(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, invalidate := range b.listeners {
+		invalidate(key)
+	}
+	return nil
+}
+
+func (b *fakeBus) Listen(ctx context.Context, invalidate func(key string), connected func(ok bool)) {
+	b.mu.Lock()
+	b.listeners = append(b.listeners, invalidate)
+	b.mu.Unlock()
+	connected(true)
+}
+
+func TestCachedClientInvalidatesOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	bus := &fakeBus{}
+	cfg := CacheConfig{TTL: time.Hour, Size: 10, PurgeInterval: time.Hour}
+	inner := &fakeClient{details: testDetails()}
+	first := NewCachedClient(logger.GetLogger(), cfg, inner, nil, bus)
+	second := NewCachedClient(logger.GetLogger(), cfg, inner, nil, bus)
+
+	// До подписки память не используется: инвалидацию можно пропустить
+	first.GetSongDetails(ctx, "Muse", "Uprising")
+	first.GetSongDetails(ctx, "Muse", "Uprising")
+	if inner.calls != 2 {
+		t.Fatalf("inner calls before subscription = %d, want 2", inner.calls)
+	}
+
+	// Listen у fakeBus не блокируется, поэтому Start подписывается сразу;
+	// дождёмся горутины подписки.
+	first.Start(ctx)
+	second.Start(ctx)
+	deadline := time.Now().Add(time.Second)
+	for !(first.memoryTrusted() && second.memoryTrusted()) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	inner.calls = 0
+	first.GetSongDetails(ctx, "Muse", "Uprising")
+	second.GetSongDetails(ctx, "Muse", "Uprising")
+	second.GetSongDetails(ctx, "Muse", "Uprising")
+	if inner.calls != 2 {
+		t.Fatalf("inner calls after subscription = %d, want 2", inner.calls)
+	}
+
+	if err := first.Invalidate(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("Invalidate error = %v", err)
+	}
+	second.GetSongDetails(ctx, "Muse", "Uprising")
+	if inner.calls != 3 {
+		t.Errorf("inner calls = %d, want 3: second instance should drop the invalidated entry", inner.calls)
+	}
+}
+
+func TestCachedClientPurgesExpiredStoreEntries(t *testing.T) {
+	store := &fakeStore{entries: map[string]*CacheEntry{
+		"expired": {Details: testDetails(), ExpiresAt: time.Now().Add(-time.Minute)},
+		"fresh":   {Details: testDetails(), ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	client := NewCachedClient(logger.GetLogger(), CacheConfig{TTL: time.Hour, Size: 10}, &fakeClient{}, store, nil)
+
+	client.purge(context.Background())
+	if _, ok := store.entries["expired"]; ok {
+		t.Error("expired entry was not purged")
+	}
+	if _, ok := store.entries["fresh"]; !ok {
+		t.Error("fresh entry was purged")
+	}
+}
@@ -0,0 +1,40 @@
+package song_client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Виды ошибок внешнего API. Проверяются через errors.Is на *APIError.
+var (
+	ErrNotFound    = errors.New("song not found in external API")
+	ErrRateLimited = errors.New("external API rate limit exceeded")
+	ErrUnavailable = errors.New("external API is unavailable")
+	ErrMalformed   = errors.New("external API returned malformed response")
+)
+
+// APIError - ошибка обращения к внешнему API: вид ошибки, HTTP-статус последней
+// попытки (0, если ответа не было) и исходная причина.
+type APIError struct {
+	Kind       error
+	StatusCode int
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
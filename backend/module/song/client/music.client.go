@@ -0,0 +1,204 @@
+package song_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"root/config"
+	"root/module/song/dto"
+	"root/shared/logger"
+)
+
+// maxResponseSize ограничивает размер ответа внешнего API.
+const maxResponseSize = 1 << 20
+
+// IMusicClient - клиент внешнего API с деталями песен.
+type IMusicClient interface {
+	GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error)
+}
+
+var _ IMusicClient = (*MusicClient)(nil)
+
+type Config struct {
+	// URLTemplate - адрес запроса с двумя %s: группа и название песни.
+	URLTemplate string
+	// Timeout ограничивает одну попытку запроса.
+	Timeout time.Duration
+	// MaxRetries - число повторов после первой попытки на 5xx, 429 и сетевых ошибках.
+	MaxRetries int
+	// BaseBackoff и MaxBackoff задают экспоненциальную задержку между повторами.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BreakerThreshold неудачных попыток подряд размыкают цепь на BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// NewConfig собирает настройки клиента из конфигурации приложения.
+func NewConfig(cfg *config.Config) Config {
+	return Config{
+		URLTemplate:      cfg.ExternalApi,
+		Timeout:          cfg.ExternalApiTimeout,
+		MaxRetries:       cfg.ExternalApiRetries,
+		BaseBackoff:      cfg.ExternalApiBackoff,
+		MaxBackoff:       cfg.ExternalApiMaxBackoff,
+		BreakerThreshold: cfg.ExternalApiBreakerThreshold,
+		BreakerCooldown:  cfg.ExternalApiBreakerCooldown,
+	}
+}
+
+type MusicClient struct {
+	logger  *logger.Logger
+	config  Config
+	http    *http.Client
+	breaker *circuitBreaker
+}
+
+func NewMusicClient(logger *logger.Logger, cfg Config) *MusicClient {
+	return &MusicClient{
+		logger:  logger,
+		config:  cfg,
+		http:    &http.Client{Timeout: cfg.Timeout},
+		breaker: newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+// GetSongDetails запрашивает детали песни с повторами и circuit breaker.
+// Ошибки API возвращаются как *APIError, ошибки отмены - как ошибка ctx.
+func (c *MusicClient) GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error) {
+	endpoint := fmt.Sprintf(c.config.URLTemplate, url.QueryEscape(group), url.QueryEscape(song))
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		details, retryAfter, err := c.attempt(ctx, endpoint)
+		if err == nil {
+			return details, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !retryable(err) || attempt >= c.config.MaxRetries {
+			return nil, lastErr
+		}
+
+		delay := c.backoff(attempt, retryAfter)
+		c.logger.Warnf("GetSongDetails: attempt %d failed: %v, retrying in %v", attempt+1, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt выполняет одну попытку запроса. retryAfter - задержка из заголовка
+// Retry-After ответа 429, если она указана.
+func (c *MusicClient) attempt(ctx context.Context, endpoint string) (details *dto.SongDetails, retryAfter time.Duration, err error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, 0, &APIError{Kind: ErrUnavailable, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		c.breaker.release()
+		return nil, 0, &APIError{Kind: ErrUnavailable, Err: err}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.breaker.release()
+			return nil, 0, ctx.Err()
+		}
+		c.breaker.failure()
+		return nil, 0, &APIError{Kind: ErrUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		c.breaker.success()
+	case resp.StatusCode == http.StatusNotFound:
+		c.breaker.success()
+		return nil, 0, &APIError{Kind: ErrNotFound, StatusCode: resp.StatusCode}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// API отвечает, просто просит подождать - цепь не размыкаем
+		c.breaker.release()
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), &APIError{Kind: ErrRateLimited, StatusCode: resp.StatusCode}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		c.breaker.failure()
+		return nil, 0, &APIError{Kind: ErrUnavailable, StatusCode: resp.StatusCode}
+	default:
+		c.breaker.success()
+		return nil, 0, &APIError{Kind: ErrMalformed, StatusCode: resp.StatusCode, Err: errors.New("unexpected status")}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, 0, &APIError{Kind: ErrUnavailable, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	details = new(dto.SongDetails)
+	if err := json.Unmarshal(body, details); err != nil {
+		return nil, 0, &APIError{Kind: ErrMalformed, StatusCode: resp.StatusCode, Err: err}
+	}
+	if err := details.Validate(); err != nil {
+		return nil, 0, &APIError{Kind: ErrMalformed, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return details, 0, nil
+}
+
+// backoff возвращает задержку перед повтором attempt: случайную величину от нуля
+// до BaseBackoff*2^attempt (full jitter), но не больше MaxBackoff. Retry-After
+// от API имеет приоритет и тоже ограничен MaxBackoff.
+func (c *MusicClient) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, c.config.MaxBackoff)
+	}
+
+	ceiling := c.config.MaxBackoff
+	if attempt < 30 {
+		ceiling = min(c.config.BaseBackoff<<attempt, c.config.MaxBackoff)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryable сообщает, имеет ли смысл повторить запрос. Разомкнутую цепь не
+// повторяем: до конца cooldown результат не изменится.
+func retryable(err error) bool {
+	if errors.Is(err, errCircuitOpen) {
+		return false
+	}
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrRateLimited)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,268 @@
+package song_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"root/shared/logger"
+)
+
+const validDetails = `{"releaseDate":"16.07.2006","text":"Ooh baby, don't you know I suffer?","link":"https://www.youtube.com/watch?v=Xsp3_a-PMTw"}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, tune func(*Config)) (*MusicClient, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := Config{
+		URLTemplate:      server.URL + "/info?group=%s&song=%s",
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		BreakerThreshold: 100,
+		BreakerCooldown:  time.Minute,
+	}
+	if tune != nil {
+		tune(&cfg)
+	}
+	return NewMusicClient(logger.GetLogger(), cfg), &calls
+}
+
+func TestGetSongDetailsSuccess(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("group"); got != "Muse & Co" {
+			t.Errorf("group = %q, want escaped %q", got, "Muse & Co")
+		}
+		if got := r.URL.Query().Get("song"); got != "Supermassive Black Hole" {
+			t.Errorf("song = %q", got)
+		}
+		fmt.Fprint(w, validDetails)
+	}, nil)
+
+	details, err := client.GetSongDetails(context.Background(), "Muse & Co", "Supermassive Black Hole")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if details.ReleaseDate != "16.07.2006" || details.Link == "" || details.Text == "" {
+		t.Errorf("unexpected details: %+v", details)
+	}
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestGetSongDetailsNotFoundIsNotRetried(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}, nil)
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Unknown")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("err = %#v, want *APIError with status 404", err)
+	}
+	if *calls != 1 {
+		t.Errorf("calls = %d, want 1", *calls)
+	}
+}
+
+func TestGetSongDetailsRetriesServerErrors(t *testing.T) {
+	var attempts int32
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, validDetails)
+	}, nil)
+
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+}
+
+func TestGetSongDetailsUnavailableAfterRetries(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, nil)
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 1 attempt + 2 retries", *calls)
+	}
+}
+
+func TestGetSongDetailsRateLimited(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, nil)
+
+	start := time.Now()
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+	// Retry-After в 1 секунду ограничен MaxBackoff
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retries took %v, Retry-After must be capped by MaxBackoff", elapsed)
+	}
+}
+
+func TestGetSongDetailsMalformed(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":   `{"releaseDate":`,
+		"bad date":       `{"releaseDate":"2006-07-16","text":"la","link":"https://example.com"}`,
+		"missing text":   `{"releaseDate":"16.07.2006","link":"https://example.com"}`,
+		"relative link":  `{"releaseDate":"16.07.2006","text":"la","link":"/watch"}`,
+		"unexpected 400": "",
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if body == "" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				fmt.Fprint(w, body)
+			}, nil)
+
+			_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+			if !errors.Is(err, ErrMalformed) {
+				t.Fatalf("err = %v, want ErrMalformed", err)
+			}
+			if *calls != 1 {
+				t.Errorf("calls = %d, malformed responses must not be retried", *calls)
+			}
+		})
+	}
+}
+
+func TestGetSongDetailsTimeout(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}, func(cfg *Config) {
+		cfg.Timeout = 20 * time.Millisecond
+		cfg.MaxRetries = 0
+	})
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable on timeout", err)
+	}
+}
+
+func TestGetSongDetailsContextCancellation(t *testing.T) {
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}, func(cfg *Config) {
+		cfg.MaxRetries = 10
+		cfg.BaseBackoff = time.Second
+		cfg.MaxBackoff = time.Second
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetSongDetails(ctx, "Muse", "Uprising")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if *calls > 2 {
+		t.Errorf("calls = %d, backoff must stop on context cancellation", *calls)
+	}
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	var healthy atomic.Bool
+	client, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, validDetails)
+	}, func(cfg *Config) {
+		cfg.MaxRetries = 0
+		cfg.BreakerThreshold = 2
+	})
+
+	now := time.Now()
+	client.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("attempt %d: err = %v, want ErrUnavailable", i, err)
+		}
+	}
+
+	_, err := client.GetSongDetails(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("err = %v, want open circuit", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("calls = %d, open circuit must not reach the API", *calls)
+	}
+
+	healthy.Store(true)
+	now = now.Add(2 * time.Minute)
+
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("probe after cooldown failed: %v", err)
+	}
+	if _, err := client.GetSongDetails(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("closed circuit failed: %v", err)
+	}
+	if *calls != 4 {
+		t.Errorf("calls = %d, want 4", *calls)
+	}
+}
+
+func TestBackoffIsJitteredAndCapped(t *testing.T) {
+	client := NewMusicClient(logger.GetLogger(), Config{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	})
+
+	for attempt := 0; attempt < 40; attempt++ {
+		delay := client.backoff(attempt, 0)
+		ceiling := time.Second
+		if attempt < 4 {
+			ceiling = 100 * time.Millisecond << attempt
+		}
+		if delay < 0 || delay > ceiling {
+			t.Errorf("attempt %d: delay %v outside [0, %v]", attempt, delay, ceiling)
+		}
+	}
+
+	if delay := client.backoff(0, 10*time.Second); delay != time.Second {
+		t.Errorf("Retry-After delay = %v, want capped at %v", delay, time.Second)
+	}
+}
@@ -1,19 +1,45 @@
 package song_controller
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	job_dto "root/module/job/dto"
+	dto "root/module/song/dto"
+	song_lyrics "root/module/song/lyrics"
 	song_service "root/module/song/service"
 	"root/shared/logger"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// actorHeader - заголовок с автором изменения для истории песен.
+const actorHeader = "X-Actor"
+
 type ISongController interface {
 	GetSongs(c *fiber.Ctx) error
+	SearchSongs(c *fiber.Ctx) error
 	GetSongText(c *fiber.Ctx) error
 	DeleteSong(c *fiber.Ctx) error
 	UpdateSong(c *fiber.Ctx) error
 	AddSong(c *fiber.Ctx) error
+	InvalidateSongDetails(c *fiber.Ctx) error
+	GetNearDuplicates(c *fiber.Ctx) error
+	GetShardStats(c *fiber.Ctx) error
+	GetTrash(c *fiber.Ctx) error
+	RestoreSong(c *fiber.Ctx) error
+	GetSongHistory(c *fiber.Ctx) error
+	RevertSong(c *fiber.Ctx) error
+	ImportSongs(c *fiber.Ctx) error
+	ExportSongs(c *fiber.Ctx) error
+	GetSyncedLyrics(c *fiber.Ctx) error
+	SaveSyncedLyrics(c *fiber.Ctx) error
+	DeleteSyncedLyrics(c *fiber.Ctx) error
 }
 
 type SongController struct {
@@ -36,23 +62,30 @@ func NewSongController(logger *logger.Logger, songService song_service.ISongServ
 
 // GetSongs возвращает список песен с возможностью фильтрации и пагинации
 // @Summary Получение списка песен
-// @Description Возвращает список песен с поддержкой фильтрации и пагинации. Фильтрация доступна по группе, названию песни, дате релиза, тексту песни и ссылке. Пагинация позволяет указать смещение и количество записей на странице.
+// @Description Возвращает список песен с поддержкой фильтрации, сортировки и пагинации. Текстовые поля group, song, text и link фильтруются по подстроке без учёта регистра; суффикс _exact задаёт точное совпадение, _prefix - совпадение по началу строки (например, song_prefix=Sup). Неизвестные параметры фильтрации и сортировки отклоняются. Для перехода на следующую страницу используйте курсор next_cursor из ответа; offset поддерживается для небольших смещений (не больше 1000).
 // @Tags Песни
 // @Accept json
 // @Produce json
-// @Param offset query int false "Смещение для пагинации. Указывает, сколько записей пропустить перед началом выборки. По умолчанию 0." default(0) minimum(0)
+// @Param offset query int false "Смещение для пагинации. Указывает, сколько записей пропустить перед началом выборки. Нельзя использовать вместе с cursor. По умолчанию 0." default(0) minimum(0) maximum(1000)
 // @Param limit query int false "Количество записей на странице. Определяет, сколько записей вернуть в ответе. По умолчанию 10." default(10) minimum(1) maximum(100)
-// @Param group query string false "Фильтр по названию группы. Возвращает песни, где название группы содержит указанную строку."
-// @Param song query string false "Фильтр по названию песни. Возвращает песни, где название песни содержит указанную строку."
-// @Param release_date query string false "Фильтр по дате релиза. Возвращает песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD."
-// @Param text query string false "Фильтр по тексту песни. Возвращает песни, где текст песни содержит указанную строку."
-// @Param link query string false "Фильтр по ссылке. Возвращает песни, где ссылка содержит указанную строку."
-// @Success 200 {array} map[string]interface{} "Список песен. Каждая песня представлена в виде объекта с полями: id, group_id, group, song, release_date, text, link."
-// @Failure 400 {object} map[string]interface{} "Неверный запрос. Возможные причины:
-// - Некорректный формат параметра offset (должен быть целым числом >= 0).
+// @Param cursor query string false "Непрозрачный курсор следующей страницы из поля next_cursor предыдущего ответа. Действителен только с той же сортировкой."
+// @Param group query string false "Фильтр по названию группы (подстрока). Также доступны group_exact и group_prefix."
+// @Param song query string false "Фильтр по названию песни (подстрока). Также доступны song_exact и song_prefix."
+// @Param text query string false "Фильтр по тексту песни (подстрока). Также доступны text_exact и text_prefix."
+// @Param link query string false "Фильтр по ссылке (подстрока). Также доступны link_exact и link_prefix."
+// @Param group_id query int false "Фильтр по ID группы."
+// @Param release_date query string false "Песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD."
+// @Param release_date_from query string false "Песни, выпущенные не раньше указанной даты. Формат даты: YYYY-MM-DD."
+// @Param release_date_to query string false "Песни, выпущенные не позже указанной даты. Формат даты: YYYY-MM-DD."
+// @Param sort query string false "Сортировка в формате поле:asc|desc. Поля: id, group, song, release_date. По умолчанию id:asc." default(id:asc)
+// @Success 200 {object} Response{data=dto.SongPage} "Страница песен и курсор следующей страницы (next_cursor отсутствует на последней странице)."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный формат параметра offset (должен быть целым числом >= 0 и <= 1000).
 // - Некорректный формат параметра limit (должен быть целым числом >= 1 и <= 100).
-// - Некорректный формат даты release_date (должен быть в формате YYYY-MM-DD)."
-// @Failure 500 {object} map[string]interface{} "Ошибка сервера. Возможные причины:
+// - Некорректный курсор, курсор другой сортировки или курсор вместе с offset.
+// - Неизвестный параметр фильтрации или поле сортировки.
+// - Некорректный формат даты (должен быть в формате YYYY-MM-DD)."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
 // - Внутренняя ошибка базы данных.
 // - Проблемы с подключением к базе данных."
 // @Router /api/song [get]
@@ -61,7 +94,7 @@ func (sc *SongController) GetSongs(c *fiber.Ctx) error {
 	defer sc.logger.Info("GetSongs: completed")
 
 	offset, err := strconv.Atoi(c.Query("offset", "0"))
-	if err != nil {
+	if err != nil || offset < 0 {
 		sc.logger.Warn("GetSongs: invalid offset")
 		return c.Status(fiber.StatusBadRequest).JSON(Response{
 			Success: false,
@@ -70,7 +103,7 @@ func (sc *SongController) GetSongs(c *fiber.Ctx) error {
 	}
 
 	limit, err := strconv.Atoi(c.Query("limit", "10"))
-	if err != nil {
+	if err != nil || limit < 1 || limit > 100 {
 		sc.logger.Warn("GetSongs: invalid limit")
 		return c.Status(fiber.StatusBadRequest).JSON(Response{
 			Success: false,
@@ -78,32 +111,32 @@ func (sc *SongController) GetSongs(c *fiber.Ctx) error {
 		})
 	}
 
-	filters := map[string]string{}
-	if group := c.Query("group"); group != "" {
-		filters["Group"] = group
-	}
-	if song := c.Query("song"); song != "" {
-		filters["Song"] = song
-	}
-	if releaseDate := c.Query("release_date"); releaseDate != "" {
-		filters["ReleaseDate"] = releaseDate
-	}
-	if text := c.Query("text"); text != "" {
-		filters["Text"] = text
+	params := c.Queries()
+	delete(params, "offset")
+	delete(params, "limit")
+	delete(params, "cursor")
+
+	filter, err := dto.ParseSongFilter(params)
+	if err != nil {
+		sc.logger.Warnf("GetSongs: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: err.Error(),
+		})
 	}
-	if link := c.Query("link"); link != "" {
-		filters["Link"] = link
+
+	page := dto.PageRequest{
+		Offset: offset,
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
 	}
 
-	sc.logger.Infof("GetSongs: offset=%d, limit=%d, filters=%v", offset, limit, filters)
+	sc.logger.Infof("GetSongs: offset=%d, limit=%d, cursor=%q, filters=%v", offset, limit, page.Cursor, params)
 
-	result, err := sc.songService.GetSongs(filters, offset, limit)
+	result, err := sc.songService.GetSongs(c.UserContext(), filter, page)
 	if err != nil {
 		sc.logger.Errorf("GetSongs: failed to fetch songs: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(Response{
-			Success: false,
-			Message: "Failed to fetch songs",
-		})
+		return errorResponse(c, err, "Failed to fetch songs")
 	}
 
 	return c.JSON(Response{
@@ -113,22 +146,87 @@ func (sc *SongController) GetSongs(c *fiber.Ctx) error {
 	})
 }
 
+// SearchSongs выполняет полнотекстовый поиск по текстам песен
+// @Summary Полнотекстовый поиск по текстам песен
+// @Description Ищет песни по названию и тексту во всех шардах с учётом морфологии русского и английского языков. Результаты упорядочены по релевантности (ts_rank), для каждого совпадения возвращается фрагмент текста, где найденные слова обёрнуты в <b></b>. Запрос поддерживает синтаксис websearch: фразы в кавычках, OR и исключение через минус.
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос."
+// @Param offset query int false "Смещение для пагинации. По умолчанию 0." default(0) minimum(0) maximum(1000)
+// @Param limit query int false "Количество результатов на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Success 200 {object} Response{data=[]dto.SongSearchHit} "Найденные песни с релевантностью и подсвеченным фрагментом текста."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Пустой поисковый запрос q.
+// - Некорректный формат параметров offset или limit."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/search [get]
+func (sc *SongController) SearchSongs(c *fiber.Ctx) error {
+	sc.logger.Info("SearchSongs: started")
+	defer sc.logger.Info("SearchSongs: completed")
+
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		sc.logger.Warn("SearchSongs: missing query")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Search query is required",
+		})
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		sc.logger.Warn("SearchSongs: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		sc.logger.Warn("SearchSongs: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid limit number",
+		})
+	}
+
+	sc.logger.Infof("SearchSongs: q=%q, offset=%d, limit=%d", query, offset, limit)
+
+	hits, err := sc.songService.SearchSongs(c.UserContext(), query, offset, limit)
+	if err != nil {
+		sc.logger.Errorf("SearchSongs: failed to search songs: %v", err)
+		return errorResponse(c, err, "Failed to search songs")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Songs found successfully",
+		Data:    hits,
+	})
+}
+
 // GetSongText возвращает текст песни с пагинацией
 // @Summary Получение текста песни
-// @Description Возвращает текст песни с поддержкой пагинации. Текст разбит на секции (куплеты, припевы), и можно указать страницу и количество строк на странице.
+// @Description Возвращает страницу текста песни. Текст разбит на секции по разметке, которая определяется автоматически: русские маркеры (Куплет 1, Припев), английские метки в квадратных скобках ([Verse 1], [Chorus], [Bridge]) или строфы, разделённые пустыми строками. У каждой секции есть тип (verse, chorus, bridge и т.д.), номер, исходная метка и текст. Ответ содержит название песни, группу, общее число секций, номер и размер страницы и признак has_next. Заголовок ETag содержит версию песни для условных запросов PATCH, PUT и DELETE с If-Match.
 // @Tags Песни
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни. Уникальный идентификатор песни в системе."
 // @Param offset query int false "Страница текста. Указывает, какую страницу текста вернуть. По умолчанию 1." default(1) minimum(1)
-// @Param limit query int false "Количество строк текста на странице. Определяет, сколько строк текста вернуть. По умолчанию 1." default(1) minimum(1) maximum(10)
-// @Success 200 {object} Response "Успешный ответ. Возвращает текст песни, разбитый на секции."
+// @Param limit query int false "Количество секций текста на странице. По умолчанию 1." default(1) minimum(1) maximum(10)
+// @Success 200 {object} Response{data=dto.SongLyricsPage} "Успешный ответ. Возвращает страницу секций текста песни."
+// @Header 200 {string} ETag "Версия песни."
 // @Failure 400 {object} Response "Неверный запрос. Возможные причины:
 // - Отсутствует ID песни.
 // - Некорректный формат параметра offset (должен быть целым числом >= 1).
 // - Некорректный формат параметра limit (должен быть целым числом >= 1 и <= 10)."
 // @Failure 404 {object} Response "Песня не найдена. Возможные причины:
-// - Песня с указанным ID не существует."
+// - Песня с указанным ID не существует.
+// - Страница offset за концом текста песни."
 // @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
 // - Внутренняя ошибка базы данных.
 // - Проблемы с подключением к базе данных."
@@ -156,7 +254,7 @@ func (sc *SongController) GetSongText(c *fiber.Ctx) error {
 	}
 
 	limit, err := strconv.Atoi(c.Query("limit", "1"))
-	if err != nil || limit < 1 {
+	if err != nil || limit < 1 || limit > 10 {
 		sc.logger.Warn("GetSongText: invalid limit")
 		return c.Status(fiber.StatusBadRequest).JSON(Response{
 			Success: false,
@@ -166,34 +264,36 @@ func (sc *SongController) GetSongText(c *fiber.Ctx) error {
 
 	sc.logger.Infof("GetSongText: songID=%s, offset=%d, limit=%d", songID, offset, limit)
 
-	sections, err := sc.songService.GetSongText(songID, offset, limit)
+	lyrics, err := sc.songService.GetSongText(c.UserContext(), songID, offset, limit)
 	if err != nil {
 		sc.logger.Errorf("GetSongText: failed to fetch song text: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(Response{
-			Success: false,
-			Message: "Failed to fetch song text",
-		})
+		return errorResponse(c, err, "Failed to fetch song text")
 	}
 
+	c.Set(fiber.HeaderETag, dto.ETag(lyrics.Version))
+
 	return c.JSON(Response{
 		Success: true,
 		Message: "Song text fetched successfully",
-		Data:    sections,
+		Data:    lyrics,
 	})
 }
 
 // DeleteSong удаляет песню по её ID
 // @Summary Удаление песни
-// @Description Удаляет песню по её уникальному идентификатору (ID). После удаления песня больше не будет доступна в системе.
+// @Description Переносит песню в корзину по её уникальному идентификатору (ID). Песня пропадает из списков, поиска и текстов, но её можно восстановить через POST /api/song/{id}/restore, пока она не удалена окончательно по истечении срока хранения корзины. С заголовком If-Match песня удаляется, только если её версия совпадает с ETag.
 // @Tags Песни
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни. Уникальный идентификатор песни в системе."
+// @Param If-Match header string false "ETag песни из ответа GET /api/song/{id}."
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
 // @Success 200 {object} Response "Успешный ответ. Возвращает сообщение об успешном удалении."
 // @Failure 400 {object} Response "Неверный запрос. Возможные причины:
 // - Отсутствует ID песни."
 // @Failure 404 {object} Response "Песня не найдена. Возможные причины:
 // - Песня с указанным ID не существует."
+// @Failure 412 {object} Response "Версия песни не совпадает с If-Match: песню изменили после получения ETag."
 // @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
 // - Внутренняя ошибка базы данных.
 // - Проблемы с подключением к базе данных."
@@ -211,12 +311,9 @@ func (sc *SongController) DeleteSong(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := sc.songService.DeleteSong(songID); err != nil {
+	if err := sc.songService.DeleteSong(c.UserContext(), songID, dto.ParseIfMatch(c.Get(fiber.HeaderIfMatch)), requestActor(c)); err != nil {
 		sc.logger.Errorf("DeleteSong: failed to delete song: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(Response{
-			Success: false,
-			Message: "Failed to delete song",
-		})
+		return errorResponse(c, err, "Failed to delete song")
 	}
 
 	return c.JSON(Response{
@@ -225,23 +322,31 @@ func (sc *SongController) DeleteSong(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateSong обновляет данные песни
+// UpdateSong частично обновляет данные песни
 // @Summary Обновление данных песни
-// @Description Обновляет данные песни по её уникальному идентификатору (ID). Можно обновить одно или несколько полей песни.
+// @Description Частично обновляет песню по её уникальному идентификатору (ID): изменяются только переданные поля. Допустимые поля: group_id, song, text, link, release_date. Поле id неизменяемо, название группы group следует за group_id (переименование группы - через PUT /api/group/{id}); неизвестные поля и null отклоняются. При смене group_id песня переносится в шард новой группы. PUT принимается как синоним PATCH. С заголовком If-Match изменение применяется, только если версия песни совпадает с ETag; каждое изменение увеличивает версию.
 // @Tags Песни
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни. Уникальный идентификатор песни в системе."
-// @Param data body map[string]interface{} true "Данные для обновления. Должен быть объектом JSON, содержащим поля для обновления (например, group, song, release_date, text, link)."
-// @Success 200 {object} Response "Успешный ответ. Возвращает сообщение об успешном обновлении."
+// @Param If-Match header string false "ETag песни из ответа GET /api/song/{id}."
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
+// @Param data body dto.SongPatch true "Изменяемые поля песни. Дата релиза в формате YYYY-MM-DD, ссылка - абсолютный http(s) URL."
+// @Success 200 {object} Response{data=dto.Song} "Успешный ответ. Возвращает обновлённую песню."
+// @Header 200 {string} ETag "Новая версия песни."
 // @Failure 400 {object} Response "Неверный запрос. Возможные причины:
-// - Отсутствует ID песни.
-// - Некорректный формат данных в теле запроса."
+// - Некорректный ID песни.
+// - Тело запроса не является JSON-объектом или не содержит полей.
+// - Неизвестное, неизменяемое или некорректное поле.
+// - Группа group_id не существует."
 // @Failure 404 {object} Response "Песня не найдена. Возможные причины:
 // - Песня с указанным ID не существует."
+// @Failure 409 {object} Response "В группе уже есть песня с таким названием."
+// @Failure 412 {object} Response "Версия песни не совпадает с If-Match: песню изменили после получения ETag."
 // @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
 // - Внутренняя ошибка базы данных.
 // - Проблемы с подключением к базе данных."
+// @Router /api/song/{id} [patch]
 // @Router /api/song/{id} [put]
 func (sc *SongController) UpdateSong(c *fiber.Ctx) error {
 	sc.logger.Info("UpdateSong: started")
@@ -256,37 +361,40 @@ func (sc *SongController) UpdateSong(c *fiber.Ctx) error {
 		})
 	}
 
-	var data map[string]interface{}
-	if err := c.BodyParser(&data); err != nil {
-		sc.logger.Errorf("UpdateSong: failed to parse request body: %v", err)
+	patch, err := dto.ParseSongPatch(c.Body())
+	if err != nil {
+		sc.logger.Warnf("UpdateSong: %v", err)
 		return c.Status(fiber.StatusBadRequest).JSON(Response{
 			Success: false,
-			Message: "Invalid input",
+			Message: err.Error(),
 		})
 	}
 
-	if err := sc.songService.UpdateSong(songID, data); err != nil {
+	song, err := sc.songService.UpdateSong(c.UserContext(), songID, patch, dto.ParseIfMatch(c.Get(fiber.HeaderIfMatch)), requestActor(c))
+	if err != nil {
 		sc.logger.Errorf("UpdateSong: failed to update song: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(Response{
-			Success: false,
-			Message: "Failed to update song",
-		})
+		return errorResponse(c, err, "Failed to update song")
 	}
 
+	c.Set(fiber.HeaderETag, dto.ETag(song.Version))
+
 	return c.JSON(Response{
 		Success: true,
 		Message: "Song updated successfully",
+		Data:    song,
 	})
 }
 
-// AddSong добавляет новую песню
+// AddSong ставит в очередь добавление новой песни
 // @Summary Добавление новой песни
-// @Description Добавляет новую песню в систему. Для добавления необходимо указать название группы и название песни.
+// @Description Ставит в очередь задачу на добавление песни и сразу возвращает её ID. Для добавления необходимо указать название группы и название песни. Дата релиза, текст и ссылка запрашиваются во внешнем API в фоне; результат можно узнать через GET /api/jobs/{id} (адрес также возвращается в заголовке Location). Если в группе уже есть песня с таким названием (без учёта регистра и крайних пробелов), задача не ставится: ответ 200 содержит существующую песню, её адрес в Content-Location и версию в ETag.
 // @Tags Песни
 // @Accept json
 // @Produce json
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
 // @Param data body map[string]string true "Данные для добавления песни. Должен быть объектом JSON, содержащим поля group и song."
-// @Success 201 {object} Response "Успешный ответ. Возвращает сообщение об успешном добавлении."
+// @Success 200 {object} Response{data=dto.Song} "Песня уже существует. Возвращает существующую песню."
+// @Success 202 {object} Response{data=job_dto.Job} "Задача принята. Возвращает задачу в статусе pending."
 // @Failure 400 {object} Response "Неверный запрос. Возможные причины:
 // - Отсутствует название группы или песни.
 // - Некорректный формат данных в теле запроса."
@@ -307,8 +415,8 @@ func (sc *SongController) AddSong(c *fiber.Ctx) error {
 		})
 	}
 
-	group := songData["group"]
-	song := songData["song"]
+	group := strings.TrimSpace(songData["group"])
+	song := strings.TrimSpace(songData["song"])
 	if group == "" || song == "" {
 		sc.logger.Warn("AddSong: group or song name is missing")
 		return c.Status(fiber.StatusBadRequest).JSON(Response{
@@ -317,16 +425,688 @@ func (sc *SongController) AddSong(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := sc.songService.AddSong(group, song); err != nil {
-		sc.logger.Errorf("AddSong: failed to add song: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(Response{
+	job, existing, err := sc.songService.AddSong(c.UserContext(), group, song, requestActor(c))
+	if err != nil {
+		sc.logger.Errorf("AddSong: failed to enqueue song: %v", err)
+		return errorResponse(c, err, "Failed to add song")
+	}
+
+	if existing != nil {
+		c.Set(fiber.HeaderContentLocation, "/api/song/"+existing.ID.String())
+		c.Set(fiber.HeaderETag, dto.ETag(existing.Version))
+		return c.JSON(Response{
+			Success: true,
+			Message: "Song already exists",
+			Data:    existing,
+		})
+	}
+
+	c.Location(jobLocation(job))
+	return c.Status(fiber.StatusAccepted).JSON(Response{
+		Success: true,
+		Message: "Song enrichment job accepted",
+		Data:    job,
+	})
+}
+
+// InvalidateSongDetails сбрасывает кэш ответа внешнего API
+// @Summary Сброс кэша деталей песни
+// @Description Удаляет закэшированный ответ внешнего API (включая закэшированный ответ 404) для указанной группы и песни из постоянного хранилища и из памяти всех экземпляров сервера. Сравнение выполняется без учёта регистра и крайних пробелов.
+// @Tags Администрирование
+// @Accept json
+// @Produce json
+// @Param group query string true "Название группы."
+// @Param song query string true "Название песни."
+// @Success 200 {object} Response "Кэш сброшен."
+// @Failure 400 {object} Response "Не указано название группы или песни."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/admin/cache/song-details [delete]
+func (sc *SongController) InvalidateSongDetails(c *fiber.Ctx) error {
+	sc.logger.Info("InvalidateSongDetails: started")
+	defer sc.logger.Info("InvalidateSongDetails: completed")
+
+	group := strings.TrimSpace(c.Query("group"))
+	song := strings.TrimSpace(c.Query("song"))
+	if group == "" || song == "" {
+		sc.logger.Warn("InvalidateSongDetails: group or song name is missing")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Group and song name are required",
+		})
+	}
+
+	if err := sc.songService.InvalidateSongDetails(c.UserContext(), group, song); err != nil {
+		sc.logger.Errorf("InvalidateSongDetails: failed to invalidate cache: %v", err)
+		return errorResponse(c, err, "Failed to invalidate song details cache")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Song details cache invalidated successfully",
+	})
+}
+
+// GetNearDuplicates ищет песни с похожими названиями
+// @Summary Поиск возможных дубликатов песен
+// @Description Возвращает пары песен одной группы, названия которых похожи по триграммам pg_trgm не меньше порога threshold, начиная с самых похожих. Точные дубликаты (без учёта регистра и крайних пробелов) не допускаются уникальным ключом, поэтому здесь находятся опечатки и варианты написания. Песни в корзине не учитываются.
+// @Tags Администрирование
+// @Produce json
+// @Param threshold query number false "Минимальное сходство названий от 0 до 1. По умолчанию 0.6." default(0.6)
+// @Param group_id query int false "Искать только в указанной группе."
+// @Param offset query int false "Смещение для пагинации. По умолчанию 0." default(0) minimum(0) maximum(1000)
+// @Param limit query int false "Количество пар на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Success 200 {object} Response{data=[]dto.DuplicateCandidate} "Пары похожих песен со степенью сходства."
+// @Failure 400 {object} Response "Некорректный порог, ID группы, offset или limit."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/admin/songs/duplicates [get]
+func (sc *SongController) GetNearDuplicates(c *fiber.Ctx) error {
+	sc.logger.Info("GetNearDuplicates: started")
+	defer sc.logger.Info("GetNearDuplicates: completed")
+
+	threshold, err := strconv.ParseFloat(c.Query("threshold", "0.6"), 64)
+	if err != nil || !(threshold > 0 && threshold <= 1) {
+		sc.logger.Warn("GetNearDuplicates: invalid threshold")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Threshold must be a number in (0, 1]",
+		})
+	}
+
+	var groupID *int
+	if value := c.Query("group_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			sc.logger.Warn("GetNearDuplicates: invalid group_id")
+			return c.Status(fiber.StatusBadRequest).JSON(Response{
+				Success: false,
+				Message: "Invalid group ID",
+			})
+		}
+		groupID = &id
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		sc.logger.Warn("GetNearDuplicates: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		sc.logger.Warn("GetNearDuplicates: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid limit number",
+		})
+	}
+
+	pairs, err := sc.songService.FindNearDuplicates(c.UserContext(), groupID, threshold, offset, limit)
+	if err != nil {
+		sc.logger.Errorf("GetNearDuplicates: failed to find duplicates: %v", err)
+		return errorResponse(c, err, "Failed to find duplicate songs")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Duplicate candidates fetched successfully",
+		Data:    pairs,
+	})
+}
+
+// GetShardStats возвращает статистику шардов песен
+// @Summary Статистика и перекос шардов
+// @Description Для каждой таблицы songs_N возвращает количество песен (всего, вне корзины и в корзине), долю от всех песен, размер таблицы и индексов в байтах, самые большие группы и время последнего изменения песен по истории. Skew - отношение самого большого шарда к среднему (1 - равномерно). Шарды, доля которых больше warn_share, перечисляются в warnings.
+// @Tags Администрирование
+// @Produce json
+// @Param top query int false "Количество самых больших групп на шард. По умолчанию 5." default(5) minimum(1) maximum(100)
+// @Param warn_share query number false "Доля песен в шарде от 0 до 1, выше которой выдаётся предупреждение. По умолчанию SHARD_SKEW_WARN_SHARE."
+// @Success 200 {object} Response{data=dto.ShardReport} "Статистика шардов."
+// @Failure 400 {object} Response "Некорректный top или warn_share."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/admin/songs/shards [get]
+func (sc *SongController) GetShardStats(c *fiber.Ctx) error {
+	sc.logger.Info("GetShardStats: started")
+	defer sc.logger.Info("GetShardStats: completed")
+
+	top, err := strconv.Atoi(c.Query("top", "5"))
+	if err != nil || top < 1 || top > 100 {
+		sc.logger.Warn("GetShardStats: invalid top")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Top must be a number in [1, 100]",
+		})
+	}
+
+	var warnShare float64
+	if value := c.Query("warn_share"); value != "" {
+		warnShare, err = strconv.ParseFloat(value, 64)
+		if err != nil || !(warnShare > 0 && warnShare <= 1) {
+			sc.logger.Warn("GetShardStats: invalid warn_share")
+			return c.Status(fiber.StatusBadRequest).JSON(Response{
+				Success: false,
+				Message: "Warn share must be a number in (0, 1]",
+			})
+		}
+	}
+
+	report, err := sc.songService.GetShardStats(c.UserContext(), top, warnShare)
+	if err != nil {
+		sc.logger.Errorf("GetShardStats: failed to collect shard stats: %v", err)
+		return errorResponse(c, err, "Failed to collect shard stats")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Shard stats collected successfully",
+		Data:    report,
+	})
+}
+
+// GetTrash возвращает песни из корзины
+// @Summary Корзина удалённых песен
+// @Description Возвращает удалённые песни всех шардов, начиная с удалённых последними. Песни хранятся в корзине в течение SONG_TRASH_RETENTION, затем удаляются окончательно.
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Param offset query int false "Смещение для пагинации. По умолчанию 0." default(0) minimum(0) maximum(1000)
+// @Param limit query int false "Количество записей на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Success 200 {object} Response{data=[]dto.Song} "Удалённые песни с датой удаления deleted_at."
+// @Failure 400 {object} Response "Некорректный формат параметров offset или limit."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/trash [get]
+func (sc *SongController) GetTrash(c *fiber.Ctx) error {
+	sc.logger.Info("GetTrash: started")
+	defer sc.logger.Info("GetTrash: completed")
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		sc.logger.Warn("GetTrash: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		sc.logger.Warn("GetTrash: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid limit number",
+		})
+	}
+
+	songs, err := sc.songService.GetTrash(c.UserContext(), offset, limit)
+	if err != nil {
+		sc.logger.Errorf("GetTrash: failed to fetch trash: %v", err)
+		return errorResponse(c, err, "Failed to fetch trash")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Trash fetched successfully",
+		Data:    songs,
+	})
+}
+
+// RestoreSong восстанавливает песню из корзины
+// @Summary Восстановление песни из корзины
+// @Description Возвращает удалённую песню из корзины. Версия песни увеличивается, новый ETag возвращается в заголовке.
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни."
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
+// @Success 200 {object} Response{data=dto.Song} "Восстановленная песня."
+// @Header 200 {string} ETag "Новая версия песни."
+// @Failure 400 {object} Response "Некорректный ID песни."
+// @Failure 404 {object} Response "Песня с указанным ID не найдена или уже удалена окончательно."
+// @Failure 409 {object} Response "Песня не находится в корзине, в группе уже есть песня с таким названием или название удалённой группы песни заняла другая группа."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/restore [post]
+func (sc *SongController) RestoreSong(c *fiber.Ctx) error {
+	sc.logger.Info("RestoreSong: started")
+	defer sc.logger.Info("RestoreSong: completed")
+
+	song, err := sc.songService.RestoreSong(c.UserContext(), c.Params("id"), requestActor(c))
+	if err != nil {
+		sc.logger.Errorf("RestoreSong: failed to restore song: %v", err)
+		return errorResponse(c, err, "Failed to restore song")
+	}
+
+	c.Set(fiber.HeaderETag, dto.ETag(song.Version))
+	return c.JSON(Response{
+		Success: true,
+		Message: "Song restored successfully",
+		Data:    song,
+	})
+}
+
+// GetSongHistory возвращает историю изменений песни
+// @Summary История изменений песни
+// @Description Возвращает ревизии песни, начиная с последней. Каждая ревизия содержит полный снимок песни после изменения, изменённые поля (diff), действие, автора и время. Номер ревизии совпадает с версией песни (ETag); переименование группы увеличивает версию без ревизии, поэтому в номерах возможны пропуски.
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни."
+// @Param offset query int false "Смещение для пагинации. По умолчанию 0." default(0) minimum(0)
+// @Param limit query int false "Количество ревизий на странице. По умолчанию 10." default(10) minimum(1) maximum(100)
+// @Success 200 {object} Response{data=[]dto.SongRevision} "Ревизии песни."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный ID песни.
+// - Некорректный формат параметров offset или limit."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/history [get]
+func (sc *SongController) GetSongHistory(c *fiber.Ctx) error {
+	sc.logger.Info("GetSongHistory: started")
+	defer sc.logger.Info("GetSongHistory: completed")
+
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		sc.logger.Warn("GetSongHistory: invalid offset")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid offset number",
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		sc.logger.Warn("GetSongHistory: invalid limit")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
 			Success: false,
-			Message: "Failed to add song",
+			Message: "Invalid limit number",
 		})
 	}
 
+	revisions, err := sc.songService.GetSongHistory(c.UserContext(), c.Params("id"), offset, limit)
+	if err != nil {
+		sc.logger.Errorf("GetSongHistory: failed to fetch history: %v", err)
+		return errorResponse(c, err, "Failed to fetch song history")
+	}
+
 	return c.JSON(Response{
 		Success: true,
-		Message: "Song added successfully",
+		Message: "Song history fetched successfully",
+		Data:    revisions,
+	})
+}
+
+// RevertSong возвращает песню к состоянию ревизии
+// @Summary Откат песни к ревизии
+// @Description Возвращает группу, название, текст, ссылку и дату релиза песни к снимку указанной ревизии. Откат выполняется как обычное изменение: с проверкой If-Match, переносом в шард группы и новой ревизией с действием revert.
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни."
+// @Param revision path int true "Номер ревизии из истории песни."
+// @Param If-Match header string false "ETag песни из ответа GET /api/song/{id}."
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
+// @Success 200 {object} Response{data=dto.Song} "Песня после отката."
+// @Header 200 {string} ETag "Новая версия песни."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Некорректный ID песни или номер ревизии.
+// - Группа из ревизии больше не существует."
+// @Failure 404 {object} Response "Песня или ревизия не найдены."
+// @Failure 409 {object} Response "В группе уже есть песня с названием из ревизии."
+// @Failure 412 {object} Response "Версия песни не совпадает с If-Match."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/revert/{revision} [post]
+func (sc *SongController) RevertSong(c *fiber.Ctx) error {
+	sc.logger.Info("RevertSong: started")
+	defer sc.logger.Info("RevertSong: completed")
+
+	song, err := sc.songService.RevertSong(c.UserContext(), c.Params("id"), c.Params("revision"), dto.ParseIfMatch(c.Get(fiber.HeaderIfMatch)), requestActor(c))
+	if err != nil {
+		sc.logger.Errorf("RevertSong: failed to revert song: %v", err)
+		return errorResponse(c, err, "Failed to revert song")
+	}
+
+	c.Set(fiber.HeaderETag, dto.ETag(song.Version))
+	return c.JSON(Response{
+		Success: true,
+		Message: "Song reverted successfully",
+		Data:    song,
+	})
+}
+
+// ImportSongs импортирует песни из CSV или NDJSON
+// @Summary Массовый импорт песен
+// @Description Потоково читает тело запроса в формате CSV (с заголовком) или NDJSON (по объекту на строку) и записывает песни пачками в шарды их групп; отсутствующие группы создаются. Колонки и ключи: group, song, release_date (YYYY-MM-DD), text, link, enrich. Если enrich=true, пустые release_date, text и link запрашиваются во внешнем API. Песня той же группы с тем же названием (без учёта регистра) пропускается как дубликат. Ответ содержит результат каждой строки: created, skipped или error с причиной. В режиме dry_run строки только проверяются, ничего не записывается.
+// @Tags Песни
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Формат тела: csv или ndjson. По умолчанию определяется по Content-Type." Enums(csv, ndjson)
+// @Param dry_run query bool false "Только проверить строки, не записывая их. По умолчанию false." default(false)
+// @Param X-Actor header string false "Автор изменения для истории песни. По умолчанию anonymous."
+// @Param data body string true "Строки CSV или NDJSON."
+// @Success 200 {object} Response{data=dto.ImportReport} "Отчёт об импорте по строкам."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Неизвестный формат или Content-Type.
+// - Пустое тело, неизвестная колонка или отсутствие колонок group и song в заголовке CSV.
+// - Некорректное значение dry_run."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/import [post]
+func (sc *SongController) ImportSongs(c *fiber.Ctx) error {
+	sc.logger.Info("ImportSongs: started")
+	defer sc.logger.Info("ImportSongs: completed")
+
+	dryRun, err := strconv.ParseBool(c.Query("dry_run", "false"))
+	if err != nil {
+		sc.logger.Warn("ImportSongs: invalid dry_run")
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: "Invalid dry_run value",
+		})
+	}
+
+	format := dto.ImportFormat(c.Query("format"))
+	if format == "" {
+		format = importFormat(c.Get(fiber.HeaderContentType))
+	}
+
+	// Большие тела не буферизуются целиком: при StreamRequestBody fasthttp
+	// отдаёт их потоком, маленькие уже прочитаны в c.Body()
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	report, err := sc.songService.ImportSongs(c.UserContext(), format, body, dryRun, requestActor(c))
+	if err != nil {
+		sc.logger.Errorf("ImportSongs: failed to import songs: %v", err)
+		return errorResponse(c, err, "Failed to import songs")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Songs imported successfully",
+		Data:    report,
+	})
+}
+
+// ExportSongs выгружает песни потоком
+// @Summary Выгрузка песен
+// @Description Потоково выгружает все песни из всех шардов вместе с группой в формате CSV (с заголовком), NDJSON (по объекту на строку) или JSON-массива. Поддерживает те же фильтры и сортировку, что и GET /api/song, но без пагинации. Все шарды читаются одним согласованным снимком. Если клиент передаёт Accept-Encoding: gzip, ответ сжимается. Ответ начинается до окончания выборки, поэтому ошибка во время выгрузки обрывает тело: JSON-массив в этом случае остаётся незакрытым.
+// @Tags Песни
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат выгрузки: csv, ndjson или json. По умолчанию json." Enums(csv, ndjson, json) default(json)
+// @Param group query string false "Фильтр по названию группы (подстрока). Также доступны group_exact и group_prefix."
+// @Param song query string false "Фильтр по названию песни (подстрока). Также доступны song_exact и song_prefix."
+// @Param text query string false "Фильтр по тексту песни (подстрока). Также доступны text_exact и text_prefix."
+// @Param link query string false "Фильтр по ссылке (подстрока). Также доступны link_exact и link_prefix."
+// @Param group_id query int false "Фильтр по ID группы."
+// @Param release_date query string false "Песни, выпущенные в указанную дату. Формат даты: YYYY-MM-DD."
+// @Param release_date_from query string false "Песни, выпущенные не раньше указанной даты. Формат даты: YYYY-MM-DD."
+// @Param release_date_to query string false "Песни, выпущенные не позже указанной даты. Формат даты: YYYY-MM-DD."
+// @Param sort query string false "Сортировка в формате поле:asc|desc. Поля: id, group, song, release_date. По умолчанию id:asc." default(id:asc)
+// @Param Accept-Encoding header string false "gzip для сжатого ответа."
+// @Success 200 {array} dto.ExportSong "Песни в выбранном формате. Колонки CSV: id, group_id, group, song, release_date, text, link, version."
+// @Failure 400 {object} Response "Неверный запрос. Возможные причины:
+// - Неизвестный формат выгрузки.
+// - Неизвестный параметр фильтрации или поле сортировки.
+// - Некорректный формат даты (должен быть в формате YYYY-MM-DD)."
+// @Router /api/song/export [get]
+func (sc *SongController) ExportSongs(c *fiber.Ctx) error {
+	sc.logger.Info("ExportSongs: started")
+	defer sc.logger.Info("ExportSongs: completed")
+
+	format, err := dto.ParseExportFormat(c.Query("format"))
+	if err != nil {
+		sc.logger.Warnf("ExportSongs: %v", err)
+		return errorResponse(c, err, "Failed to export songs")
+	}
+
+	params := c.Queries()
+	delete(params, "format")
+
+	filter, err := dto.ParseSongFilter(params)
+	if err != nil {
+		sc.logger.Warnf("ExportSongs: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	sc.logger.Infof("ExportSongs: format=%s, filters=%v", format, params)
+
+	compress := c.Context().Request.Header.HasAcceptEncoding("gzip")
+	c.Vary(fiber.HeaderAcceptEncoding)
+	c.Attachment("songs." + string(format))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	if compress {
+		c.Set(fiber.HeaderContentEncoding, "gzip")
+	}
+
+	// Тело пишется после выхода из обработчика, поэтому замыкание не
+	// должно обращаться к c. Дедлайн запроса к выгрузке не относится: она
+	// длится, пока клиент читает поток.
+	ctx := context.WithoutCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var out io.Writer = w
+		var zw *gzip.Writer
+		if compress {
+			zw = gzip.NewWriter(w)
+			out = zw
+		}
+
+		if err := sc.songService.ExportSongs(ctx, filter, format, out); err != nil {
+			sc.logger.Errorf("ExportSongs: failed to export songs: %v", err)
+			return
+		}
+		if zw != nil {
+			if err := zw.Close(); err != nil {
+				sc.logger.Errorf("ExportSongs: failed to finish gzip stream: %v", err)
+			}
+		}
+	})
+	return nil
+}
+
+// GetSyncedLyrics возвращает синхронизированный текст песни
+// @Summary Синхронизированный текст песни (LRC)
+// @Description Возвращает строки текста песни с моментом начала каждой строки и обычный текст, собранный из них. Источник - загруженный через PUT текст LRC (source=uploaded) или текст самой песни, размеченный метками [mm:ss.xx] (source=text). С параметром at вместо всех строк возвращается строка, звучащая в этот момент воспроизведения, и следующая за ней (data типа dto.SyncedLyricsAt); до первой строки поле line пустое.
+// @Tags Песни
+// @Produce json
+// @Param id path string true "ID песни."
+// @Param at query string false "Позиция воспроизведения: секунды (83.5) или mm:ss.xx (01:23.50)."
+// @Success 200 {object} Response{data=dto.SyncedLyrics} "Синхронизированный текст или строка в момент at."
+// @Failure 400 {object} Response "Некорректный ID песни или параметр at."
+// @Failure 404 {object} Response "Песня не найдена или у неё нет синхронизированного текста."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/lyrics/synced [get]
+func (sc *SongController) GetSyncedLyrics(c *fiber.Ctx) error {
+	sc.logger.Info("GetSyncedLyrics: started")
+	defer sc.logger.Info("GetSyncedLyrics: completed")
+
+	songID := c.Params("id")
+
+	if value := c.Query("at"); value != "" {
+		at, err := song_lyrics.ParseOffset(value)
+		if err != nil {
+			sc.logger.Warnf("GetSyncedLyrics: invalid at %q", value)
+			return errorResponse(c, err, "Failed to fetch synced lyrics")
+		}
+
+		line, err := sc.songService.GetSyncedLyricsAt(c.UserContext(), songID, at)
+		if err != nil {
+			sc.logger.Errorf("GetSyncedLyrics: failed to fetch synced line: %v", err)
+			return errorResponse(c, err, "Failed to fetch synced lyrics")
+		}
+		return c.JSON(Response{
+			Success: true,
+			Message: "Synced line fetched successfully",
+			Data:    line,
+		})
+	}
+
+	lyrics, err := sc.songService.GetSyncedLyrics(c.UserContext(), songID)
+	if err != nil {
+		sc.logger.Errorf("GetSyncedLyrics: failed to fetch synced lyrics: %v", err)
+		return errorResponse(c, err, "Failed to fetch synced lyrics")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Synced lyrics fetched successfully",
+		Data:    lyrics,
+	})
+}
+
+// SaveSyncedLyrics загружает синхронизированный текст песни
+// @Summary Загрузка синхронизированного текста (LRC)
+// @Description Разбирает тело запроса в формате LRC и заменяет им синхронизированный текст песни. Поддерживаются метки [mm:ss], [mm:ss.xx] и [mm:ss.xxx], несколько меток в одной строке, тег [offset:мс] и пословные метки <mm:ss.xx>, которые отбрасываются. Остальные теги метаданных и строки без меток пропускаются. Текст самой песни не меняется.
+// @Tags Песни
+// @Accept plain
+// @Produce json
+// @Param id path string true "ID песни."
+// @Param data body string true "Текст в формате LRC."
+// @Success 200 {object} Response{data=dto.SyncedLyrics} "Сохранённый синхронизированный текст."
+// @Failure 400 {object} Response "Некорректный ID песни или в тексте нет строк с метками времени."
+// @Failure 404 {object} Response "Песня не найдена."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/lyrics/synced [put]
+func (sc *SongController) SaveSyncedLyrics(c *fiber.Ctx) error {
+	sc.logger.Info("SaveSyncedLyrics: started")
+	defer sc.logger.Info("SaveSyncedLyrics: completed")
+
+	lyrics, err := sc.songService.SaveSyncedLyrics(c.UserContext(), c.Params("id"), string(c.Body()))
+	if err != nil {
+		sc.logger.Errorf("SaveSyncedLyrics: failed to save synced lyrics: %v", err)
+		return errorResponse(c, err, "Failed to save synced lyrics")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Synced lyrics saved successfully",
+		Data:    lyrics,
+	})
+}
+
+// DeleteSyncedLyrics удаляет загруженный синхронизированный текст песни
+// @Summary Удаление синхронизированного текста
+// @Description Удаляет загруженный через PUT текст LRC. Если текст самой песни размечен метками времени, GET продолжит отдавать строки из него.
+// @Tags Песни
+// @Produce json
+// @Param id path string true "ID песни."
+// @Success 200 {object} Response "Синхронизированный текст удалён."
+// @Failure 400 {object} Response "Некорректный ID песни."
+// @Failure 404 {object} Response "У песни нет загруженного синхронизированного текста."
+// @Failure 500 {object} Response "Ошибка сервера. Возможные причины:
+// - Внутренняя ошибка базы данных.
+// - Проблемы с подключением к базе данных."
+// @Router /api/song/{id}/lyrics/synced [delete]
+func (sc *SongController) DeleteSyncedLyrics(c *fiber.Ctx) error {
+	sc.logger.Info("DeleteSyncedLyrics: started")
+	defer sc.logger.Info("DeleteSyncedLyrics: completed")
+
+	if err := sc.songService.DeleteSyncedLyrics(c.UserContext(), c.Params("id")); err != nil {
+		sc.logger.Errorf("DeleteSyncedLyrics: failed to delete synced lyrics: %v", err)
+		return errorResponse(c, err, "Failed to delete synced lyrics")
+	}
+
+	return c.JSON(Response{
+		Success: true,
+		Message: "Synced lyrics deleted successfully",
+	})
+}
+
+// importFormat определяет формат импорта по Content-Type.
+func importFormat(contentType string) dto.ImportFormat {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv":
+		return dto.ImportCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return dto.ImportNDJSON
+	}
+	return ""
+}
+
+// requestActor возвращает автора изменения из заголовка X-Actor.
+func requestActor(c *fiber.Ctx) string {
+	return dto.NormalizeActor(c.Get(actorHeader))
+}
+
+// jobLocation возвращает адрес, по которому клиент может опросить задачу.
+func jobLocation(job *job_dto.Job) string {
+	return "/api/jobs/" + job.ID.String()
+}
+
+// errorStatus сопоставляет ошибкам сервиса HTTP-статус, неизвестные ошибки - 500.
+func errorStatus(err error) int {
+	var patchErr *dto.PatchError
+	switch {
+	case errors.As(err, &patchErr):
+		return fiber.StatusBadRequest
+	case errors.Is(err, dto.ErrInvalidCursor),
+		errors.Is(err, dto.ErrOffsetTooLarge),
+		errors.Is(err, dto.ErrCursorAndOffset),
+		errors.Is(err, dto.ErrEmptyQuery),
+		errors.Is(err, dto.ErrInvalidSongID),
+		errors.Is(err, dto.ErrInvalidRevision),
+		errors.Is(err, dto.ErrUnknownImportFormat),
+		errors.Is(err, dto.ErrUnknownExportFormat),
+		errors.Is(err, song_lyrics.ErrInvalidLRC),
+		errors.Is(err, song_lyrics.ErrInvalidOffset),
+		errors.Is(err, dto.ErrInvalidImport):
+		return fiber.StatusBadRequest
+	case errors.Is(err, dto.ErrSongNotFound),
+		errors.Is(err, dto.ErrRevisionNotFound),
+		errors.Is(err, dto.ErrLyricsPageNotFound),
+		errors.Is(err, dto.ErrSyncedNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, dto.ErrVersionMismatch):
+		return fiber.StatusPreconditionFailed
+	case errors.Is(err, dto.ErrSongNotDeleted),
+		errors.Is(err, dto.ErrSongExists),
+		errors.Is(err, dto.ErrGroupNameTaken):
+		return fiber.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return fiber.StatusServiceUnavailable
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// errorResponse отвечает статусом из errorStatus. Текст ошибок клиента отдаётся
+// как есть, для внутренних ошибок используется message.
+func errorResponse(c *fiber.Ctx, err error, message string) error {
+	status := errorStatus(err)
+	if status != fiber.StatusInternalServerError {
+		message = err.Error()
+	}
+	return c.Status(status).JSON(Response{
+		Success: false,
+		Message: message,
 	})
 }
@@ -0,0 +1,108 @@
+package song_controller
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"root/config"
+	job_dto "root/module/job/dto"
+	dto "root/module/song/dto"
+	song_repository "root/module/song/repository"
+	song_service "root/module/song/service"
+	"root/shared/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type fakeMusicClient struct{}
+
+func (fakeMusicClient) GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error) {
+	return &dto.SongDetails{ReleaseDate: "16.07.2006", Text: "Ooh baby", Link: "https://example.com/song"}, nil
+}
+
+func TestSongConditionalRequests(t *testing.T) {
+	log := logger.GetLogger()
+	service := song_service.NewSongService(log, &config.Config{}, song_repository.NewMemoryRepository(), nil, fakeMusicClient{}, nil)
+	controller := NewSongController(log, service)
+
+	id, err := service.ProcessJob(context.Background(), &job_dto.Job{Group: "Muse", Song: "Uprising", Actor: "test"})
+	if err != nil {
+		t.Fatalf("ProcessJob error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/song/:id", controller.GetSongText)
+	app.Patch("/api/song/:id", controller.UpdateSong)
+	app.Delete("/api/song/:id", controller.DeleteSong)
+
+	do := func(method, body, ifMatch string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/song/"+id.String(), strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if ifMatch != "" {
+			req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s error = %v", method, err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderETag)
+	}
+
+	if status, etag := do(fiber.MethodGet, "", ""); status != fiber.StatusOK || etag != `"1"` {
+		t.Fatalf("GET = %d, ETag %s", status, etag)
+	}
+	if status, _ := do(fiber.MethodPatch, `{"song":"Uprising (Live)"}`, `"7"`); status != fiber.StatusPreconditionFailed {
+		t.Fatalf("PATCH with stale If-Match = %d, want 412", status)
+	}
+	if status, etag := do(fiber.MethodPatch, `{"song":"Uprising (Live)"}`, `"1"`); status != fiber.StatusOK || etag != `"2"` {
+		t.Fatalf("PATCH = %d, ETag %s", status, etag)
+	}
+	if status, _ := do(fiber.MethodDelete, "", `"2"`); status != fiber.StatusOK {
+		t.Fatalf("DELETE = %d", status)
+	}
+	if status, _ := do(fiber.MethodGet, "", ""); status != fiber.StatusNotFound {
+		t.Fatalf("GET after DELETE = %d, want 404", status)
+	}
+}
+
+func TestGetSongsRejectsBadCursor(t *testing.T) {
+	log := logger.GetLogger()
+	service := song_service.NewSongService(log, &config.Config{}, song_repository.NewMemoryRepository(), nil, fakeMusicClient{}, nil)
+	controller := NewSongController(log, service)
+
+	if _, err := service.ProcessJob(context.Background(), &job_dto.Job{Group: "Muse", Song: "Uprising", Actor: "test"}); err != nil {
+		t.Fatalf("ProcessJob error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/song", controller.GetSongs)
+
+	get := func(query string) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/song?"+query, nil))
+		if err != nil {
+			t.Fatalf("GET ?%s error = %v", query, err)
+		}
+		return resp.StatusCode
+	}
+
+	// Курсор, выданный для сортировки по названию, не подходит к сортировке по группе
+	byTitle := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"song","v":"Uprising","id":"` + uuid.NewString() + `"}`))
+	for _, query := range []string{
+		"cursor=%21%21%21",
+		"cursor=" + base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		"cursor=" + byTitle + "&sort=group",
+	} {
+		if status := get(query); status != fiber.StatusBadRequest {
+			t.Errorf("GET ?%s = %d, want 400", query, status)
+		}
+	}
+	if status := get("cursor=" + byTitle + "&sort=song"); status != fiber.StatusOK {
+		t.Errorf("GET with a valid cursor = %d, want 200", status)
+	}
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// SongDetailsCacheEntry - запись постоянного кэша ответов внешнего API.
+// NotFound отмечает закэшированный ответ 404.
+type SongDetailsCacheEntry struct {
+	Key         string    `gorm:"primaryKey"`
+	Group       string    `gorm:"not null"`
+	Song        string    `gorm:"not null"`
+	ReleaseDate string    `gorm:"not null;default:''"`
+	Text        string    `gorm:"type:text;not null;default:''"`
+	Link        string    `gorm:"type:text;not null;default:''"`
+	NotFound    bool      `gorm:"not null;default:false"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (SongDetailsCacheEntry) TableName() string {
+	return "song_details_cache"
+}
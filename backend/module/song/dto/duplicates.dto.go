@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/google/uuid"
+
+// DuplicateCandidate - пара песен одной группы с похожими названиями.
+// Similarity - триграммное сходство названий pg_trgm от 0 до 1.
+type DuplicateCandidate struct {
+	GroupID       int       `json:"group_id"`
+	Group         string    `json:"group"`
+	SongID        uuid.UUID `json:"song_id"`
+	Song          string    `json:"song"`
+	DuplicateID   uuid.UUID `json:"duplicate_id"`
+	DuplicateSong string    `json:"duplicate_song"`
+	Similarity    float64   `json:"similarity"`
+}
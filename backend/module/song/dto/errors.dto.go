@@ -0,0 +1,22 @@
+package dto
+
+import "errors"
+
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrOffsetTooLarge     = errors.New("offset is too large, use cursor instead")
+	ErrCursorAndOffset    = errors.New("cursor and offset cannot be used together")
+	ErrEmptyQuery         = errors.New("search query is empty")
+	ErrInvalidSongID      = errors.New("invalid song id")
+	ErrSongNotFound       = errors.New("song not found")
+	ErrLyricsPageNotFound = errors.New("lyrics page is out of range")
+	ErrVersionMismatch    = errors.New("song version does not match If-Match")
+	ErrSongNotDeleted     = errors.New("song is not in trash")
+	ErrSongExists         = errors.New("song with the same title already exists in the group")
+	ErrGroupNameTaken     = errors.New("song's group was deleted and its name is taken by another group")
+	ErrInvalidRevision    = errors.New("invalid revision number")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrSyncedNotFound     = errors.New("song has no synced lyrics")
+
+	ErrInvalidSongDetails = errors.New("invalid song details from external API")
+)
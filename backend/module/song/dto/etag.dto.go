@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IfMatch - условие заголовка If-Match: список версий песни, при которых
+// изменение допустимо. Nil означает отсутствие условия.
+type IfMatch struct {
+	Versions []int
+}
+
+// ETag возвращает сильный ETag для версии песни.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatch разбирает заголовок If-Match. Пустой заголовок и "*" не
+// ограничивают версию. Слабые и нераспознанные ETag не совпадают ни с одной
+// версией: If-Match требует строгого сравнения.
+func ParseIfMatch(header string) *IfMatch {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	condition := &IfMatch{Versions: []int{}}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			condition.Versions = append(condition.Versions, version)
+		}
+	}
+	return condition
+}
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   *IfMatch
+	}{
+		{"absent", "", nil},
+		{"blank", "   ", nil},
+		{"any", "*", nil},
+		{"any with spaces", " * ", nil},
+		{"strong", `"3"`, &IfMatch{Versions: []int{3}}},
+		{"own etag", ETag(12), &IfMatch{Versions: []int{12}}},
+		{"list", `"1", "2","5"`, &IfMatch{Versions: []int{1, 2, 5}}},
+		// Слабое сравнение для If-Match запрещено, такой тег не совпадает ни с чем
+		{"weak", `W/"3"`, &IfMatch{Versions: []int{}}},
+		{"weak and strong", `W/"3", "4"`, &IfMatch{Versions: []int{4}}},
+		{"unquoted", `3`, &IfMatch{Versions: []int{}}},
+		{"unterminated quote", `"3`, &IfMatch{Versions: []int{}}},
+		{"foreign etag", `"abc"`, &IfMatch{Versions: []int{}}},
+		{"empty etag", `""`, &IfMatch{Versions: []int{}}},
+		{"star inside list", `*, "2"`, &IfMatch{Versions: []int{2}}},
+		{"only commas", `,,`, &IfMatch{Versions: []int{}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ParseIfMatch(tc.header); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseIfMatch(%q) = %+v, want %+v", tc.header, got, tc.want)
+			}
+		})
+	}
+}
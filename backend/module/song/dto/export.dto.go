@@ -0,0 +1,85 @@
+package dto
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormat - формат выгрузки песен.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+	ExportJSON   ExportFormat = "json"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format, use csv, ndjson or json")
+
+// ParseExportFormat проверяет формат выгрузки. Пустое значение означает json.
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(value); format {
+	case "":
+		return ExportJSON, nil
+	case ExportCSV, ExportNDJSON, ExportJSON:
+		return format, nil
+	}
+	return "", ErrUnknownExportFormat
+}
+
+// ContentType возвращает MIME-тип выгрузки.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// ExportColumns - колонки CSV выгрузки. Колонки group, song, release_date, text
+// и link совпадают с колонками импорта.
+var ExportColumns = []string{"id", "group_id", "group", "song", "release_date", "text", "link", "version"}
+
+// ExportSong - песня в выгрузке: вместе с группой и датой в формате YYYY-MM-DD.
+type ExportSong struct {
+	ID          uuid.UUID `json:"id"`
+	GroupID     int       `json:"group_id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	ReleaseDate string    `json:"release_date"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+	Version     int       `json:"version"`
+}
+
+func NewExportSong(song *Song) *ExportSong {
+	return &ExportSong{
+		ID:          song.ID,
+		GroupID:     song.GroupID,
+		Group:       song.Group,
+		Song:        song.Song,
+		ReleaseDate: song.ReleaseDate.Format(DateLayout),
+		Text:        song.Text,
+		Link:        song.Link,
+		Version:     song.Version,
+	}
+}
+
+// Record возвращает значения в порядке ExportColumns.
+func (e *ExportSong) Record() []string {
+	return []string{
+		e.ID.String(),
+		strconv.Itoa(e.GroupID),
+		e.Group,
+		e.Song,
+		e.ReleaseDate,
+		e.Text,
+		e.Link,
+		strconv.Itoa(e.Version),
+	}
+}
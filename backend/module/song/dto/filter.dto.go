@@ -0,0 +1,186 @@
+package dto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout - формат дат в параметрах запросов.
+const DateLayout = "2006-01-02"
+
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains"
+	MatchExact    MatchMode = "exact"
+	MatchPrefix   MatchMode = "prefix"
+)
+
+// TextFilter - условие на текстовое поле песни.
+type TextFilter struct {
+	Mode  MatchMode
+	Value string
+}
+
+// SongSort - поле сортировки списка песен из SortFields и направление.
+type SongSort struct {
+	Field string
+	Desc  bool
+}
+
+// SongFilter - типизированный фильтр списка песен. Nil-поля не участвуют в выборке.
+type SongFilter struct {
+	Group *TextFilter
+	Song  *TextFilter
+	Text  *TextFilter
+	Link  *TextFilter
+
+	GroupID         *int
+	ReleaseDate     *time.Time
+	ReleaseDateFrom *time.Time
+	ReleaseDateTo   *time.Time
+
+	Sort SongSort
+}
+
+// Сортировки, доступные клиентам. По умолчанию песни идут по id.
+const (
+	SortByID          = "id"
+	SortByGroup       = "group"
+	SortBySong        = "song"
+	SortByReleaseDate = "release_date"
+)
+
+var SortFields = []string{SortByID, SortByGroup, SortBySong, SortByReleaseDate}
+
+// textFilterFields - параметры текстовых фильтров. Параметр без суффикса ищет
+// подстроку, суффиксы _exact, _prefix и _contains задают режим явно.
+var textFilterFields = []string{"group", "song", "text", "link"}
+
+// FilterError описывает некорректный или неизвестный параметр фильтрации.
+type FilterError struct {
+	Param  string
+	Reason string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter %q: %s", e.Param, e.Reason)
+}
+
+// ParseSongFilter строит SongFilter из параметров запроса. Любой параметр,
+// не входящий в список разрешённых, считается ошибкой.
+func ParseSongFilter(params map[string]string) (*SongFilter, error) {
+	filter := &SongFilter{Sort: SongSort{Field: SortByID}}
+
+	for param, value := range params {
+		if err := filter.set(param, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.ReleaseDateFrom != nil && filter.ReleaseDateTo != nil && filter.ReleaseDateFrom.After(*filter.ReleaseDateTo) {
+		return nil, &FilterError{Param: "release_date_from", Reason: "must not be after release_date_to"}
+	}
+
+	return filter, nil
+}
+
+func (f *SongFilter) set(param, value string) error {
+	switch param {
+	case "group_id":
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return &FilterError{Param: param, Reason: "must be a positive integer"}
+		}
+		f.GroupID = &id
+		return nil
+	case "release_date":
+		return setDate(&f.ReleaseDate, param, value)
+	case "release_date_from":
+		return setDate(&f.ReleaseDateFrom, param, value)
+	case "release_date_to":
+		return setDate(&f.ReleaseDateTo, param, value)
+	case "sort":
+		return f.setSort(value)
+	}
+
+	for _, field := range textFilterFields {
+		mode, ok := textFilterMode(param, field)
+		if !ok {
+			continue
+		}
+		if value == "" {
+			return &FilterError{Param: param, Reason: "must not be empty"}
+		}
+
+		target := f.textField(field)
+		if *target != nil {
+			return &FilterError{Param: param, Reason: fmt.Sprintf("only one %s filter is allowed", field)}
+		}
+		*target = &TextFilter{Mode: mode, Value: value}
+		return nil
+	}
+
+	return &FilterError{Param: param, Reason: "unknown filter"}
+}
+
+func (f *SongFilter) setSort(value string) error {
+	field, direction, _ := strings.Cut(value, ":")
+
+	known := false
+	for _, allowed := range SortFields {
+		if field == allowed {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return &FilterError{Param: "sort", Reason: fmt.Sprintf("unknown sort field %q, allowed: %s", field, strings.Join(SortFields, ", "))}
+	}
+
+	switch direction {
+	case "", "asc":
+		f.Sort = SongSort{Field: field}
+	case "desc":
+		f.Sort = SongSort{Field: field, Desc: true}
+	default:
+		return &FilterError{Param: "sort", Reason: "direction must be asc or desc"}
+	}
+	return nil
+}
+
+func (f *SongFilter) textField(field string) **TextFilter {
+	switch field {
+	case "group":
+		return &f.Group
+	case "song":
+		return &f.Song
+	case "text":
+		return &f.Text
+	default:
+		return &f.Link
+	}
+}
+
+func textFilterMode(param, field string) (MatchMode, bool) {
+	switch param {
+	case field, field + "_contains":
+		return MatchContains, true
+	case field + "_exact":
+		return MatchExact, true
+	case field + "_prefix":
+		return MatchPrefix, true
+	}
+	return "", false
+}
+
+func setDate(target **time.Time, param, value string) error {
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return &FilterError{Param: param, Reason: "date must be in YYYY-MM-DD format"}
+	}
+	*target = &date
+	return nil
+}
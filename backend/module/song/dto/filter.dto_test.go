@@ -0,0 +1,134 @@
+package dto
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSongFilter(t *testing.T) {
+	date := func(value string) *time.Time {
+		d, _ := time.Parse(DateLayout, value)
+		return &d
+	}
+	groupID := 7
+
+	cases := []struct {
+		name   string
+		params map[string]string
+		want   *SongFilter
+	}{
+		{
+			name:   "no params sort by id",
+			params: map[string]string{},
+			want:   &SongFilter{Sort: SongSort{Field: SortByID}},
+		},
+		{
+			name:   "text filters with modes",
+			params: map[string]string{"group": "Muse", "song_exact": "Uprising", "text_prefix": "Paranoia", "link_contains": "youtube"},
+			want: &SongFilter{
+				Group: &TextFilter{Mode: MatchContains, Value: "Muse"},
+				Song:  &TextFilter{Mode: MatchExact, Value: "Uprising"},
+				Text:  &TextFilter{Mode: MatchPrefix, Value: "Paranoia"},
+				Link:  &TextFilter{Mode: MatchContains, Value: "youtube"},
+				Sort:  SongSort{Field: SortByID},
+			},
+		},
+		{
+			name:   "group id and date range",
+			params: map[string]string{"group_id": "7", "release_date_from": "2006-01-01", "release_date_to": "2009-12-31"},
+			want: &SongFilter{
+				GroupID:         &groupID,
+				ReleaseDateFrom: date("2006-01-01"),
+				ReleaseDateTo:   date("2009-12-31"),
+				Sort:            SongSort{Field: SortByID},
+			},
+		},
+		{
+			name:   "single day range",
+			params: map[string]string{"release_date_from": "2006-07-16", "release_date_to": "2006-07-16"},
+			want: &SongFilter{
+				ReleaseDateFrom: date("2006-07-16"),
+				ReleaseDateTo:   date("2006-07-16"),
+				Sort:            SongSort{Field: SortByID},
+			},
+		},
+		{
+			name:   "exact date",
+			params: map[string]string{"release_date": "2006-07-16"},
+			want:   &SongFilter{ReleaseDate: date("2006-07-16"), Sort: SongSort{Field: SortByID}},
+		},
+		{
+			name:   "sort without direction",
+			params: map[string]string{"sort": "release_date"},
+			want:   &SongFilter{Sort: SongSort{Field: SortByReleaseDate}},
+		},
+		{
+			name:   "sort asc",
+			params: map[string]string{"sort": "group:asc"},
+			want:   &SongFilter{Sort: SongSort{Field: SortByGroup}},
+		},
+		{
+			name:   "sort desc",
+			params: map[string]string{"sort": "song:desc"},
+			want:   &SongFilter{Sort: SongSort{Field: SortBySong, Desc: true}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSongFilter(tc.params)
+			if err != nil {
+				t.Fatalf("ParseSongFilter error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseSongFilter = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSongFilterRejects(t *testing.T) {
+	cases := []struct {
+		name   string
+		params map[string]string
+		param  string
+	}{
+		{"unknown field", map[string]string{"artist": "Muse"}, "artist"},
+		{"unknown text mode", map[string]string{"song_suffix": "ing"}, "song_suffix"},
+		{"pagination is not a filter", map[string]string{"page": "2"}, "page"},
+		{"empty text filter", map[string]string{"group": ""}, "group"},
+		{"unknown sort field", map[string]string{"sort": "text"}, "sort"},
+		{"empty sort", map[string]string{"sort": ""}, "sort"},
+		{"unknown sort direction", map[string]string{"sort": "group:down"}, "sort"},
+		{"sort field is case sensitive", map[string]string{"sort": "Group"}, "sort"},
+		{"group id is not a number", map[string]string{"group_id": "muse"}, "group_id"},
+		{"group id is not positive", map[string]string{"group_id": "0"}, "group_id"},
+		{"external date format", map[string]string{"release_date": "16.07.2006"}, "release_date"},
+		{"impossible date", map[string]string{"release_date_from": "2006-02-30"}, "release_date_from"},
+		{"date with time", map[string]string{"release_date_to": "2006-07-16T00:00:00Z"}, "release_date_to"},
+		{"inverted range", map[string]string{"release_date_from": "2010-01-01", "release_date_to": "2009-12-31"}, "release_date_from"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := ParseSongFilter(tc.params)
+			var filterErr *FilterError
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("ParseSongFilter = %+v, %v; want FilterError", filter, err)
+			}
+			if filterErr.Param != tc.param {
+				t.Errorf("FilterError.Param = %q, want %q", filterErr.Param, tc.param)
+			}
+		})
+	}
+}
+
+// TestParseSongFilterOneTextFilterPerField проверяет, что два режима одного
+// поля не складываются молча: порядок обхода map не должен решать, какой победит.
+func TestParseSongFilterOneTextFilterPerField(t *testing.T) {
+	_, err := ParseSongFilter(map[string]string{"song": "Up", "song_exact": "Uprising"})
+	var filterErr *FilterError
+	if !errors.As(err, &filterErr) {
+		t.Fatalf("ParseSongFilter error = %v, want FilterError", err)
+	}
+}
@@ -0,0 +1,136 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ImportFormat - формат тела запроса импорта.
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+// ImportStatus - результат импорта одной строки.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportSkipped ImportStatus = "skipped"
+	ImportFailed  ImportStatus = "error"
+)
+
+var (
+	ErrUnknownImportFormat = errors.New("unknown import format, use csv or ndjson")
+	ErrInvalidImport       = errors.New("invalid import body")
+)
+
+// ImportRow - строка импорта. Колонки CSV и ключи NDJSON совпадают с тегами json.
+// Если Enrich установлен, пустые release_date, text и link запрашиваются во
+// внешнем API; заполненные значения строки имеют приоритет.
+type ImportRow struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	Enrich      bool   `json:"enrich"`
+}
+
+// ImportRowResult - результат импорта строки. Row - номер строки данных,
+// начиная с 1, без учёта заголовка CSV.
+type ImportRowResult struct {
+	Row    int          `json:"row"`
+	Status ImportStatus `json:"status"`
+	SongID *uuid.UUID   `json:"song_id,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+}
+
+// ImportReport - отчёт об импорте. В режиме DryRun ничего не записывается,
+// а статус created означает, что строка была бы создана.
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add добавляет результат строки в отчёт.
+func (r *ImportReport) Add(result ImportRowResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// Normalize обрезает пробелы и проверяет обязательные поля до обогащения.
+func (r *ImportRow) Normalize() error {
+	r.Group = strings.TrimSpace(r.Group)
+	r.Song = strings.TrimSpace(r.Song)
+	r.ReleaseDate = strings.TrimSpace(r.ReleaseDate)
+	r.Text = strings.TrimSpace(r.Text)
+	r.Link = strings.TrimSpace(r.Link)
+
+	if r.Group == "" || utf8.RuneCountInString(r.Group) > maxSongNameLength {
+		return fmt.Errorf("group must be between 1 and %d characters", maxSongNameLength)
+	}
+	if r.Song == "" || utf8.RuneCountInString(r.Song) > maxSongNameLength {
+		return fmt.Errorf("song must be between 1 and %d characters", maxSongNameLength)
+	}
+	return nil
+}
+
+// Merge заполняет пустые поля строки деталями из внешнего API.
+func (r *ImportRow) Merge(details *SongDetails) {
+	if r.ReleaseDate == "" {
+		if date, err := time.Parse(ExternalDateLayout, strings.TrimSpace(details.ReleaseDate)); err == nil {
+			r.ReleaseDate = date.Format(DateLayout)
+		}
+	}
+	if r.Text == "" {
+		r.Text = strings.TrimSpace(details.Text)
+	}
+	if r.Link == "" {
+		r.Link = strings.TrimSpace(details.Link)
+	}
+}
+
+// ToSong проверяет строку и собирает из неё песню. GroupID заполняет
+// вызывающий после определения группы.
+func (r *ImportRow) ToSong() (*Song, error) {
+	releaseDate, err := time.Parse(DateLayout, r.ReleaseDate)
+	if err != nil {
+		return nil, errors.New("release_date must be in YYYY-MM-DD format")
+	}
+	if r.Text == "" {
+		return nil, errors.New("text must not be empty")
+	}
+	parsed, err := url.Parse(r.Link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, errors.New("link must be an absolute http(s) URL")
+	}
+
+	return &Song{
+		ID:          uuid.New(),
+		Group:       r.Group,
+		Song:        r.Song,
+		Text:        r.Text,
+		Link:        r.Link,
+		ReleaseDate: releaseDate,
+		Version:     1,
+	}, nil
+}
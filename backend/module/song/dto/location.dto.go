@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/google/uuid"
+
+// SongLocation - запись индекса id песни -> группа. Шард песни вычисляется по
+// группе тем же правилом, что и у плагина шардирования, поэтому индекс не нужно
+// переписывать при изменении числа шардов.
+type SongLocation struct {
+	SongID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+	GroupID int       `gorm:"not null;index"`
+}
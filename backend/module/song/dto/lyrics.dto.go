@@ -0,0 +1,21 @@
+package dto
+
+import (
+	song_lyrics "root/module/song/lyrics"
+
+	"github.com/google/uuid"
+)
+
+// SongLyricsPage - страница секций текста песни. Page начинается с 1,
+// HasNext сообщает, есть ли следующая страница.
+type SongLyricsPage struct {
+	SongID        uuid.UUID             `json:"song_id"`
+	Song          string                `json:"song"`
+	Group         string                `json:"group"`
+	TotalSections int                   `json:"total_sections"`
+	Page          int                   `json:"page"`
+	PageSize      int                   `json:"page_size"`
+	HasNext       bool                  `json:"has_next"`
+	Sections      []song_lyrics.Section `json:"sections"`
+	Version       int                   `json:"version"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+import "github.com/google/uuid"
+
+// PageRequest описывает запрошенную страницу списка песен.
+// Cursor и Offset взаимоисключающие: курсор используется для keyset-пагинации,
+// смещение оставлено для обратной совместимости и ограничено сверху.
+type PageRequest struct {
+	Offset int
+	Limit  int
+	Cursor string
+}
+
+type SongPage struct {
+	Songs      []Song `json:"songs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SongCursor - позиция в списке песен: сортировка, для которой она выдана,
+// значение поля сортировки и id последней отданной песни. Сервис кодирует её
+// в непрозрачный курсор, репозиторий отдаёт песни строго после неё.
+type SongCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v,omitempty"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// NewSongCursor возвращает позицию сразу после song в порядке sort.
+func NewSongCursor(sort SongSort, song *Song) *SongCursor {
+	return &SongCursor{
+		Sort:  sort.Field,
+		Desc:  sort.Desc,
+		Value: SortValue(sort.Field, song),
+		ID:    song.ID,
+	}
+}
+
+// SortValue возвращает значение поля сортировки песни в виде строки курсора.
+func SortValue(field string, song *Song) string {
+	switch field {
+	case SortByGroup:
+		return song.Group
+	case SortBySong:
+		return song.Song
+	case SortByReleaseDate:
+		return song.ReleaseDate.Format(DateLayout)
+	default:
+		return ""
+	}
+}
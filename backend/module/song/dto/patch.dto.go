@@ -0,0 +1,173 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSongNameLength - ограничение длины названия песни в символах.
+const maxSongNameLength = 255
+
+// SongPatch - частичное обновление песни. Nil-поля не изменяются. Смена
+// GroupID переносит песню в шард новой группы и обновляет название группы.
+type SongPatch struct {
+	GroupID     *int       `json:"group_id,omitempty" example:"1"`
+	Song        *string    `json:"song,omitempty" example:"Supermassive Black Hole"`
+	Text        *string    `json:"text,omitempty"`
+	Link        *string    `json:"link,omitempty" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	ReleaseDate *time.Time `json:"release_date,omitempty" swaggertype:"string" example:"2006-07-16"`
+}
+
+// patchFields - поля, которые клиент может изменить.
+var patchFields = []string{"group_id", "song", "text", "link", "release_date"}
+
+// immutableFields - поля песни, которые нельзя менять через PATCH, и причина.
+var immutableFields = map[string]string{
+	"id":    "song id is immutable",
+	"group": "group name follows group_id, rename the group via /api/group/{id}",
+}
+
+// PatchError описывает некорректное поле в теле PATCH.
+type PatchError struct {
+	Field  string
+	Reason string
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("invalid field %q: %s", e.Field, e.Reason)
+}
+
+// ParseSongPatch разбирает и проверяет тело PATCH. Неизвестные и неизменяемые
+// поля, null и пустой объект считаются ошибкой.
+func ParseSongPatch(body []byte) (*SongPatch, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, &PatchError{Field: "body", Reason: "must be a JSON object"}
+	}
+	if len(fields) == 0 {
+		return nil, &PatchError{Field: "body", Reason: "no fields to update, allowed: " + strings.Join(patchFields, ", ")}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	// Порядок проверки фиксирован, чтобы ошибка не зависела от обхода map
+	sort.Strings(names)
+
+	patch := new(SongPatch)
+	for _, name := range names {
+		if err := patch.set(name, fields[name]); err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+func (p *SongPatch) set(field string, raw json.RawMessage) error {
+	if reason, ok := immutableFields[field]; ok {
+		return &PatchError{Field: field, Reason: reason}
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return &PatchError{Field: field, Reason: "must not be null"}
+	}
+
+	switch field {
+	case "group_id":
+		var id int
+		if err := json.Unmarshal(raw, &id); err != nil || id < 1 {
+			return &PatchError{Field: field, Reason: "must be a positive integer"}
+		}
+		p.GroupID = &id
+	case "song":
+		value, err := patchString(field, raw)
+		if err != nil {
+			return err
+		}
+		if value == "" || utf8.RuneCountInString(value) > maxSongNameLength {
+			return &PatchError{Field: field, Reason: fmt.Sprintf("must be between 1 and %d characters", maxSongNameLength)}
+		}
+		p.Song = &value
+	case "text":
+		value, err := patchString(field, raw)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return &PatchError{Field: field, Reason: "must not be empty"}
+		}
+		p.Text = &value
+	case "link":
+		value, err := patchString(field, raw)
+		if err != nil {
+			return err
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return &PatchError{Field: field, Reason: "must be an absolute http(s) URL"}
+		}
+		p.Link = &value
+	case "release_date":
+		value, err := patchString(field, raw)
+		if err != nil {
+			return err
+		}
+		date, err := time.Parse(DateLayout, value)
+		if err != nil {
+			return &PatchError{Field: field, Reason: "date must be in YYYY-MM-DD format"}
+		}
+		p.ReleaseDate = &date
+	default:
+		return &PatchError{Field: field, Reason: "unknown field, allowed: " + strings.Join(patchFields, ", ")}
+	}
+	return nil
+}
+
+// Columns возвращает изменяемые колонки песни, кроме group_id: смену группы
+// обрабатывает сервис.
+func (p *SongPatch) Columns() map[string]interface{} {
+	columns := map[string]interface{}{}
+	if p.Song != nil {
+		columns["song"] = *p.Song
+	}
+	if p.Text != nil {
+		columns["text"] = *p.Text
+	}
+	if p.Link != nil {
+		columns["link"] = *p.Link
+	}
+	if p.ReleaseDate != nil {
+		columns["release_date"] = *p.ReleaseDate
+	}
+	return columns
+}
+
+// Apply переносит изменения в song, кроме group_id.
+func (p *SongPatch) Apply(song *Song) {
+	if p.Song != nil {
+		song.Song = *p.Song
+	}
+	if p.Text != nil {
+		song.Text = *p.Text
+	}
+	if p.Link != nil {
+		song.Link = *p.Link
+	}
+	if p.ReleaseDate != nil {
+		song.ReleaseDate = *p.ReleaseDate
+	}
+}
+
+func patchString(field string, raw json.RawMessage) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", &PatchError{Field: field, Reason: "must be a string"}
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSongPatch(t *testing.T) {
+	str := func(value string) *string { return &value }
+	groupID := 3
+	released, _ := time.Parse(DateLayout, "2006-07-16")
+
+	cases := []struct {
+		name string
+		body string
+		want *SongPatch
+	}{
+		{
+			name: "single field leaves others absent",
+			body: `{"song":"Uprising"}`,
+			want: &SongPatch{Song: str("Uprising")},
+		},
+		{
+			name: "all fields",
+			body: `{"group_id":3,"song":" Starlight ","text":"Far away","link":"https://example.com/starlight","release_date":"2006-07-16"}`,
+			want: &SongPatch{
+				GroupID:     &groupID,
+				Song:        str("Starlight"),
+				Text:        str("Far away"),
+				Link:        str("https://example.com/starlight"),
+				ReleaseDate: &released,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSongPatch([]byte(tc.body))
+			if err != nil {
+				t.Fatalf("ParseSongPatch error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseSongPatch = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSongPatchRejects(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"not json", `song=Uprising`, "body"},
+		{"array", `[{"song":"Uprising"}]`, "body"},
+		{"null body", `null`, "body"},
+		{"empty object", `{}`, "body"},
+		{"unknown field", `{"artist":"Muse"}`, "artist"},
+		{"immutable id", `{"id":"7b3c5c9e-0000-0000-0000-000000000000"}`, "id"},
+		{"immutable group", `{"group":"Muse"}`, "group"},
+		{"null song", `{"song":null}`, "song"},
+		{"null text", `{"text": null }`, "text"},
+		{"null group id", `{"group_id":null}`, "group_id"},
+		{"blank song", `{"song":"   "}`, "song"},
+		{"too long song", `{"song":"` + strings.Repeat("я", maxSongNameLength+1) + `"}`, "song"},
+		{"song is not a string", `{"song":42}`, "song"},
+		{"empty text", `{"text":""}`, "text"},
+		{"group id is a string", `{"group_id":"3"}`, "group_id"},
+		{"group id is not positive", `{"group_id":0}`, "group_id"},
+		{"relative link", `{"link":"/watch?v=1"}`, "link"},
+		{"link scheme", `{"link":"ftp://example.com/song"}`, "link"},
+		{"external date format", `{"release_date":"16.07.2006"}`, "release_date"},
+		// Ошибка по первому полю в алфавитном порядке, а не по порядку обхода map
+		{"first invalid field wins", `{"song":null,"link":"x","group":"Muse"}`, "group"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch, err := ParseSongPatch([]byte(tc.body))
+			var patchErr *PatchError
+			if !errors.As(err, &patchErr) {
+				t.Fatalf("ParseSongPatch = %+v, %v; want PatchError", patch, err)
+			}
+			if patchErr.Field != tc.field {
+				t.Errorf("PatchError.Field = %q, want %q", patchErr.Field, tc.field)
+			}
+		})
+	}
+}
+
+func TestSongPatchApply(t *testing.T) {
+	text := "New text"
+	patch := &SongPatch{Text: &text}
+	song := &Song{Group: "Muse", Song: "Uprising", Text: "Old text", Link: "https://example.com/uprising"}
+
+	patch.Apply(song)
+	if song.Text != text || song.Song != "Uprising" || song.Link != "https://example.com/uprising" {
+		t.Errorf("Apply changed absent fields: %+v", song)
+	}
+	if columns := patch.Columns(); !reflect.DeepEqual(columns, map[string]interface{}{"text": text}) {
+		t.Errorf("Columns = %v, want only text", columns)
+	}
+}
@@ -0,0 +1,181 @@
+package dto
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// RevisionAction - изменение песни, записанное в ревизии.
+type RevisionAction string
+
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+	RevisionRevert  RevisionAction = "revert"
+)
+
+const (
+	// AnonymousActor - автор изменения, если клиент его не указал.
+	AnonymousActor = "anonymous"
+	// maxActorLength - ограничение длины автора изменения в символах.
+	maxActorLength = 255
+)
+
+// NormalizeActor приводит автора изменения к виду для истории: слишком
+// длинное значение обрезается, пустое заменяется на AnonymousActor.
+func NormalizeActor(actor string) string {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return AnonymousActor
+	}
+	if utf8.RuneCountInString(actor) > maxActorLength {
+		actor = string([]rune(actor)[:maxActorLength])
+	}
+	return actor
+}
+
+// SongRevision - неизменяемая запись истории песни. Номер ревизии - версия
+// песни после изменения, включая изменения через группу: переименование и
+// каскадное удаление. История хранится и после окончательного удаления песни.
+type SongRevision struct {
+	ID        int64          `json:"-" gorm:"primaryKey"`
+	SongID    uuid.UUID      `json:"song_id" gorm:"type:uuid;not null;uniqueIndex:idx_song_revisions_song_version"`
+	Version   int            `json:"version" gorm:"not null;uniqueIndex:idx_song_revisions_song_version"`
+	Action    RevisionAction `json:"action" gorm:"type:varchar(16);not null"`
+	Actor     string         `json:"actor" gorm:"not null"`
+	Snapshot  SongSnapshot   `json:"snapshot" gorm:"type:jsonb;not null"`
+	Diff      RevisionDiff   `json:"diff" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"not null"`
+}
+
+// NewSongRevision собирает ревизию с состоянием song после изменения.
+func NewSongRevision(song *Song, diff RevisionDiff, action RevisionAction, actor string) SongRevision {
+	return SongRevision{
+		SongID:   song.ID,
+		Version:  song.Version,
+		Action:   action,
+		Actor:    actor,
+		Snapshot: NewSongSnapshot(song),
+		Diff:     diff,
+	}
+}
+
+// SongSnapshot - состояние изменяемых полей песни после изменения.
+type SongSnapshot struct {
+	GroupID     int    `json:"group_id"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+	ReleaseDate string `json:"release_date" example:"2006-07-16"`
+}
+
+// FieldChange - значение поля до и после изменения.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// RevisionDiff - изменённые поля песни по именам полей снимка.
+type RevisionDiff map[string]FieldChange
+
+// NewSongSnapshot снимает состояние песни.
+func NewSongSnapshot(song *Song) SongSnapshot {
+	return SongSnapshot{
+		GroupID:     song.GroupID,
+		Group:       song.Group,
+		Song:        song.Song,
+		Text:        song.Text,
+		Link:        song.Link,
+		ReleaseDate: song.ReleaseDate.Format(DateLayout),
+	}
+}
+
+// Diff возвращает поля, отличающиеся от prev. Для новой песни prev равен nil,
+// и в diff попадают все поля.
+func (s SongSnapshot) Diff(prev *SongSnapshot) RevisionDiff {
+	if prev == nil {
+		prev = &SongSnapshot{}
+	}
+	diff := RevisionDiff{}
+	if s.GroupID != prev.GroupID {
+		diff["group_id"] = FieldChange{From: prev.GroupID, To: s.GroupID}
+	}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"group", prev.Group, s.Group},
+		{"song", prev.Song, s.Song},
+		{"text", prev.Text, s.Text},
+		{"link", prev.Link, s.Link},
+		{"release_date", prev.ReleaseDate, s.ReleaseDate},
+	}
+	for _, field := range fields {
+		if field.from != field.to {
+			diff[field.name] = FieldChange{From: field.from, To: field.to}
+		}
+	}
+	return diff
+}
+
+// Patch возвращает изменение, приводящее песню к состоянию снимка.
+func (s SongSnapshot) Patch() (*SongPatch, error) {
+	releaseDate, err := time.Parse(DateLayout, s.ReleaseDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release date in snapshot: %w", err)
+	}
+	return &SongPatch{
+		GroupID:     &s.GroupID,
+		Song:        &s.Song,
+		Text:        &s.Text,
+		Link:        &s.Link,
+		ReleaseDate: &releaseDate,
+	}, nil
+}
+
+func (s SongSnapshot) Value() (driver.Value, error) {
+	return jsonValue(s)
+}
+
+func (s *SongSnapshot) Scan(value interface{}) error {
+	return jsonScan(value, s)
+}
+
+func (d RevisionDiff) Value() (driver.Value, error) {
+	if d == nil {
+		d = RevisionDiff{}
+	}
+	return jsonValue(d)
+}
+
+func (d *RevisionDiff) Scan(value interface{}) error {
+	return jsonScan(value, d)
+}
+
+func jsonValue(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func jsonScan(value interface{}, target interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, target)
+	case string:
+		return json.Unmarshal([]byte(v), target)
+	default:
+		return fmt.Errorf("unsupported jsonb value %T", value)
+	}
+}
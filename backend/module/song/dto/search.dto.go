@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SongSearchHit - результат полнотекстового поиска: метаданные песни,
+// релевантность и фрагмент текста с подсвеченными совпадениями.
+type SongSearchHit struct {
+	ID          uuid.UUID `json:"id"`
+	GroupID     int       `json:"groupid"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	Link        string    `json:"link"`
+	ReleaseDate time.Time `json:"releasedate"`
+	Rank        float64   `json:"rank"`
+	Snippet     string    `json:"snippet"`
+}
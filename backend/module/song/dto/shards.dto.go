@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+// ShardGroupStats - группа с наибольшим числом песен в шарде.
+type ShardGroupStats struct {
+	GroupID int    `json:"group_id"`
+	Group   string `json:"group"`
+	Songs   int64  `json:"songs"`
+}
+
+// ShardStats - состояние одной таблицы songs_N. Rows включает песни в
+// корзине, Share - доля шарда во всех строках. LastWriteAt - время последнего
+// изменения песен шарда по истории изменений, nil для шарда без истории.
+type ShardStats struct {
+	Shard       int               `json:"shard"`
+	Table       string            `json:"table"`
+	Rows        int64             `json:"rows"`
+	LiveRows    int64             `json:"live_rows"`
+	TrashedRows int64             `json:"trashed_rows"`
+	Share       float64           `json:"share"`
+	TableBytes  int64             `json:"table_bytes"`
+	IndexBytes  int64             `json:"index_bytes"`
+	TopGroups   []ShardGroupStats `json:"top_groups"`
+	LastWriteAt *time.Time        `json:"last_write_at"`
+}
+
+// ShardReport - отчёт о распределении песен по шардам. Skew - отношение
+// самого большого шарда к среднему: 1 при равномерном распределении, NumShards,
+// если все строки в одном шарде. Warnings перечисляет шарды, доля которых
+// больше WarnShare.
+type ShardReport struct {
+	Shards      []ShardStats `json:"shards"`
+	TotalRows   int64        `json:"total_rows"`
+	Skew        float64      `json:"skew"`
+	MaxShare    float64      `json:"max_share"`
+	WarnShare   float64      `json:"warn_share"`
+	Warnings    []string     `json:"warnings"`
+	GeneratedAt time.Time    `json:"generated_at"`
+}
@@ -1,26 +1,91 @@
 package dto
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Song - песня. Version увеличивается при каждом изменении и отдаётся как ETag.
+// DeletedAt заполнен у песен в корзине, GORM исключает их из выборок по dto.Song.
 type Song struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	GroupID     int       `json:"groupid" gorm:"not null;index"`
-	Group       string    `json:"group" gorm:"not null;index"`
-	Song        string    `json:"song" gorm:"not null"`
-	Text        string    `json:"text" gorm:"type:text;index"`
-	Link        string    `json:"link" gorm:"type:text;index"`
-	ReleaseDate time.Time `json:"releasedate" gorm:"type:date;index"`
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	GroupID     int            `json:"groupid" gorm:"not null;index"`
+	Group       string         `json:"group" gorm:"not null;index"`
+	Song        string         `json:"song" gorm:"not null"`
+	Text        string         `json:"text" gorm:"type:text"`
+	Link        string         `json:"link" gorm:"type:text"`
+	ReleaseDate time.Time      `json:"releasedate" gorm:"type:date;index"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"`
 }
 
+// ExternalDateLayout - формат releaseDate во внешнем API (dd.mm.yyyy).
+const ExternalDateLayout = "02.01.2006"
+
+// SongDetails - ответ внешнего API с деталями песни.
 type SongDetails struct {
-	Group string `json:"group"`
-	Song  string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Validate проверяет, что ответ внешнего API содержит все поля в ожидаемом формате.
+func (d *SongDetails) Validate() error {
+	if _, err := time.Parse(ExternalDateLayout, strings.TrimSpace(d.ReleaseDate)); err != nil {
+		return fmt.Errorf("%w: releaseDate %q is not in dd.mm.yyyy format", ErrInvalidSongDetails, d.ReleaseDate)
+	}
+
+	if strings.TrimSpace(d.Text) == "" {
+		return fmt.Errorf("%w: text is empty", ErrInvalidSongDetails)
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(d.Link))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: link %q is not an absolute http(s) URL", ErrInvalidSongDetails, d.Link)
+	}
+
+	return nil
+}
+
+// ToSong проверяет ответ внешнего API и собирает из него песню. GroupID
+// заполняет вызывающий после определения группы.
+func (d *SongDetails) ToSong(group, song string) (*Song, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	releaseDate, _ := time.Parse(ExternalDateLayout, strings.TrimSpace(d.ReleaseDate))
+	return &Song{
+		ID:          uuid.New(),
+		Group:       group,
+		Song:        song,
+		Text:        strings.TrimSpace(d.Text),
+		Link:        strings.TrimSpace(d.Link),
+		ReleaseDate: releaseDate,
+		Version:     1,
+	}, nil
+}
+
+// SongKey - уникальный ключ песни вне корзины: группа и название песни без
+// учёта регистра и крайних пробелов.
+type SongKey struct {
+	GroupID int
+	Song    string
+}
+
+func NewSongKey(groupID int, song string) SongKey {
+	return SongKey{GroupID: groupID, Song: strings.ToLower(strings.TrimSpace(song))}
 }
 
 type SongText struct {
-	Text string `json:"text"`
+	ID      uuid.UUID `json:"id"`
+	Group   string    `json:"group"`
+	Song    string    `json:"song"`
+	Text    string    `json:"text"`
+	Version int       `json:"version"`
 }
@@ -0,0 +1,91 @@
+package dto
+
+import (
+	song_lyrics "root/module/song/lyrics"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Источники синхронизированного текста.
+const (
+	// SyncedSourceUploaded - текст LRC загружен через API и хранится построчно.
+	SyncedSourceUploaded = "uploaded"
+	// SyncedSourceText - текст песни сам размечен метками [mm:ss.xx].
+	SyncedSourceText = "text"
+)
+
+// SongSyncedLine - строка загруженного текста LRC. Строки песни нумеруются
+// Position с нуля в порядке времени.
+type SongSyncedLine struct {
+	SongID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Position int       `gorm:"primaryKey;autoIncrement:false"`
+	AtMs     int64     `gorm:"not null"`
+	Text     string    `gorm:"type:text;not null;default:''"`
+}
+
+// SyncedLyricsLine - строка синхронизированного текста в ответе API.
+type SyncedLyricsLine struct {
+	Index int    `json:"index"`
+	AtMs  int64  `json:"at_ms"`
+	Time  string `json:"time"`
+	Text  string `json:"text"`
+}
+
+// SyncedLyrics - синхронизированный текст песни. Text - обычный текст,
+// собранный из строк.
+type SyncedLyrics struct {
+	SongID uuid.UUID          `json:"song_id"`
+	Source string             `json:"source"`
+	Lines  []SyncedLyricsLine `json:"lines"`
+	Text   string             `json:"text"`
+}
+
+// SyncedLyricsAt - строка, звучащая в момент AtMs, и следующая за ней. До
+// первой строки Line пуст, после последней пуст Next.
+type SyncedLyricsAt struct {
+	SongID uuid.UUID         `json:"song_id"`
+	AtMs   int64             `json:"at_ms"`
+	Line   *SyncedLyricsLine `json:"line"`
+	Next   *SyncedLyricsLine `json:"next"`
+}
+
+func NewSyncedLyricsLine(index int, line song_lyrics.SyncedLine) *SyncedLyricsLine {
+	return &SyncedLyricsLine{
+		Index: index,
+		AtMs:  line.At.Milliseconds(),
+		Time:  song_lyrics.FormatTimestamp(line.At),
+		Text:  line.Text,
+	}
+}
+
+// ToSyncedLines переводит сохранённые строки в строки парсера.
+func ToSyncedLines(rows []SongSyncedLine) []song_lyrics.SyncedLine {
+	lines := make([]song_lyrics.SyncedLine, len(rows))
+	for i, row := range rows {
+		lines[i] = song_lyrics.SyncedLine{At: time.Duration(row.AtMs) * time.Millisecond, Text: row.Text}
+	}
+	return lines
+}
+
+// NewSongSyncedLines готовит строки парсера к сохранению.
+func NewSongSyncedLines(songID uuid.UUID, lines []song_lyrics.SyncedLine) []SongSyncedLine {
+	rows := make([]SongSyncedLine, len(lines))
+	for i, line := range lines {
+		rows[i] = SongSyncedLine{SongID: songID, Position: i, AtMs: line.At.Milliseconds(), Text: line.Text}
+	}
+	return rows
+}
+
+func NewSyncedLyrics(songID uuid.UUID, source string, lines []song_lyrics.SyncedLine) *SyncedLyrics {
+	result := &SyncedLyrics{
+		SongID: songID,
+		Source: source,
+		Lines:  make([]SyncedLyricsLine, len(lines)),
+		Text:   song_lyrics.PlainText(lines),
+	}
+	for i, line := range lines {
+		result.Lines[i] = *NewSyncedLyricsLine(i, line)
+	}
+	return result
+}
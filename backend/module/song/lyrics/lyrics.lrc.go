@@ -0,0 +1,173 @@
+package song_lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidLRC    = errors.New("invalid LRC lyrics")
+	ErrInvalidOffset = errors.New("playback offset must be seconds or mm:ss.xx")
+)
+
+// lrcTime - время в формате LRC: mm:ss, mm:ss.xx или mm:ss:xx.
+const lrcTime = `(\d{1,3}):([0-5]?\d)(?:[.:](\d{1,3}))?`
+
+var (
+	// lrcTimestamp - временная метка в начале строки LRC.
+	lrcTimestamp = regexp.MustCompile(`^\[` + lrcTime + `\]`)
+	// playbackTime - позиция воспроизведения в формате LRC без скобок.
+	playbackTime = regexp.MustCompile(`^` + lrcTime + `$`)
+	// lrcTag - метаданные LRC вида [ar:Исполнитель] на отдельной строке.
+	lrcTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	// lrcWordTimestamp - пословные метки расширенного LRC вида <mm:ss.xx>.
+	lrcWordTimestamp = regexp.MustCompile(`<\d{1,3}:\d{1,2}(?:[.:]\d{1,3})?>`)
+)
+
+// SyncedLine - строка текста с моментом начала от начала трека.
+type SyncedLine struct {
+	At   time.Duration
+	Text string
+}
+
+// IsLRC сообщает, содержит ли текст строки с временными метками LRC.
+func IsLRC(text string) bool {
+	for _, line := range splitLines(text) {
+		if lrcTimestamp.MatchString(strings.TrimSpace(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLRC разбирает текст LRC в строки, упорядоченные по времени. Строка с
+// несколькими метками повторяется для каждой из них, тег [offset:мс] сдвигает
+// все метки, остальные метаданные и строки без меток пропускаются. Пустой
+// текст после метки сохраняется: так в LRC отмечают паузы.
+func ParseLRC(text string) ([]SyncedLine, error) {
+	var lines []SyncedLine
+	var offset time.Duration
+
+	for _, raw := range splitLines(text) {
+		line := strings.TrimSpace(raw)
+
+		var stamps []time.Duration
+		for {
+			match := lrcTimestamp.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			stamps = append(stamps, lrcDuration(match[1], match[2], match[3]))
+			line = line[len(match[0]):]
+		}
+
+		if len(stamps) == 0 {
+			if tag := lrcTag.FindStringSubmatch(line); tag != nil && strings.EqualFold(tag[1], "offset") {
+				ms, err := strconv.Atoi(strings.TrimSpace(tag[2]))
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid offset %q", ErrInvalidLRC, tag[2])
+				}
+				offset = time.Duration(ms) * time.Millisecond
+			}
+			continue
+		}
+
+		body := strings.TrimSpace(lrcWordTimestamp.ReplaceAllString(line, ""))
+		for _, at := range stamps {
+			lines = append(lines, SyncedLine{At: at, Text: body})
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no [mm:ss.xx] timed lines", ErrInvalidLRC)
+	}
+
+	// Положительный offset по спецификации LRC показывает строки раньше
+	for i := range lines {
+		lines[i].At = max(lines[i].At-offset, 0)
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].At < lines[j].At })
+
+	return lines, nil
+}
+
+// PlainText собирает обычный текст песни из синхронизированных строк. Паузы
+// становятся пустыми строками и разделяют строфы.
+func PlainText(lines []SyncedLine) string {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text
+	}
+	return collapseBlankLines(strings.Join(texts, "\n"))
+}
+
+// LineAt возвращает индекс строки, звучащей в момент at: последней строки,
+// начавшейся не позже at. До первой строки возвращает -1.
+func LineAt(lines []SyncedLine, at time.Duration) int {
+	return sort.Search(len(lines), func(i int) bool { return lines[i].At > at }) - 1
+}
+
+// FormatTimestamp форматирует момент как метку LRC mm:ss.xx.
+func FormatTimestamp(at time.Duration) string {
+	centis := at.Milliseconds() / 10
+	return fmt.Sprintf("%02d:%02d.%02d", centis/6000, centis/100%60, centis%100)
+}
+
+// ParseOffset разбирает позицию воспроизведения: секунды ("83.5") или
+// метку LRC ("01:23.50").
+func ParseOffset(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if match := playbackTime.FindStringSubmatch(value); match != nil {
+		return lrcDuration(match[1], match[2], match[3]), nil
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	// Сравнение в такой форме отсекает и NaN
+	if err != nil || !(seconds >= 0 && seconds <= maxOffsetSeconds) {
+		return 0, ErrInvalidOffset
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// maxOffsetSeconds ограничивает позицию воспроизведения сутками.
+const maxOffsetSeconds = 24 * 60 * 60
+
+// lrcDuration переводит части метки в длительность. Дробная часть из двух цифр -
+// сотые, из трёх - тысячные секунды.
+func lrcDuration(minutes, seconds, fraction string) time.Duration {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	at := time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+
+	if fraction != "" {
+		f, _ := strconv.Atoi(fraction)
+		for i := len(fraction); i < 3; i++ {
+			f *= 10
+		}
+		at += time.Duration(f) * time.Millisecond
+	}
+	return at
+}
+
+// collapseBlankLines убирает пустые строки по краям и схлопывает подряд идущие.
+func collapseBlankLines(text string) string {
+	var out []string
+	blank := false
+	for _, line := range splitLines(strings.TrimSpace(text)) {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && len(out) > 0 {
+			out = append(out, "")
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,63 @@
+package song_lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLRC(t *testing.T) {
+	text := "[ar:Artist]\n[offset:+500]\n[00:12.00]<00:12.00>First <00:12.50>line\n[00:20.5][01:05.250]Chorus\n[00:30.00]\nplain line without tag\n[00:31.00]After break"
+
+	lines, err := ParseLRC(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []SyncedLine{
+		{At: 11500 * time.Millisecond, Text: "First line"},
+		{At: 20 * time.Second, Text: "Chorus"},
+		{At: 29500 * time.Millisecond, Text: ""},
+		{At: 30500 * time.Millisecond, Text: "After break"},
+		{At: 64750 * time.Millisecond, Text: "Chorus"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("lines = %+v\nwant %+v", lines, want)
+	}
+
+	if got := PlainText(lines); got != "First line\nChorus\n\nAfter break\nChorus" {
+		t.Errorf("plain text = %q", got)
+	}
+
+	for at, index := range map[time.Duration]int{0: -1, 11500 * time.Millisecond: 0, 25 * time.Second: 1, time.Hour: 4} {
+		if got := LineAt(lines, at); got != index {
+			t.Errorf("LineAt(%v) = %d, want %d", at, got, index)
+		}
+	}
+
+	if _, err := ParseLRC("no timestamps here"); !errors.Is(err, ErrInvalidLRC) {
+		t.Errorf("err = %v, want ErrInvalidLRC", err)
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	valid := map[string]time.Duration{
+		"83.5":      83500 * time.Millisecond,
+		"0":         0,
+		"01:23.50":  83500 * time.Millisecond,
+		"1:02":      62 * time.Second,
+		"00:01.005": 1005 * time.Millisecond,
+	}
+	for value, want := range valid {
+		if got, err := ParseOffset(value); err != nil || got != want {
+			t.Errorf("ParseOffset(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "-1", "NaN", "abc", "1:75"} {
+		if _, err := ParseOffset(value); !errors.Is(err, ErrInvalidOffset) {
+			t.Errorf("ParseOffset(%q) err = %v, want ErrInvalidOffset", value, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package song_lyrics
+
+import (
+	"strings"
+)
+
+// SectionKind - тип секции текста песни.
+type SectionKind string
+
+const (
+	KindVerse      SectionKind = "verse"
+	KindPreChorus  SectionKind = "pre-chorus"
+	KindChorus     SectionKind = "chorus"
+	KindPostChorus SectionKind = "post-chorus"
+	KindBridge     SectionKind = "bridge"
+	KindHook       SectionKind = "hook"
+	KindIntro      SectionKind = "intro"
+	KindOutro      SectionKind = "outro"
+	KindInterlude  SectionKind = "interlude"
+	// KindStanza - строфа без метки: текст без маркеров или перед первым маркером.
+	KindStanza SectionKind = "stanza"
+	// KindOther - секция с меткой, тип которой не распознан.
+	KindOther SectionKind = "other"
+)
+
+// Section - секция текста песни. Ordinal - номер секции своего типа: из метки
+// ("Куплет 2", "[Verse 2]"), а если номера нет - порядковый номер среди
+// секций того же типа, начиная с 1. Label - исходная строка-маркер без
+// изменений, у строф без метки пустая.
+type Section struct {
+	Kind    SectionKind `json:"kind"`
+	Ordinal int         `json:"ordinal"`
+	Label   string      `json:"label,omitempty"`
+	Body    string      `json:"body"`
+}
+
+// Parser делит текст песни на секции по одному соглашению о разметке.
+type Parser interface {
+	// Name - имя соглашения для логов.
+	Name() string
+	// Detect сообщает, размечен ли текст по этому соглашению.
+	Detect(text string) bool
+	// Parse возвращает непустые секции в порядке следования.
+	Parse(text string) []Section
+}
+
+// AutoParser выбирает первый парсер, распознавший разметку текста.
+type AutoParser struct {
+	parsers []Parser
+}
+
+var _ Parser = (*AutoParser)(nil)
+
+// NewAutoParser возвращает парсер с автоопределением. Без аргументов
+// используются русские маркеры, английские метки в квадратных скобках и,
+// если разметки нет, деление на строфы по пустым строкам.
+func NewAutoParser(parsers ...Parser) *AutoParser {
+	if len(parsers) == 0 {
+		parsers = []Parser{NewRussianParser(), NewBracketParser(), NewStanzaParser()}
+	}
+	return &AutoParser{parsers: parsers}
+}
+
+func (a *AutoParser) Name() string {
+	return "auto"
+}
+
+func (a *AutoParser) Detect(text string) bool {
+	return a.Choose(text) != nil
+}
+
+// Choose возвращает парсер, который будет использован для text, или nil.
+func (a *AutoParser) Choose(text string) Parser {
+	for _, parser := range a.parsers {
+		if parser.Detect(text) {
+			return parser
+		}
+	}
+	return nil
+}
+
+func (a *AutoParser) Parse(text string) []Section {
+	parser := a.Choose(text)
+	if parser == nil {
+		return []Section{}
+	}
+	return parser.Parse(text)
+}
+
+// Bodies возвращает тексты секций без меток.
+func Bodies(sections []Section) []string {
+	bodies := make([]string, len(sections))
+	for i, section := range sections {
+		bodies[i] = section.Body
+	}
+	return bodies
+}
+
+// StanzaParser делит текст на строфы по пустым строкам.
+type StanzaParser struct{}
+
+var _ Parser = StanzaParser{}
+
+func NewStanzaParser() StanzaParser {
+	return StanzaParser{}
+}
+
+func (StanzaParser) Name() string {
+	return "stanza"
+}
+
+// Detect подходит любому непустому тексту, поэтому StanzaParser ставится последним.
+func (StanzaParser) Detect(text string) bool {
+	return strings.TrimSpace(text) != ""
+}
+
+func (StanzaParser) Parse(text string) []Section {
+	sections := []Section{}
+	var lines []string
+
+	flush := func() {
+		if body := joinBody(lines); body != "" {
+			sections = append(sections, Section{Kind: KindStanza, Ordinal: len(sections) + 1, Body: body})
+		}
+		lines = lines[:0]
+	}
+
+	for _, line := range splitLines(text) {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	return sections
+}
+
+// splitLines делит текст на строки, принимая и CRLF.
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// joinBody собирает тело секции, отбрасывая пустые строки по краям.
+func joinBody(lines []string) string {
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
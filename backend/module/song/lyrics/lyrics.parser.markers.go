@@ -0,0 +1,152 @@
+package song_lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// russianKinds - русские названия секций.
+var russianKinds = map[string]SectionKind{
+	"куплет":      KindVerse,
+	"предприпев":  KindPreChorus,
+	"пред-припев": KindPreChorus,
+	"припев":      KindChorus,
+	"бридж":       KindBridge,
+	"переход":     KindBridge,
+	"вступление":  KindIntro,
+	"интро":       KindIntro,
+	"концовка":    KindOutro,
+	"кода":        KindOutro,
+	"аутро":       KindOutro,
+	"проигрыш":    KindInterlude,
+}
+
+// englishKinds - названия секций в метках вида [Verse 1].
+var englishKinds = map[string]SectionKind{
+	"verse":        KindVerse,
+	"pre-chorus":   KindPreChorus,
+	"pre chorus":   KindPreChorus,
+	"prechorus":    KindPreChorus,
+	"chorus":       KindChorus,
+	"refrain":      KindChorus,
+	"post-chorus":  KindPostChorus,
+	"post chorus":  KindPostChorus,
+	"bridge":       KindBridge,
+	"hook":         KindHook,
+	"intro":        KindIntro,
+	"outro":        KindOutro,
+	"interlude":    KindInterlude,
+	"instrumental": KindInterlude,
+}
+
+// MarkerParser делит текст по строкам-маркерам секций. Текст до первого
+// маркера становится строфой без метки.
+type MarkerParser struct {
+	name string
+	// pattern распознаёт строку-маркер: первая группа - название секции,
+	// вторая - необязательный номер.
+	pattern *regexp.Regexp
+	kinds   map[string]SectionKind
+	// strict отвергает маркеры с неизвестным названием; иначе они дают KindOther.
+	strict bool
+}
+
+var _ Parser = (*MarkerParser)(nil)
+
+// NewRussianParser распознаёт маркеры "Куплет 1", "Припев", "Бридж" и т.п.
+// на отдельной строке, в том числе в квадратных скобках и с двоеточием.
+func NewRussianParser() *MarkerParser {
+	return &MarkerParser{
+		name:    "russian",
+		pattern: regexp.MustCompile(`^\[?\s*([\p{Cyrillic}-]+)\s*(\d+)?\s*\]?\s*:?$`),
+		kinds:   russianKinds,
+		strict:  true,
+	}
+}
+
+// NewBracketParser распознаёт метки в квадратных скобках на отдельной строке:
+// [Verse 1], [Chorus], [Bridge], [Outro], в том числе с исполнителем после
+// двоеточия, как [Verse 2: Artist]. Временные метки LRC вида [00:12.34]
+// маркерами не считаются.
+func NewBracketParser() *MarkerParser {
+	return &MarkerParser{
+		name:    "bracket",
+		pattern: regexp.MustCompile(`^\[\s*([^\]\d:][^\]\d:]*?)\s*(\d+)?\s*(?::[^\]]*)?\]$`),
+		kinds:   englishKinds,
+	}
+}
+
+func (p *MarkerParser) Name() string {
+	return p.name
+}
+
+func (p *MarkerParser) Detect(text string) bool {
+	for _, line := range splitLines(text) {
+		if _, _, ok := p.marker(line); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *MarkerParser) Parse(text string) []Section {
+	sections := []Section{}
+	counts := make(map[SectionKind]int)
+
+	current := Section{Kind: KindStanza}
+	var lines []string
+
+	flush := func() {
+		current.Body = joinBody(lines)
+		lines = lines[:0]
+		if current.Body == "" {
+			return
+		}
+		if current.Ordinal == 0 {
+			counts[current.Kind]++
+			current.Ordinal = counts[current.Kind]
+		}
+		sections = append(sections, current)
+	}
+
+	for _, line := range splitLines(text) {
+		kind, ordinal, ok := p.marker(line)
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+
+		flush()
+		if ordinal > 0 {
+			counts[kind] = ordinal
+		}
+		current = Section{Kind: kind, Ordinal: ordinal, Label: strings.TrimSpace(line)}
+	}
+	flush()
+
+	return sections
+}
+
+// marker проверяет, является ли строка маркером, и возвращает тип секции и
+// номер из метки (0, если номера нет).
+func (p *MarkerParser) marker(line string) (SectionKind, int, bool) {
+	match := p.pattern.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return "", 0, false
+	}
+
+	kind, ok := p.kinds[strings.ToLower(strings.TrimSpace(match[1]))]
+	if !ok {
+		if p.strict {
+			return "", 0, false
+		}
+		kind = KindOther
+	}
+
+	ordinal := 0
+	if match[2] != "" {
+		ordinal, _ = strconv.Atoi(match[2])
+	}
+	return kind, ordinal, true
+}
@@ -0,0 +1,79 @@
+package song_lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAutoParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		parser string
+		want   []Section
+	}{
+		{
+			name:   "russian markers",
+			text:   "Куплет 1\nПервая строка\nВторая строка\n\nПрипев\nЛа-ла-ла\n\nКуплет 2:\nТретья строка\n[Припев]\nЛа-ла-ла",
+			parser: "russian",
+			want: []Section{
+				{Kind: KindVerse, Ordinal: 1, Label: "Куплет 1", Body: "Первая строка\nВторая строка"},
+				{Kind: KindChorus, Ordinal: 1, Label: "Припев", Body: "Ла-ла-ла"},
+				{Kind: KindVerse, Ordinal: 2, Label: "Куплет 2:", Body: "Третья строка"},
+				{Kind: KindChorus, Ordinal: 2, Label: "[Припев]", Body: "Ла-ла-ла"},
+			},
+		},
+		{
+			name:   "english brackets",
+			text:   "Intro line\r\n[Verse 1: Artist]\r\nHello\r\n[Pre-Chorus]\r\nRising\r\n[Chorus]\r\nOh oh\r\n[Chorus]\r\n[Bridge]\r\nQuiet\r\n[Guitar Solo]\r\nNa\r\n[Outro]\r\nBye",
+			parser: "bracket",
+			want: []Section{
+				{Kind: KindStanza, Ordinal: 1, Body: "Intro line"},
+				{Kind: KindVerse, Ordinal: 1, Label: "[Verse 1: Artist]", Body: "Hello"},
+				{Kind: KindPreChorus, Ordinal: 1, Label: "[Pre-Chorus]", Body: "Rising"},
+				{Kind: KindChorus, Ordinal: 1, Label: "[Chorus]", Body: "Oh oh"},
+				{Kind: KindBridge, Ordinal: 1, Label: "[Bridge]", Body: "Quiet"},
+				{Kind: KindOther, Ordinal: 1, Label: "[Guitar Solo]", Body: "Na"},
+				{Kind: KindOutro, Ordinal: 1, Label: "[Outro]", Body: "Bye"},
+			},
+		},
+		{
+			name:   "blank line stanzas",
+			text:   "\nfirst\nstanza\n\n\n  \nsecond stanza\n",
+			parser: "stanza",
+			want: []Section{
+				{Kind: KindStanza, Ordinal: 1, Body: "first\nstanza"},
+				{Kind: KindStanza, Ordinal: 2, Body: "second stanza"},
+			},
+		},
+		{
+			name:   "lrc timestamps are not markers",
+			text:   "[00:12.30]first line\n[00:15.00]second line",
+			parser: "stanza",
+			want: []Section{
+				{Kind: KindStanza, Ordinal: 1, Body: "[00:12.30]first line\n[00:15.00]second line"},
+			},
+		},
+		{
+			name: "empty text",
+			text: " \n ",
+			want: []Section{},
+		},
+	}
+
+	auto := NewAutoParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := ""
+			if chosen := auto.Choose(tt.text); chosen != nil {
+				parser = chosen.Name()
+			}
+			if parser != tt.parser {
+				t.Errorf("parser = %q, want %q", parser, tt.parser)
+			}
+			if got := auto.Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sections = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
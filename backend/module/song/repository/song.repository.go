@@ -9,27 +9,36 @@ import (
 	"gorm.io/gorm"
 )
 
+// CheckTable возвращает ID группы groupName и создаёт группу, если её нет.
+// Параллельные вызовы не создают дубликатов: вставку того же названия
+// пропускает уникальный индекс groups_name_key, и ID перечитывается.
 func (r *SongRepository) CheckTable(ctx context.Context, groupName string) (int, error) {
+	db := r.db.WithContext(ctx)
+
 	// Проверяем, существует ли запись с таким именем группы
 	existingGroup := new(dto.Group)
-	result := r.db.WithContext(ctx).Where("name = ?", groupName).First(existingGroup)
-
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			// Если запись не найдена, создаем новую
-			newGroup := dto.Group{
-				Name: groupName, // Используем переданное имя группы
-			}
-			if err := r.db.WithContext(ctx).Create(&newGroup).Error; err != nil {
-				return 0, fmt.Errorf("не удалось создать запись: %w", err)
-			}
-			// Возвращаем ID созданной записи
-			return int(newGroup.ID), nil
-		}
-		// Если произошла другая ошибка, возвращаем её
+	result := db.Where("name = ?", groupName).Take(existingGroup)
+	if result.Error == nil {
+		return existingGroup.ID, nil
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return 0, fmt.Errorf("ошибка при поиске записи: %w", result.Error)
 	}
 
-	// Если запись найдена, возвращаем её ID
-	return int(existingGroup.ID), nil
-}
\ No newline at end of file
+	// Если запись не найдена, создаем новую. RETURNING ничего не вернёт, если
+	// группу успел создать параллельный запрос.
+	var ids []int
+	err := db.Raw("INSERT INTO groups (name) VALUES (?) ON CONFLICT (name) DO NOTHING RETURNING id", groupName).
+		Scan(&ids).Error
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать запись: %w", err)
+	}
+	if len(ids) > 0 {
+		return ids[0], nil
+	}
+
+	if err := db.Where("name = ?", groupName).Take(existingGroup).Error; err != nil {
+		return 0, fmt.Errorf("ошибка при поиске записи: %w", err)
+	}
+	return existingGroup.ID, nil
+}
@@ -0,0 +1,20 @@
+package song_repository
+
+import (
+	"context"
+	"fmt"
+	"root/module/song/dto"
+)
+
+// FindGroupID возвращает ID группы с точно таким названием, как в CheckTable,
+// или 0, если группы нет. В отличие от CheckTable группа не создаётся.
+func (r *SongRepository) FindGroupID(ctx context.Context, groupName string) (int, error) {
+	var ids []int
+	if err := r.reader(ctx).Model(&dto.Group{}).Where("name = ?", groupName).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("ошибка при поиске группы: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return ids[0], nil
+}
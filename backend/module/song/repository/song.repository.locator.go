@@ -0,0 +1,57 @@
+package song_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"root/database"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LocateSong возвращает таблицу шарда, в которой хранится песня. Сначала
+// используется индекс song_locations; песни, созданные до появления индекса,
+// ищутся по всем шардам и сразу добавляются в индекс.
+func (r *SongRepository) LocateSong(ctx context.Context, songID uuid.UUID) (string, error) {
+	location := new(dto.SongLocation)
+	err := r.reader(ctx).Where("song_id = ?", songID).Take(location).Error
+	if err == nil {
+		return database.GroupShardTable(location.GroupID), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("ошибка при поиске шарда песни: %w", err)
+	}
+
+	r.logger.Warnf("LocateSong: song %s is missing in location index, scanning shards", songID)
+	tables := database.ShardTables()
+	found, err := database.FanOut(ctx, r.config.ShardFanOutLimit, tables, func(ctx context.Context, tableName string) ([]int, error) {
+		var groupIDs []int
+		if err := r.reader(ctx).Table(tableName).Where("id = ?", songID).Limit(1).Pluck("group_id", &groupIDs).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при поиске песни в %s: %w", tableName, err)
+		}
+		return groupIDs, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, groupIDs := range found {
+		if len(groupIDs) == 0 {
+			continue
+		}
+		if err := r.saveSongLocation(r.db.WithContext(ctx), songID, groupIDs[0]); err != nil {
+			r.logger.Warnf("LocateSong: failed to backfill location for song %s: %v", songID, err)
+		}
+		return tables[i], nil
+	}
+
+	return "", dto.ErrSongNotFound
+}
+
+// saveSongLocation записывает или обновляет группу песни в индексе в рамках tx.
+func (r *SongRepository) saveSongLocation(tx *gorm.DB, songID uuid.UUID, groupID int) error {
+	return tx.Exec(`INSERT INTO song_locations (song_id, group_id) VALUES (?, ?)
+		ON CONFLICT (song_id) DO UPDATE SET group_id = EXCLUDED.group_id`, songID, groupID).Error
+}
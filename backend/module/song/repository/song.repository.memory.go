@@ -0,0 +1,472 @@
+package song_repository
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"root/database"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MemoryRepository - ISongRepository в памяти процесса для тестов сервиса и
+// контроллеров без Postgres. Методы безопасны для конкурентного вызова и
+// повторяют поведение SongRepository: порядок выборок, корзину, ревизии и
+// уникальность названий. Шарды учитываются только в статистике, полнотекстовый
+// поиск сравнивает слова целиком без стемминга.
+type MemoryRepository struct {
+	mu sync.RWMutex
+	// groups - группы по порядку создания, ID группы - индекс плюс один.
+	groups    []dto.Group
+	songs     map[uuid.UUID]dto.Song
+	revisions map[uuid.UUID][]dto.SongRevision
+	synced    map[uuid.UUID][]dto.SongSyncedLine
+
+	revisionID int64
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		songs:     map[uuid.UUID]dto.Song{},
+		revisions: map[uuid.UUID][]dto.SongRevision{},
+		synced:    map[uuid.UUID][]dto.SongSyncedLine{},
+	}
+}
+
+func (r *MemoryRepository) CheckTable(ctx context.Context, groupName string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id := r.groupID(groupName); id != 0 {
+		return id, nil
+	}
+	r.groups = append(r.groups, dto.Group{ID: len(r.groups) + 1, Name: groupName})
+	return len(r.groups), nil
+}
+
+func (r *MemoryRepository) FindGroupID(ctx context.Context, groupName string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.groupID(groupName), nil
+}
+
+func (r *MemoryRepository) groupID(groupName string) int {
+	for _, group := range r.groups {
+		if group.Name == groupName {
+			return group.ID
+		}
+	}
+	return 0
+}
+
+func (r *MemoryRepository) ListSongs(ctx context.Context, filter *dto.SongFilter, after *dto.SongCursor, limit int) ([]dto.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := r.filterSongs(filter, after)
+	return songs[:min(limit, len(songs))], nil
+}
+
+// ExportSongs передаёт write снимок песен под filter, сделанный до первого
+// вызова write, поэтому write может обращаться к репозиторию.
+func (r *MemoryRepository) ExportSongs(ctx context.Context, filter *dto.SongFilter, write func(song *dto.Song) error) error {
+	r.mu.RLock()
+	songs := r.filterSongs(filter, nil)
+	r.mu.RUnlock()
+
+	for i := range songs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := write(&songs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterSongs возвращает песни вне корзины под filter после after в порядке filter.Sort.
+func (r *MemoryRepository) filterSongs(filter *dto.SongFilter, after *dto.SongCursor) []dto.Song {
+	less := songLess(filter.Sort)
+	var pivot *dto.Song
+	if after != nil {
+		pivot = cursorSong(after)
+	}
+
+	songs := []dto.Song{}
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid || !matchSong(&song, filter) {
+			continue
+		}
+		if pivot != nil && !less(pivot, &song) {
+			continue
+		}
+		songs = append(songs, song)
+	}
+	sortSongs(songs, less)
+	return songs
+}
+
+func (r *MemoryRepository) GetSong(ctx context.Context, songID uuid.UUID) (*dto.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.songs[songID]
+	if !ok || song.DeletedAt.Valid {
+		return nil, dto.ErrSongNotFound
+	}
+	return &song, nil
+}
+
+func (r *MemoryRepository) FindSong(ctx context.Context, groupID int, title string) (*dto.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id, ok := r.liveSong(dto.NewSongKey(groupID, title)); ok {
+		song := r.songs[id]
+		return &song, nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryRepository) ExistingSongs(ctx context.Context, keys []dto.SongKey) (map[dto.SongKey]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found := map[dto.SongKey]bool{}
+	for _, key := range keys {
+		if _, ok := r.liveSong(key); ok {
+			found[key] = true
+		}
+	}
+	return found, nil
+}
+
+// liveSong возвращает id песни вне корзины с ключом key.
+func (r *MemoryRepository) liveSong(key dto.SongKey) (uuid.UUID, bool) {
+	for id, song := range r.songs {
+		if !song.DeletedAt.Valid && dto.NewSongKey(song.GroupID, song.Song) == key {
+			return id, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// titleTaken сообщает, занят ли ключ song другой песней вне корзины.
+func (r *MemoryRepository) titleTaken(song *dto.Song) bool {
+	id, ok := r.liveSong(dto.NewSongKey(song.GroupID, song.Song))
+	return ok && id != song.ID
+}
+
+func (r *MemoryRepository) CreateSongs(ctx context.Context, songs []*dto.Song, actor string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := map[dto.SongKey]bool{}
+	for _, song := range songs {
+		key := dto.NewSongKey(song.GroupID, song.Song)
+		if keys[key] || r.titleTaken(song) {
+			return dto.ErrSongExists
+		}
+		keys[key] = true
+	}
+
+	for _, song := range songs {
+		r.songs[song.ID] = *song
+		r.saveRevision(song, dto.NewSongSnapshot(song).Diff(nil), dto.RevisionCreate, actor)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpdateSong(ctx context.Context, songID uuid.UUID, patch *dto.SongPatch, ifMatch *dto.IfMatch, action dto.RevisionAction, actor string) (*dto.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok || song.DeletedAt.Valid {
+		return nil, dto.ErrSongNotFound
+	}
+
+	before := dto.NewSongSnapshot(&song)
+	patch.Apply(&song)
+	song.Version++
+
+	if patch.GroupID != nil && *patch.GroupID != song.GroupID {
+		if *patch.GroupID < 1 || *patch.GroupID > len(r.groups) {
+			return nil, &dto.PatchError{Field: "group_id", Reason: "group does not exist"}
+		}
+		group := r.groups[*patch.GroupID-1]
+		song.GroupID = group.ID
+		song.Group = group.Name
+	}
+	if !versionMatches(ifMatch, song.Version-1) {
+		return nil, dto.ErrVersionMismatch
+	}
+	if r.titleTaken(&song) {
+		return nil, dto.ErrSongExists
+	}
+
+	r.songs[songID] = song
+	r.saveRevision(&song, dto.NewSongSnapshot(&song).Diff(&before), action, actor)
+	return &song, nil
+}
+
+func (r *MemoryRepository) DeleteSong(ctx context.Context, songID uuid.UUID, ifMatch *dto.IfMatch, actor string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok || song.DeletedAt.Valid {
+		return dto.ErrSongNotFound
+	}
+	if !versionMatches(ifMatch, song.Version) {
+		return dto.ErrVersionMismatch
+	}
+
+	song.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	song.Version++
+	r.songs[songID] = song
+	r.saveRevision(&song, dto.RevisionDiff{}, dto.RevisionDelete, actor)
+	return nil
+}
+
+func (r *MemoryRepository) ListTrash(ctx context.Context, limit int) ([]dto.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := []dto.Song{}
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid {
+			songs = append(songs, song)
+		}
+	}
+	sortSongs(songs, trashLess)
+	return songs[:min(limit, len(songs))], nil
+}
+
+func (r *MemoryRepository) RestoreSong(ctx context.Context, songID uuid.UUID, actor string) (*dto.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok {
+		return nil, dto.ErrSongNotFound
+	}
+	if !song.DeletedAt.Valid {
+		return nil, dto.ErrSongNotDeleted
+	}
+	if r.titleTaken(&song) {
+		return nil, dto.ErrSongExists
+	}
+
+	song.DeletedAt = gorm.DeletedAt{}
+	song.Version++
+	r.songs[songID] = song
+	r.saveRevision(&song, dto.RevisionDiff{}, dto.RevisionRestore, actor)
+	return &song, nil
+}
+
+func (r *MemoryRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, song := range r.songs {
+		if song.DeletedAt.Valid && song.DeletedAt.Time.Before(before) {
+			delete(r.songs, id)
+			delete(r.synced, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryRepository) GetShardStats(ctx context.Context, top int) ([]dto.ShardStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tables := database.ShardTables()
+	shards := make([]dto.ShardStats, len(tables))
+	groups := make([]map[int]*dto.ShardGroupStats, len(tables))
+	for i, tableName := range tables {
+		shards[i] = dto.ShardStats{Shard: i, Table: tableName, TopGroups: []dto.ShardGroupStats{}}
+		groups[i] = map[int]*dto.ShardGroupStats{}
+	}
+
+	for id, song := range r.songs {
+		i := database.ShardIndex(song.GroupID)
+		stats := &shards[i]
+		stats.Rows++
+		if song.DeletedAt.Valid {
+			stats.TrashedRows++
+		} else {
+			stats.LiveRows++
+			group, ok := groups[i][song.GroupID]
+			if !ok {
+				group = &dto.ShardGroupStats{GroupID: song.GroupID, Group: song.Group}
+				groups[i][song.GroupID] = group
+			}
+			group.Songs++
+		}
+		for _, revision := range r.revisions[id] {
+			if stats.LastWriteAt == nil || revision.CreatedAt.After(*stats.LastWriteAt) {
+				createdAt := revision.CreatedAt
+				stats.LastWriteAt = &createdAt
+			}
+		}
+	}
+
+	for i := range shards {
+		for _, group := range groups[i] {
+			shards[i].TopGroups = append(shards[i].TopGroups, *group)
+		}
+		slices.SortFunc(shards[i].TopGroups, func(a, b dto.ShardGroupStats) int {
+			if a.Songs != b.Songs {
+				return int(b.Songs - a.Songs)
+			}
+			return a.GroupID - b.GroupID
+		})
+		shards[i].TopGroups = shards[i].TopGroups[:min(top, len(shards[i].TopGroups))]
+	}
+	return shards, nil
+}
+
+func (r *MemoryRepository) GetSongRevisions(ctx context.Context, songID uuid.UUID, offset, limit int) ([]dto.SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.revisions[songID]
+	revisions := []dto.SongRevision{}
+	for i := len(history) - 1 - offset; i >= 0 && len(revisions) < limit; i-- {
+		revisions = append(revisions, history[i])
+	}
+	return revisions, nil
+}
+
+func (r *MemoryRepository) GetSongRevision(ctx context.Context, songID uuid.UUID, version int) (*dto.SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision := range r.revisions[songID] {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, dto.ErrRevisionNotFound
+}
+
+// saveRevision добавляет в историю состояние песни после изменения.
+func (r *MemoryRepository) saveRevision(song *dto.Song, diff dto.RevisionDiff, action dto.RevisionAction, actor string) {
+	r.revisionID++
+	revision := dto.NewSongRevision(song, diff, action, actor)
+	revision.ID = r.revisionID
+	revision.CreatedAt = time.Now()
+	r.revisions[song.ID] = append(r.revisions[song.ID], revision)
+}
+
+func (r *MemoryRepository) GetSyncedLines(ctx context.Context, songID uuid.UUID) ([]dto.SongSyncedLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]dto.SongSyncedLine{}, r.synced[songID]...), nil
+}
+
+func (r *MemoryRepository) ReplaceSyncedLines(ctx context.Context, songID uuid.UUID, lines []dto.SongSyncedLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if song, ok := r.songs[songID]; !ok || song.DeletedAt.Valid {
+		return dto.ErrSongNotFound
+	}
+	lines = slices.Clone(lines)
+	slices.SortFunc(lines, func(a, b dto.SongSyncedLine) int { return a.Position - b.Position })
+	r.synced[songID] = lines
+	return nil
+}
+
+func (r *MemoryRepository) DeleteSyncedLines(ctx context.Context, songID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := int64(len(r.synced[songID]))
+	delete(r.synced, songID)
+	return deleted, nil
+}
+
+// versionMatches проверяет версию песни по условию If-Match.
+func versionMatches(ifMatch *dto.IfMatch, version int) bool {
+	return ifMatch == nil || slices.Contains(ifMatch.Versions, version)
+}
+
+// matchSong проверяет песню по фильтру так же, как applySongFilter: точное
+// совпадение учитывает регистр, префикс и подстрока, как ILIKE, - нет.
+func matchSong(song *dto.Song, filter *dto.SongFilter) bool {
+	if !matchText(song.Group, filter.Group) || !matchText(song.Song, filter.Song) ||
+		!matchText(song.Text, filter.Text) || !matchText(song.Link, filter.Link) {
+		return false
+	}
+	if filter.GroupID != nil && song.GroupID != *filter.GroupID {
+		return false
+	}
+	if filter.ReleaseDate != nil && !song.ReleaseDate.Equal(*filter.ReleaseDate) {
+		return false
+	}
+	if filter.ReleaseDateFrom != nil && song.ReleaseDate.Before(*filter.ReleaseDateFrom) {
+		return false
+	}
+	if filter.ReleaseDateTo != nil && song.ReleaseDate.After(*filter.ReleaseDateTo) {
+		return false
+	}
+	return true
+}
+
+func matchText(value string, filter *dto.TextFilter) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.Mode == dto.MatchExact {
+		return value == filter.Value
+	}
+	value, pattern := strings.ToLower(value), strings.ToLower(filter.Value)
+	switch filter.Mode {
+	case dto.MatchPrefix:
+		return strings.HasPrefix(value, pattern)
+	default:
+		return strings.Contains(value, pattern)
+	}
+}
+
+// cursorSong восстанавливает из курсора песню с теми же значениями полей
+// сортировки, после которой продолжается выборка.
+func cursorSong(after *dto.SongCursor) *dto.Song {
+	song := &dto.Song{ID: after.ID}
+	switch after.Sort {
+	case dto.SortByGroup:
+		song.Group = after.Value
+	case dto.SortBySong:
+		song.Song = after.Value
+	case dto.SortByReleaseDate:
+		song.ReleaseDate, _ = time.Parse(dto.DateLayout, after.Value)
+	}
+	return song
+}
+
+func sortSongs(songs []dto.Song, less func(a, b *dto.Song) bool) {
+	slices.SortFunc(songs, func(a, b dto.Song) int {
+		switch {
+		case less(&a, &b):
+			return -1
+		case less(&b, &a):
+			return 1
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+}
@@ -0,0 +1,258 @@
+package song_repository
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"root/module/song/dto"
+)
+
+// Веса совпадения в названии и в тексте, как у весов A и B в ts_rank.
+const (
+	titleWeight = 1.0
+	textWeight  = 0.4
+)
+
+// maxSnippetFragments - число строк текста с совпадениями во фрагменте, как
+// MaxFragments в ts_headline.
+const maxSnippetFragments = 3
+
+// searchTerm - слово запроса. Слово с минусом исключает песню.
+type searchTerm struct {
+	word    string
+	exclude bool
+}
+
+// parseSearchQuery разбирает запрос в духе websearch_to_tsquery: слова через
+// пробел должны встретиться все, or разделяет альтернативы, слово с минусом
+// исключает песню. Кавычки не задают порядок слов.
+func parseSearchQuery(query string) [][]searchTerm {
+	alternatives := [][]searchTerm{}
+	var terms []searchTerm
+	for _, field := range strings.Fields(query) {
+		if strings.EqualFold(field, "or") {
+			if len(terms) > 0 {
+				alternatives = append(alternatives, terms)
+			}
+			terms = nil
+			continue
+		}
+		exclude := strings.HasPrefix(field, "-")
+		for _, word := range searchWords(field) {
+			terms = append(terms, searchTerm{word: word, exclude: exclude})
+		}
+	}
+	if len(terms) > 0 {
+		alternatives = append(alternatives, terms)
+	}
+	return alternatives
+}
+
+// searchWords разбивает строку на слова из букв и цифр в нижнем регистре.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wordSet(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range searchWords(s) {
+		set[word] = true
+	}
+	return set
+}
+
+// rankSong возвращает релевантность песни лучшей подходящей альтернативе
+// запроса и слова этой альтернативы, или 0, если песня не подходит.
+func rankSong(song *dto.Song, alternatives [][]searchTerm) (float64, map[string]bool) {
+	title, text := wordSet(song.Song), wordSet(song.Text)
+
+	var best float64
+	var matched map[string]bool
+	for _, terms := range alternatives {
+		var weight float64
+		words := map[string]bool{}
+		ok := true
+		for _, term := range terms {
+			found := title[term.word] || text[term.word]
+			if found == term.exclude {
+				ok = false
+				break
+			}
+			if term.exclude {
+				continue
+			}
+			words[term.word] = true
+			if title[term.word] {
+				weight += titleWeight
+			}
+			if text[term.word] {
+				weight += textWeight
+			}
+		}
+		if !ok || len(words) == 0 {
+			continue
+		}
+		if rank := weight / float64(len(words)) / (titleWeight + textWeight); rank > best {
+			best, matched = rank, words
+		}
+	}
+	return best, matched
+}
+
+// snippet собирает фрагмент текста из строк с совпадениями и выделяет слова
+// тегами <b>, как ts_headline. Без совпадений в тексте возвращается первая строка.
+func snippet(text string, words map[string]bool) string {
+	lines := strings.Split(text, "\n")
+	fragments := []string{}
+	for _, line := range lines {
+		if marked, ok := highlight(line, words); ok {
+			fragments = append(fragments, strings.TrimSpace(marked))
+			if len(fragments) == maxSnippetFragments {
+				break
+			}
+		}
+	}
+	if len(fragments) == 0 {
+		return strings.TrimSpace(lines[0])
+	}
+	return strings.Join(fragments, " ... ")
+}
+
+// highlight выделяет в line слова из words и сообщает, нашлось ли хоть одно.
+func highlight(line string, words map[string]bool) (string, bool) {
+	var out strings.Builder
+	found := false
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		if words[strings.ToLower(string(word))] {
+			found = true
+			out.WriteString("<b>" + string(word) + "</b>")
+		} else {
+			out.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range line {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+	return out.String(), found
+}
+
+func (r *MemoryRepository) SearchSongs(ctx context.Context, query string, limit int) ([]dto.SongSearchHit, error) {
+	alternatives := parseSearchQuery(query)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hits := []dto.SongSearchHit{}
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid {
+			continue
+		}
+		rank, words := rankSong(&song, alternatives)
+		if rank == 0 {
+			continue
+		}
+		hits = append(hits, dto.SongSearchHit{
+			ID:          song.ID,
+			GroupID:     song.GroupID,
+			Group:       song.Group,
+			Song:        song.Song,
+			Link:        song.Link,
+			ReleaseDate: song.ReleaseDate,
+			Rank:        rank,
+			Snippet:     snippet(song.Text, words),
+		})
+	}
+	slices.SortFunc(hits, func(a, b dto.SongSearchHit) int {
+		if searchHitLess(&a, &b) {
+			return -1
+		}
+		return 1
+	})
+	return hits[:min(limit, len(hits))], nil
+}
+
+// trigrams возвращает триграммы строки по правилам pg_trgm: слова в нижнем
+// регистре дополняются двумя пробелами в начале и одним в конце.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range searchWords(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// similarity - триграммное сходство строк, как similarity из pg_trgm.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for trigram := range ta {
+		if tb[trigram] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func (r *MemoryRepository) FindNearDuplicates(ctx context.Context, groupID *int, threshold float64, limit int) ([]dto.DuplicateCandidate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byGroup := map[int][]dto.Song{}
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid || (groupID != nil && song.GroupID != *groupID) {
+			continue
+		}
+		byGroup[song.GroupID] = append(byGroup[song.GroupID], song)
+	}
+
+	pairs := []dto.DuplicateCandidate{}
+	for _, songs := range byGroup {
+		for _, a := range songs {
+			for _, b := range songs {
+				if bytes.Compare(a.ID[:], b.ID[:]) >= 0 {
+					continue
+				}
+				if sim := similarity(a.Song, b.Song); sim >= threshold {
+					pairs = append(pairs, dto.DuplicateCandidate{
+						GroupID:       a.GroupID,
+						Group:         a.Group,
+						SongID:        a.ID,
+						Song:          a.Song,
+						DuplicateID:   b.ID,
+						DuplicateSong: b.Song,
+						Similarity:    sim,
+					})
+				}
+			}
+		}
+	}
+	slices.SortFunc(pairs, func(a, b dto.DuplicateCandidate) int {
+		if duplicateLess(&a, &b) {
+			return -1
+		}
+		return 1
+	})
+	return pairs[:min(limit, len(pairs))], nil
+}
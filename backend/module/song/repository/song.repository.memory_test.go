@@ -0,0 +1,172 @@
+package song_repository
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+)
+
+func newMemorySong(t *testing.T, repo *MemoryRepository, group, title, text string) *dto.Song {
+	t.Helper()
+
+	groupID, err := repo.CheckTable(context.Background(), group)
+	if err != nil {
+		t.Fatalf("CheckTable error = %v", err)
+	}
+	song := &dto.Song{
+		ID:          uuid.New(),
+		GroupID:     groupID,
+		Group:       group,
+		Song:        title,
+		Text:        text,
+		Link:        "https://example.com/" + title,
+		ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+		Version:     1,
+	}
+	if err := repo.CreateSongs(context.Background(), []*dto.Song{song}, "test"); err != nil {
+		t.Fatalf("CreateSongs(%q) error = %v", title, err)
+	}
+	return song
+}
+
+func TestMemoryListSongsCursor(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	for _, title := range []string{"Uprising", "Hysteria", "Starlight", "Madness"} {
+		newMemorySong(t, repo, "Muse", title, "")
+	}
+	newMemorySong(t, repo, "Placebo", "Every You Every Me", "")
+
+	filter := &dto.SongFilter{Group: &dto.TextFilter{Mode: dto.MatchExact, Value: "Muse"}, Sort: dto.SongSort{Field: dto.SortBySong}}
+	var titles []string
+	var after *dto.SongCursor
+	for {
+		songs, err := repo.ListSongs(ctx, filter, after, 3)
+		if err != nil {
+			t.Fatalf("ListSongs error = %v", err)
+		}
+		for _, song := range songs {
+			titles = append(titles, song.Song)
+		}
+		if len(songs) < 3 {
+			break
+		}
+		after = dto.NewSongCursor(filter.Sort, &songs[len(songs)-1])
+	}
+
+	want := []string{"Hysteria", "Madness", "Starlight", "Uprising"}
+	if len(titles) != len(want) {
+		t.Fatalf("titles = %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("titles = %v, want %v", titles, want)
+		}
+	}
+}
+
+func TestMemoryUniqueTitles(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	song := newMemorySong(t, repo, "Muse", "Uprising", "")
+
+	duplicate := *song
+	duplicate.ID, duplicate.Song = uuid.New(), " UPRISING "
+	if err := repo.CreateSongs(ctx, []*dto.Song{&duplicate}, "test"); !errors.Is(err, dto.ErrSongExists) {
+		t.Fatalf("CreateSongs duplicate error = %v, want ErrSongExists", err)
+	}
+
+	if err := repo.DeleteSong(ctx, song.ID, nil, "test"); err != nil {
+		t.Fatalf("DeleteSong error = %v", err)
+	}
+	if err := repo.CreateSongs(ctx, []*dto.Song{&duplicate}, "test"); err != nil {
+		t.Fatalf("CreateSongs after delete error = %v", err)
+	}
+	if _, err := repo.RestoreSong(ctx, song.ID, "test"); !errors.Is(err, dto.ErrSongExists) {
+		t.Fatalf("RestoreSong error = %v, want ErrSongExists", err)
+	}
+}
+
+func TestMemoryUpdateSong(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	song := newMemorySong(t, repo, "Muse", "Uprising", "")
+	otherID, _ := repo.CheckTable(ctx, "Placebo")
+
+	title := "Uprising (Live)"
+	patch := &dto.SongPatch{Song: &title}
+	if _, err := repo.UpdateSong(ctx, song.ID, patch, &dto.IfMatch{Versions: []int{2}}, dto.RevisionUpdate, "test"); !errors.Is(err, dto.ErrVersionMismatch) {
+		t.Fatalf("UpdateSong stale error = %v, want ErrVersionMismatch", err)
+	}
+
+	missing := 100
+	var patchErr *dto.PatchError
+	if _, err := repo.UpdateSong(ctx, song.ID, &dto.SongPatch{GroupID: &missing}, nil, dto.RevisionUpdate, "test"); !errors.As(err, &patchErr) {
+		t.Fatalf("UpdateSong missing group error = %v, want PatchError", err)
+	}
+
+	patch.GroupID = &otherID
+	updated, err := repo.UpdateSong(ctx, song.ID, patch, &dto.IfMatch{Versions: []int{1}}, dto.RevisionUpdate, "test")
+	if err != nil {
+		t.Fatalf("UpdateSong error = %v", err)
+	}
+	if updated.Version != 2 || updated.Song != title || updated.Group != "Placebo" {
+		t.Fatalf("updated song = %+v", updated)
+	}
+
+	revision, err := repo.GetSongRevision(ctx, song.ID, 2)
+	if err != nil {
+		t.Fatalf("GetSongRevision error = %v", err)
+	}
+	if _, ok := revision.Diff["group_id"]; !ok || len(revision.Diff) != 3 {
+		t.Errorf("revision diff = %v, want group_id, group and song", revision.Diff)
+	}
+}
+
+func TestMemorySearchSongs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	uprising := newMemorySong(t, repo, "Muse", "Uprising", "They will not force us\nThey will stop degrading us")
+	newMemorySong(t, repo, "Muse", "Starlight", "Far away\nThe ship is taking me far away")
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"uprising", 1},
+		{"far away", 1},
+		{"force or ship", 2},
+		{"they -uprising", 0},
+		{"nothing", 0},
+	}
+	for _, tt := range tests {
+		hits, err := repo.SearchSongs(ctx, tt.query, 10)
+		if err != nil {
+			t.Fatalf("SearchSongs(%q) error = %v", tt.query, err)
+		}
+		if len(hits) != tt.want {
+			t.Errorf("SearchSongs(%q) = %d hits, want %d", tt.query, len(hits), tt.want)
+		}
+	}
+
+	hits, _ := repo.SearchSongs(ctx, "uprising force", 10)
+	if len(hits) != 1 || hits[0].ID != uprising.ID || hits[0].Snippet != "They will not <b>force</b> us" {
+		t.Errorf("hits = %+v", hits)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	// Пример из документации pg_trgm
+	if got := similarity("word", "two words"); math.Abs(got-4.0/11) > 1e-9 {
+		t.Errorf("similarity = %v, want %v", got, 4.0/11)
+	}
+	if got := similarity("Uprising", "uprising!"); got != 1 {
+		t.Errorf("similarity = %v, want 1", got)
+	}
+}
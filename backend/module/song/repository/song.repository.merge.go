@@ -0,0 +1,114 @@
+package song_repository
+
+import (
+	"bytes"
+	"container/heap"
+	"strings"
+
+	"root/module/song/dto"
+)
+
+// songLess возвращает порядок песен между шардами, совпадающий с applySongOrder.
+// Сравнение id побайтово совпадает с порядком сортировки uuid в Postgres.
+func songLess(sort dto.SongSort) func(a, b *dto.Song) bool {
+	return func(a, b *dto.Song) bool {
+		cmp := 0
+		switch sort.Field {
+		case dto.SortByGroup:
+			cmp = strings.Compare(a.Group, b.Group)
+		case dto.SortBySong:
+			cmp = strings.Compare(a.Song, b.Song)
+		case dto.SortByReleaseDate:
+			cmp = a.ReleaseDate.Compare(b.ReleaseDate)
+		}
+		if cmp == 0 {
+			cmp = bytes.Compare(a.ID[:], b.ID[:])
+		}
+		if sort.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+}
+
+// searchHitLess упорядочивает результаты поиска по убыванию ts_rank,
+// при равной релевантности - по id, как и внутри каждого шарда.
+func searchHitLess(a, b *dto.SongSearchHit) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
+
+// trashLess упорядочивает корзину по убыванию deleted_at, затем по id, как и
+// внутри каждого шарда.
+func trashLess(a, b *dto.Song) bool {
+	if !a.DeletedAt.Time.Equal(b.DeletedAt.Time) {
+		return a.DeletedAt.Time.After(b.DeletedAt.Time)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) < 0
+}
+
+// duplicateLess упорядочивает пары по убыванию сходства, затем по id, как и
+// внутри каждого шарда.
+func duplicateLess(a, b *dto.DuplicateCandidate) bool {
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	if cmp := bytes.Compare(a.SongID[:], b.SongID[:]); cmp != 0 {
+		return cmp < 0
+	}
+	return bytes.Compare(a.DuplicateID[:], b.DuplicateID[:]) < 0
+}
+
+// shardHead - текущая позиция в отсортированной выборке одного шарда. Если
+// задан more, выборка читается порциями: more возвращает следующую порцию
+// или пустой срез, когда шард исчерпан.
+type shardHead[T any] struct {
+	items []T
+	pos   int
+	more  func() ([]T, error)
+}
+
+type shardHeap[T any] struct {
+	heads []*shardHead[T]
+	less  func(a, b *T) bool
+}
+
+func (h *shardHeap[T]) Len() int { return len(h.heads) }
+func (h *shardHeap[T]) Less(i, j int) bool {
+	return h.less(&h.heads[i].items[h.heads[i].pos], &h.heads[j].items[h.heads[j].pos])
+}
+func (h *shardHeap[T]) Swap(i, j int)      { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h *shardHeap[T]) Push(x interface{}) { h.heads = append(h.heads, x.(*shardHead[T])) }
+func (h *shardHeap[T]) Pop() interface{} {
+	old := h.heads
+	head := old[len(old)-1]
+	h.heads = old[:len(old)-1]
+	return head
+}
+
+// mergeShards сливает уже отсортированные по less выборки шардов (k-way merge)
+// и возвращает не более n первых элементов в общем порядке.
+func mergeShards[T any](shards [][]T, less func(a, b *T) bool, n int) []T {
+	h := &shardHeap[T]{less: less}
+	for _, items := range shards {
+		if len(items) > 0 {
+			h.heads = append(h.heads, &shardHead[T]{items: items})
+		}
+	}
+	heap.Init(h)
+
+	merged := make([]T, 0, n)
+	for h.Len() > 0 && len(merged) < n {
+		head := h.heads[0]
+		merged = append(merged, head.items[head.pos])
+		head.pos++
+		if head.pos == len(head.items) {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,124 @@
+package song_repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+)
+
+// mergeID возвращает uuid, байты которого упорядочены по n.
+func mergeID(n byte) uuid.UUID {
+	var id uuid.UUID
+	id[15] = n
+	return id
+}
+
+func mergeSong(n byte, group, title, released string) dto.Song {
+	date, _ := time.Parse(dto.DateLayout, released)
+	return dto.Song{ID: mergeID(n), Group: group, Song: title, ReleaseDate: date}
+}
+
+func songIDs(songs []dto.Song) []byte {
+	ids := make([]byte, len(songs))
+	for i, song := range songs {
+		ids[i] = song.ID[15]
+	}
+	return ids
+}
+
+func TestMergeShards(t *testing.T) {
+	cases := []struct {
+		name   string
+		sort   dto.SongSort
+		shards [][]dto.Song
+		limit  int
+		want   []byte
+	}{
+		{
+			name: "interleaved shards by id",
+			sort: dto.SongSort{Field: dto.SortByID},
+			shards: [][]dto.Song{
+				{mergeSong(1, "A", "a", "2001-01-01"), mergeSong(4, "A", "d", "2001-01-01")},
+				{mergeSong(2, "B", "b", "2001-01-01"), mergeSong(3, "B", "c", "2001-01-01")},
+			},
+			limit: 10,
+			want:  []byte{1, 2, 3, 4},
+		},
+		{
+			name: "equal sort keys across shards break ties by id",
+			sort: dto.SongSort{Field: dto.SortBySong},
+			shards: [][]dto.Song{
+				{mergeSong(3, "A", "Same", "2001-01-01"), mergeSong(9, "A", "Zulu", "2001-01-01")},
+				{mergeSong(1, "B", "Same", "2001-01-01"), mergeSong(5, "B", "Same", "2001-01-01")},
+				{mergeSong(2, "C", "Same", "2001-01-01")},
+			},
+			limit: 10,
+			want:  []byte{1, 2, 3, 5, 9},
+		},
+		{
+			name: "descending order reverses ties too",
+			sort: dto.SongSort{Field: dto.SortByReleaseDate, Desc: true},
+			shards: [][]dto.Song{
+				{mergeSong(2, "A", "a", "2010-01-01"), mergeSong(1, "A", "b", "2000-01-01")},
+				{mergeSong(3, "B", "c", "2010-01-01"), mergeSong(4, "B", "d", "2005-01-01")},
+			},
+			limit: 10,
+			want:  []byte{3, 2, 4, 1},
+		},
+		{
+			name: "empty shards are skipped",
+			sort: dto.SongSort{Field: dto.SortByGroup},
+			shards: [][]dto.Song{
+				nil,
+				{mergeSong(2, "Muse", "a", "2001-01-01")},
+				{},
+				{mergeSong(1, "Placebo", "b", "2001-01-01")},
+			},
+			limit: 10,
+			want:  []byte{2, 1},
+		},
+		{
+			name:   "all shards empty",
+			sort:   dto.SongSort{Field: dto.SortByID},
+			shards: [][]dto.Song{nil, {}},
+			limit:  10,
+			want:   []byte{},
+		},
+		{
+			name: "limit stops the merge",
+			sort: dto.SongSort{Field: dto.SortByID},
+			shards: [][]dto.Song{
+				{mergeSong(1, "A", "a", "2001-01-01"), mergeSong(3, "A", "c", "2001-01-01")},
+				{mergeSong(2, "B", "b", "2001-01-01"), mergeSong(4, "B", "d", "2001-01-01")},
+			},
+			limit: 3,
+			want:  []byte{1, 2, 3},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := songIDs(mergeShards(tc.shards, songLess(tc.sort), tc.limit))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("merged ids = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestSongLessMatchesUUIDOrder проверяет, что id сравниваются побайтово, как
+// uuid в Postgres, а не по строке или отдельным полям.
+func TestSongLessMatchesUUIDOrder(t *testing.T) {
+	low := uuid.MustParse("0fffffff-ffff-ffff-ffff-ffffffffffff")
+	high := uuid.MustParse("10000000-0000-0000-0000-000000000000")
+	less := songLess(dto.SongSort{Field: dto.SortByID})
+	if !less(&dto.Song{ID: low}, &dto.Song{ID: high}) || less(&dto.Song{ID: high}, &dto.Song{ID: low}) {
+		t.Error("songLess does not follow byte order of ids")
+	}
+	if less(&dto.Song{ID: low}, &dto.Song{ID: low}) {
+		t.Error("songLess must be strict for equal songs")
+	}
+}
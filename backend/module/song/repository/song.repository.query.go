@@ -0,0 +1,106 @@
+package song_repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"root/module/song/dto"
+
+	"gorm.io/gorm"
+)
+
+// sortColumns - выражения ORDER BY для разрешённых полей сортировки. Текстовые
+// поля сравниваются в COLLATE "C", чтобы порядок Postgres совпадал с побайтовым
+// сравнением строк при слиянии шардов.
+var sortColumns = map[string]string{
+	dto.SortByID:          "id",
+	dto.SortByGroup:       `"group" COLLATE "C"`,
+	dto.SortBySong:        `song COLLATE "C"`,
+	dto.SortByReleaseDate: "release_date",
+}
+
+// applySongFilter добавляет к запросу по таблице шарда условия фильтра.
+// Имена колонок берутся только из кода, значения передаются параметрами.
+func applySongFilter(query *gorm.DB, filter *dto.SongFilter) *gorm.DB {
+	query = applyTextFilter(query, `"group"`, filter.Group)
+	query = applyTextFilter(query, "song", filter.Song)
+	query = applyTextFilter(query, "text", filter.Text)
+	query = applyTextFilter(query, "link", filter.Link)
+
+	if filter.GroupID != nil {
+		query = query.Where("group_id = ?", *filter.GroupID)
+	}
+	if filter.ReleaseDate != nil {
+		query = query.Where("release_date = ?", *filter.ReleaseDate)
+	}
+	if filter.ReleaseDateFrom != nil {
+		query = query.Where("release_date >= ?", *filter.ReleaseDateFrom)
+	}
+	if filter.ReleaseDateTo != nil {
+		query = query.Where("release_date <= ?", *filter.ReleaseDateTo)
+	}
+
+	return query
+}
+
+// applySongOrder сортирует выборку шарда по полю фильтра, id разрешает равенства.
+func applySongOrder(query *gorm.DB, sort dto.SongSort) *gorm.DB {
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+
+	if sort.Field == dto.SortByID {
+		return query.Order("id " + direction)
+	}
+	return query.Order(fmt.Sprintf("%s %s, id %s", sortColumns[sort.Field], direction, direction))
+}
+
+// applyCursor ограничивает выборку шарда песнями строго после курсора.
+func applyCursor(query *gorm.DB, after *dto.SongCursor) *gorm.DB {
+	op := ">"
+	if after.Desc {
+		op = "<"
+	}
+
+	if after.Sort == dto.SortByID {
+		return query.Where(fmt.Sprintf("id %s ?", op), after.ID)
+	}
+
+	var value interface{} = after.Value
+	if after.Sort == dto.SortByReleaseDate {
+		value, _ = time.Parse(dto.DateLayout, after.Value)
+	}
+	return query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", sortColumns[after.Sort], op), value, after.ID)
+}
+
+// applyIfMatch добавляет в WHERE проверку версии из If-Match.
+func applyIfMatch(query *gorm.DB, ifMatch *dto.IfMatch) *gorm.DB {
+	if ifMatch == nil {
+		return query
+	}
+	return query.Where("version IN ?", ifMatch.Versions)
+}
+
+func applyTextFilter(query *gorm.DB, column string, filter *dto.TextFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+
+	switch filter.Mode {
+	case dto.MatchExact:
+		return query.Where(column+" = ?", filter.Value)
+	case dto.MatchPrefix:
+		return query.Where(column+` ILIKE ? ESCAPE '\'`, escapeLike(filter.Value)+"%")
+	default:
+		return query.Where(column+` ILIKE ? ESCAPE '\'`, "%"+escapeLike(filter.Value)+"%")
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike экранирует спецсимволы LIKE, чтобы % и _ в запросе искались буквально.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
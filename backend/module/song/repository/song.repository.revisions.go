@@ -0,0 +1,48 @@
+package song_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// saveSongRevision записывает в историю состояние песни после изменения в
+// рамках tx, в которой изменена песня.
+func (r *SongRepository) saveSongRevision(tx *gorm.DB, song *dto.Song, diff dto.RevisionDiff, action dto.RevisionAction, actor string) error {
+	revision := dto.NewSongRevision(song, diff, action, actor)
+	if err := tx.Create(&revision).Error; err != nil {
+		return fmt.Errorf("не удалось записать ревизию песни: %w", err)
+	}
+	return nil
+}
+
+// GetSongRevisions возвращает ревизии песни, начиная с последней.
+func (r *SongRepository) GetSongRevisions(ctx context.Context, songID uuid.UUID, offset, limit int) ([]dto.SongRevision, error) {
+	revisions := []dto.SongRevision{}
+	err := r.reader(ctx).
+		Where("song_id = ?", songID).
+		Order("version DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении истории песни: %w", err)
+	}
+	return revisions, nil
+}
+
+func (r *SongRepository) GetSongRevision(ctx context.Context, songID uuid.UUID, version int) (*dto.SongRevision, error) {
+	revision := new(dto.SongRevision)
+	err := r.reader(ctx).Where("song_id = ? AND version = ?", songID, version).Take(revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении ревизии песни: %w", err)
+	}
+	return revision, nil
+}
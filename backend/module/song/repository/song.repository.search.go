@@ -0,0 +1,79 @@
+package song_repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"root/database"
+	"root/module/song/dto"
+
+	"gorm.io/gorm"
+)
+
+// SearchSongs ищет песни вне корзины по названию и тексту и возвращает не
+// больше limit результатов по убыванию релевантности ts_rank.
+func (r *SongRepository) SearchSongs(ctx context.Context, query string, limit int) ([]dto.SongSearchHit, error) {
+	shards, err := database.FanOut(ctx, r.config.ShardFanOutLimit, database.ShardTables(), func(ctx context.Context, tableName string) ([]dto.SongSearchHit, error) {
+		// websearch_to_tsquery понимает кавычки, OR и минус и не падает
+		// с ошибкой синтаксиса на произвольном пользовательском вводе.
+		var hits []dto.SongSearchHit
+		err := r.reader(ctx).Raw(fmt.Sprintf(`
+			SELECT id, group_id, "group", song, link, release_date,
+				ts_rank(search_vector, q) AS rank,
+				ts_headline('%s', text, q, 'StartSel=<b>, StopSel=</b>, MaxFragments=3, MaxWords=20, MinWords=5') AS snippet
+			FROM %s, websearch_to_tsquery('%s', ?) AS q
+			WHERE search_vector @@ q AND deleted_at IS NULL
+			ORDER BY rank DESC, id
+			LIMIT ?`, database.SearchConfig, tableName, database.SearchConfig), query, limit).Scan(&hits).Error
+		if err != nil {
+			r.logger.Errorf("SearchSongs: error searching table %s: %v", tableName, err)
+			return nil, err
+		}
+
+		r.logger.Infof("SearchSongs: found %d hits in table %s", len(hits), tableName)
+		return hits, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShards(shards, searchHitLess, limit), nil
+}
+
+// FindNearDuplicates возвращает не больше limit пар песен одной группы вне
+// корзины, названия которых похожи не меньше чем на threshold по триграммам
+// pg_trgm, начиная с самых похожих. Если задан groupID, проверяется только
+// эта группа.
+func (r *SongRepository) FindNearDuplicates(ctx context.Context, groupID *int, threshold float64, limit int) ([]dto.DuplicateCandidate, error) {
+	shards, err := database.FanOut(ctx, r.config.ShardFanOutLimit, filterTables(groupID), func(ctx context.Context, tableName string) ([]dto.DuplicateCandidate, error) {
+		var pairs []dto.DuplicateCandidate
+		err := r.reader(ctx).Transaction(func(tx *gorm.DB) error {
+			// Оператор % использует порог из настройки, а не аргумент, зато
+			// может идти по триграммному индексу
+			err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)",
+				strconv.FormatFloat(threshold, 'f', -1, 64)).Error
+			if err != nil {
+				return err
+			}
+
+			query := tx.Table(tableName + " AS a").
+				Select(`a.group_id, a."group", a.id AS song_id, a.song, b.id AS duplicate_id, b.song AS duplicate_song,
+					similarity(lower(a.song), lower(b.song)) AS similarity`).
+				Joins("JOIN " + tableName + " AS b ON b.group_id = a.group_id AND a.id < b.id AND lower(a.song) % lower(b.song)").
+				Where("a.deleted_at IS NULL AND b.deleted_at IS NULL")
+			if groupID != nil {
+				query = query.Where("a.group_id = ?", *groupID)
+			}
+			return query.Order("similarity DESC, song_id, duplicate_id").Limit(limit).Scan(&pairs).Error
+		})
+		if err != nil {
+			r.logger.Errorf("FindNearDuplicates: error searching duplicates in table %s: %v", tableName, err)
+			return nil, err
+		}
+		return pairs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShards(shards, duplicateLess, limit), nil
+}
@@ -2,24 +2,73 @@ package song_repository
 
 import (
 	"context"
+	"root/config"
+	"root/database"
+	"root/module/song/dto"
 	"root/shared/logger"
+	"time"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-var _ ISongRepository = (*SongRepository)(nil)
+var (
+	_ ISongRepository = (*SongRepository)(nil)
+	_ ISongRepository = (*MemoryRepository)(nil)
+)
 
+// ISongRepository - хранилище песен, групп, истории и синхронизированных
+// текстов. Каждый метод изменения атомарен: песня, её ревизия и служебные
+// записи сохраняются вместе или не сохраняются вовсе. Повтор названия песни
+// в группе возвращается как dto.ErrSongExists. Методы чтения читают из
+// реплики, только если ctx отмечен database.WithReplicaReads.
 type ISongRepository interface {
 	CheckTable(ctx context.Context, groupName string) (int, error)
+	FindGroupID(ctx context.Context, groupName string) (int, error)
+
+	ListSongs(ctx context.Context, filter *dto.SongFilter, after *dto.SongCursor, limit int) ([]dto.Song, error)
+	ExportSongs(ctx context.Context, filter *dto.SongFilter, write func(song *dto.Song) error) error
+	SearchSongs(ctx context.Context, query string, limit int) ([]dto.SongSearchHit, error)
+	GetSong(ctx context.Context, songID uuid.UUID) (*dto.Song, error)
+	FindSong(ctx context.Context, groupID int, title string) (*dto.Song, error)
+	ExistingSongs(ctx context.Context, keys []dto.SongKey) (map[dto.SongKey]bool, error)
+	CreateSongs(ctx context.Context, songs []*dto.Song, actor string) error
+	UpdateSong(ctx context.Context, songID uuid.UUID, patch *dto.SongPatch, ifMatch *dto.IfMatch, action dto.RevisionAction, actor string) (*dto.Song, error)
+	DeleteSong(ctx context.Context, songID uuid.UUID, ifMatch *dto.IfMatch, actor string) error
+	FindNearDuplicates(ctx context.Context, groupID *int, threshold float64, limit int) ([]dto.DuplicateCandidate, error)
+	GetShardStats(ctx context.Context, top int) ([]dto.ShardStats, error)
+
+	ListTrash(ctx context.Context, limit int) ([]dto.Song, error)
+	RestoreSong(ctx context.Context, songID uuid.UUID, actor string) (*dto.Song, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+
+	GetSongRevisions(ctx context.Context, songID uuid.UUID, offset, limit int) ([]dto.SongRevision, error)
+	GetSongRevision(ctx context.Context, songID uuid.UUID, version int) (*dto.SongRevision, error)
+
+	GetSyncedLines(ctx context.Context, songID uuid.UUID) ([]dto.SongSyncedLine, error)
+	ReplaceSyncedLines(ctx context.Context, songID uuid.UUID, lines []dto.SongSyncedLine) error
+	DeleteSyncedLines(ctx context.Context, songID uuid.UUID) (int64, error)
 }
 
 type SongRepository struct {
 	logger *logger.Logger
-	db     *gorm.DB
+	config *config.Config
+	// db - основная БД: в неё идут записи и чтения внутри транзакций записи
+	db      *gorm.DB
+	cluster *database.Cluster
 }
 
-func NewSongRepository(logger *logger.Logger, db *gorm.DB) *SongRepository {
+func NewSongRepository(logger *logger.Logger, config *config.Config, cluster *database.Cluster) *SongRepository {
 	return &SongRepository{
-		logger: logger,
-		db:     db,
+		logger:  logger,
+		config:  config,
+		db:      cluster.Writer(),
+		cluster: cluster,
 	}
 }
+
+// reader возвращает подключение для чтения вне транзакций записи: реплику,
+// если ctx отмечен database.WithReplicaReads, иначе основную БД.
+func (r *SongRepository) reader(ctx context.Context) *gorm.DB {
+	return r.cluster.Reader(ctx).WithContext(ctx)
+}
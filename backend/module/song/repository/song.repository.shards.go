@@ -0,0 +1,74 @@
+package song_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"root/database"
+	"root/module/song/dto"
+)
+
+// GetShardStats собирает для каждой таблицы songs_N количество песен, размер
+// таблицы и индексов, top самых больших групп и время последнего изменения.
+// Доли шардов считает вызывающий.
+func (r *SongRepository) GetShardStats(ctx context.Context, top int) ([]dto.ShardStats, error) {
+	shards, err := database.FanOut(ctx, r.config.ShardFanOutLimit, database.ShardTables(), func(ctx context.Context, tableName string) (dto.ShardStats, error) {
+		stats, err := r.shardStats(ctx, tableName, top)
+		if err != nil {
+			r.logger.Errorf("GetShardStats: error collecting stats for table %s: %v", tableName, err)
+		}
+		return stats, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range shards {
+		shards[i].Shard = i
+	}
+	return shards, nil
+}
+
+// shardStats собирает статистику одной таблицы шарда. Время последнего
+// изменения берётся из истории: у строк песен нет своей отметки времени.
+func (r *SongRepository) shardStats(ctx context.Context, tableName string, top int) (dto.ShardStats, error) {
+	db := r.reader(ctx)
+	stats := dto.ShardStats{Table: tableName}
+
+	var sizes struct {
+		Rows, LiveRows, TrashedRows, TableBytes, IndexBytes int64
+	}
+	err := db.Raw(fmt.Sprintf(`SELECT count(*) AS rows,
+			count(*) FILTER (WHERE deleted_at IS NULL) AS live_rows,
+			count(*) FILTER (WHERE deleted_at IS NOT NULL) AS trashed_rows,
+			pg_table_size(?::regclass) AS table_bytes,
+			pg_indexes_size(?::regclass) AS index_bytes
+		FROM %s`, tableName), tableName, tableName).Scan(&sizes).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.Rows, stats.LiveRows, stats.TrashedRows = sizes.Rows, sizes.LiveRows, sizes.TrashedRows
+	stats.TableBytes, stats.IndexBytes = sizes.TableBytes, sizes.IndexBytes
+
+	stats.TopGroups = []dto.ShardGroupStats{}
+	err = db.Table(tableName).
+		Select(`group_id, max("group") AS "group", count(*) AS songs`).
+		Where("deleted_at IS NULL").
+		Group("group_id").
+		Order("songs DESC, group_id").
+		Limit(top).
+		Scan(&stats.TopGroups).Error
+	if err != nil {
+		return stats, err
+	}
+
+	var lastWrite struct{ LastWriteAt *time.Time }
+	err = db.Raw(fmt.Sprintf(`SELECT max(created_at) AS last_write_at FROM song_revisions
+		WHERE song_id IN (SELECT id FROM %s)`, tableName)).Scan(&lastWrite).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.LastWriteAt = lastWrite.LastWriteAt
+
+	return stats, nil
+}
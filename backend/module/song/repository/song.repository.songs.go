@@ -0,0 +1,388 @@
+package song_repository
+
+import (
+	"container/heap"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"root/database"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// uniqueViolation - код ошибки Postgres при нарушении уникального индекса.
+const uniqueViolation = "23505"
+
+// exportBatchSize - число строк, которое читается из шарда за один запрос.
+// В памяти одновременно находится не больше одной порции на шард.
+const exportBatchSize = 500
+
+// filterTables возвращает шарды, в которых могут быть песни под filter: песни
+// одной группы лежат в одном шарде, остальные шарды не опрашиваются.
+func filterTables(groupID *int) []string {
+	if groupID != nil {
+		return []string{database.GroupShardTable(*groupID)}
+	}
+	return database.ShardTables()
+}
+
+// ListSongs возвращает не больше limit песен вне корзины, подходящих под
+// filter, в порядке filter.Sort строго после after. Каждый шард отдаёт
+// не больше limit строк, выборки сливаются в общий порядок.
+func (r *SongRepository) ListSongs(ctx context.Context, filter *dto.SongFilter, after *dto.SongCursor, limit int) ([]dto.Song, error) {
+	shards, err := database.FanOut(ctx, r.config.ShardFanOutLimit, filterTables(filter.GroupID), func(ctx context.Context, tableName string) ([]dto.Song, error) {
+		var songs []dto.Song
+		query := applySongFilter(r.reader(ctx).Table(tableName), filter)
+		if after != nil {
+			query = applyCursor(query, after)
+		}
+		query = applySongOrder(query, filter.Sort)
+
+		if err := query.Limit(limit).Find(&songs).Error; err != nil {
+			r.logger.Errorf("ListSongs: error fetching songs from table %s: %v", tableName, err)
+			return nil, err
+		}
+
+		r.logger.Infof("ListSongs: found %d songs in table %s", len(songs), tableName)
+		return songs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShards(shards, songLess(filter.Sort), limit), nil
+}
+
+// ExportSongs передаёт write все песни под filter в порядке filter.Sort. Шарды
+// читаются порциями по курсору и сливаются на лету в одной транзакции
+// REPEATABLE READ, поэтому выгрузка - согласованный снимок и не держит в
+// памяти всю выборку. Ошибка write прерывает выгрузку.
+func (r *SongRepository) ExportSongs(ctx context.Context, filter *dto.SongFilter, write func(song *dto.Song) error) error {
+	return r.reader(ctx).Transaction(func(tx *gorm.DB) error {
+		h := &shardHeap[dto.Song]{less: songLess(filter.Sort)}
+		for _, tableName := range filterTables(filter.GroupID) {
+			head := &shardHead[dto.Song]{more: exportBatches(tx, tableName, filter)}
+			items, err := head.more()
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", tableName, err)
+			}
+			if len(items) > 0 {
+				head.items = items
+				h.heads = append(h.heads, head)
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			head := h.heads[0]
+			if err := write(&head.items[head.pos]); err != nil {
+				return err
+			}
+
+			head.pos++
+			if head.pos < len(head.items) {
+				heap.Fix(h, 0)
+				continue
+			}
+
+			items, err := head.more()
+			if err != nil {
+				return fmt.Errorf("failed to read shard: %w", err)
+			}
+			if len(items) == 0 {
+				heap.Pop(h)
+				continue
+			}
+			head.items, head.pos = items, 0
+			heap.Fix(h, 0)
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// exportBatches возвращает функцию, которая читает следующую порцию шарда после
+// последней отданной песни в порядке filter.Sort.
+func exportBatches(tx *gorm.DB, tableName string, filter *dto.SongFilter) func() ([]dto.Song, error) {
+	var after *dto.SongCursor
+	done := false
+
+	return func() ([]dto.Song, error) {
+		if done {
+			return nil, nil
+		}
+
+		query := applySongFilter(tx.Table(tableName), filter)
+		if after != nil {
+			query = applyCursor(query, after)
+		}
+
+		var songs []dto.Song
+		if err := applySongOrder(query, filter.Sort).Limit(exportBatchSize).Find(&songs).Error; err != nil {
+			return nil, err
+		}
+
+		if len(songs) < exportBatchSize {
+			done = true
+		}
+		if len(songs) > 0 {
+			after = dto.NewSongCursor(filter.Sort, &songs[len(songs)-1])
+		}
+		return songs, nil
+	}
+}
+
+// GetSong возвращает песню вне корзины или dto.ErrSongNotFound.
+func (r *SongRepository) GetSong(ctx context.Context, songID uuid.UUID) (*dto.Song, error) {
+	tableName, err := r.LocateSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	song := new(dto.Song)
+	if err := r.reader(ctx).Table(tableName).Where("id = ?", songID).Take(song).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, dto.ErrSongNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении песни из %s: %w", tableName, err)
+	}
+	return song, nil
+}
+
+// FindSong ищет песню группы вне корзины по названию без учёта регистра и
+// крайних пробелов, как в уникальном ключе шарда. Возвращает nil, если песни нет.
+func (r *SongRepository) FindSong(ctx context.Context, groupID int, title string) (*dto.Song, error) {
+	var songs []dto.Song
+	err := r.reader(ctx).Table(database.GroupShardTable(groupID)).
+		Where("group_id = ? AND lower(btrim(song)) = lower(btrim(?))", groupID, title).
+		Limit(1).
+		Find(&songs).Error
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске песни: %w", err)
+	}
+	if len(songs) == 0 {
+		return nil, nil
+	}
+	return &songs[0], nil
+}
+
+// ExistingSongs возвращает ключи из keys, под которыми уже есть песни вне корзины.
+func (r *SongRepository) ExistingSongs(ctx context.Context, keys []dto.SongKey) (map[dto.SongKey]bool, error) {
+	byShard := map[string][][]interface{}{}
+	for _, key := range keys {
+		tableName := database.GroupShardTable(key.GroupID)
+		byShard[tableName] = append(byShard[tableName], []interface{}{key.GroupID, key.Song})
+	}
+
+	found := map[dto.SongKey]bool{}
+	for tableName, values := range byShard {
+		var rows []struct {
+			GroupID int
+			Song    string
+		}
+		err := r.reader(ctx).Table(tableName).
+			Select("group_id, lower(btrim(song)) AS song").
+			Where("deleted_at IS NULL AND (group_id, lower(btrim(song))) IN ?", values).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при поиске дубликатов в %s: %w", tableName, err)
+		}
+		for _, row := range rows {
+			found[dto.SongKey{GroupID: row.GroupID, Song: row.Song}] = true
+		}
+	}
+	return found, nil
+}
+
+// CreateSongs записывает песни в шарды их групп вместе с записями в
+// song_locations и ревизиями создания от имени actor одной транзакцией.
+func (r *SongRepository) CreateSongs(ctx context.Context, songs []*dto.Song, actor string) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	byShard := map[string][]*dto.Song{}
+	locations := make([]dto.SongLocation, 0, len(songs))
+	revisions := make([]dto.SongRevision, 0, len(songs))
+	for _, song := range songs {
+		tableName := database.GroupShardTable(song.GroupID)
+		byShard[tableName] = append(byShard[tableName], song)
+		locations = append(locations, dto.SongLocation{SongID: song.ID, GroupID: song.GroupID})
+		revisions = append(revisions, dto.NewSongRevision(song, dto.NewSongSnapshot(song).Diff(nil), dto.RevisionCreate, actor))
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// В транзакции плагин шардирования не подменяет таблицу, поэтому шард
+		// указывается явно тем же правилом database.ShardIndex.
+		for tableName, songs := range byShard {
+			if err := tx.Table(tableName).Create(&songs).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&locations).Error; err != nil {
+			return err
+		}
+		return tx.Create(&revisions).Error
+	})
+	if isDuplicateSong(err) {
+		return dto.ErrSongExists
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось создать песни: %w", err)
+	}
+	return nil
+}
+
+// UpdateSong применяет к песне patch, увеличивает её версию и записывает
+// ревизию action от имени actor. Если меняется группа и её шард отличается от
+// текущего, песня переносится в новый шард в той же транзакции, что и
+// обновление индекса song_locations. Если задан ifMatch, версия проверяется
+// в WHERE изменения строки.
+func (r *SongRepository) UpdateSong(ctx context.Context, songID uuid.UUID, patch *dto.SongPatch, ifMatch *dto.IfMatch, action dto.RevisionAction, actor string) (*dto.Song, error) {
+	tableName, err := r.LocateSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	song := new(dto.Song)
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(tableName).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", songID).Take(song).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dto.ErrSongNotFound
+			}
+			return err
+		}
+
+		before := dto.NewSongSnapshot(song)
+		columns := patch.Columns()
+		columns["version"] = gorm.Expr("version + 1")
+		patch.Apply(song)
+		song.Version++
+
+		if patch.GroupID == nil || *patch.GroupID == song.GroupID {
+			if err := r.updateSongRow(tx, tableName, songID, columns, ifMatch); err != nil {
+				return err
+			}
+			return r.saveSongRevision(tx, song, dto.NewSongSnapshot(song).Diff(&before), action, actor)
+		}
+
+		// Группа блокируется на чтение, чтобы её не удалили до конца переноса
+		group := new(dto.Group)
+		err = tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", *patch.GroupID).Take(group).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &dto.PatchError{Field: "group_id", Reason: "group does not exist"}
+			}
+			return err
+		}
+		song.GroupID = group.ID
+		song.Group = group.Name
+
+		targetTable := database.GroupShardTable(group.ID)
+		if targetTable == tableName {
+			columns["group_id"] = song.GroupID
+			columns["group"] = song.Group
+			if err := r.updateSongRow(tx, tableName, songID, columns, ifMatch); err != nil {
+				return err
+			}
+		} else {
+			result := applyIfMatch(tx.Table(tableName).Unscoped().Where("id = ?", songID), ifMatch).Delete(&dto.Song{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return dto.ErrVersionMismatch
+			}
+			if err := tx.Table(targetTable).Create(song).Error; err != nil {
+				return err
+			}
+			r.logger.Infof("UpdateSong: song %s moved from %s to %s", songID, tableName, targetTable)
+		}
+		if err := r.saveSongLocation(tx, songID, song.GroupID); err != nil {
+			return err
+		}
+		return r.saveSongRevision(tx, song, dto.NewSongSnapshot(song).Diff(&before), action, actor)
+	})
+	if isDuplicateSong(err) {
+		return nil, dto.ErrSongExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return song, nil
+}
+
+// updateSongRow изменяет строку песни, уже заблокированную в tx. Строка
+// существует, поэтому отсутствие изменённых строк означает несовпадение версии.
+func (r *SongRepository) updateSongRow(tx *gorm.DB, tableName string, songID uuid.UUID, columns map[string]interface{}, ifMatch *dto.IfMatch) error {
+	result := applyIfMatch(tx.Table(tableName).Where("id = ?", songID), ifMatch).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return dto.ErrVersionMismatch
+	}
+	return nil
+}
+
+// DeleteSong переносит песню в корзину: проставляет deleted_at, оставляя строку
+// и запись в song_locations для восстановления, и записывает ревизию удаления
+// от имени actor. Если задан ifMatch, версия проверяется в WHERE, и при
+// несовпадении возвращается dto.ErrVersionMismatch.
+func (r *SongRepository) DeleteSong(ctx context.Context, songID uuid.UUID, ifMatch *dto.IfMatch, actor string) error {
+	tableName, err := r.LocateSong(ctx, songID)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := applyIfMatch(tx.Table(tableName).Where("id = ? AND deleted_at IS NULL", songID), ifMatch).
+			Updates(map[string]interface{}{
+				"deleted_at": time.Now(),
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("не удалось удалить песню из %s: %w", tableName, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return r.missingOrMismatch(tx, tableName, songID, ifMatch)
+		}
+
+		song := new(dto.Song)
+		if err := tx.Table(tableName).Unscoped().Where("id = ?", songID).Take(song).Error; err != nil {
+			return err
+		}
+		return r.saveSongRevision(tx, song, dto.RevisionDiff{}, dto.RevisionDelete, actor)
+	})
+}
+
+// missingOrMismatch объясняет, почему условное изменение не затронуло строк:
+// песни нет в шарде или её версия не совпала с If-Match.
+func (r *SongRepository) missingOrMismatch(tx *gorm.DB, tableName string, songID uuid.UUID, ifMatch *dto.IfMatch) error {
+	if ifMatch == nil {
+		return dto.ErrSongNotFound
+	}
+	var count int64
+	if err := tx.Table(tableName).Where("id = ? AND deleted_at IS NULL", songID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return dto.ErrSongNotFound
+	}
+	return dto.ErrVersionMismatch
+}
+
+// isDuplicateSong сообщает, нарушает ли ошибка уникальный ключ названия песни.
+func isDuplicateSong(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == uniqueViolation &&
+		strings.HasSuffix(pgErr.ConstraintName, database.SongTitleKeySuffix)
+}
@@ -0,0 +1,66 @@
+package song_repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// syncedBatchSize - число строк LRC в одном INSERT.
+const syncedBatchSize = 500
+
+// GetSyncedLines возвращает загруженные строки LRC песни в порядке времени.
+func (r *SongRepository) GetSyncedLines(ctx context.Context, songID uuid.UUID) ([]dto.SongSyncedLine, error) {
+	lines := []dto.SongSyncedLine{}
+	if err := r.reader(ctx).Where("song_id = ?", songID).Order("position").Find(&lines).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении синхронизированного текста: %w", err)
+	}
+	return lines, nil
+}
+
+// ReplaceSyncedLines заменяет строки LRC песни вне корзины. Песня блокируется
+// на время записи, чтобы её не удалили, пока пишутся её строки.
+func (r *SongRepository) ReplaceSyncedLines(ctx context.Context, songID uuid.UUID, lines []dto.SongSyncedLine) error {
+	tableName, err := r.LocateSong(ctx, songID)
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(tableName).Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("id = ?", songID).Take(new(dto.Song)).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dto.ErrSongNotFound
+			}
+			return err
+		}
+		if _, err := r.deleteSyncedLines(tx, songID); err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(lines, syncedBatchSize).Error; err != nil {
+			return fmt.Errorf("не удалось записать синхронизированный текст: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteSyncedLines удаляет загруженные строки LRC песни и возвращает число
+// удалённых строк.
+func (r *SongRepository) DeleteSyncedLines(ctx context.Context, songID uuid.UUID) (int64, error) {
+	return r.deleteSyncedLines(r.db.WithContext(ctx), songID)
+}
+
+// deleteSyncedLines удаляет строки LRC песен в рамках tx.
+func (r *SongRepository) deleteSyncedLines(tx *gorm.DB, songIDs ...uuid.UUID) (int64, error) {
+	result := tx.Where("song_id IN ?", songIDs).Delete(&dto.SongSyncedLine{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("не удалось удалить синхронизированный текст: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,124 @@
+package song_repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"root/database"
+	"root/module/song/dto"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListTrash возвращает не больше limit песен из корзины всех шардов, начиная
+// с удалённых последними.
+func (r *SongRepository) ListTrash(ctx context.Context, limit int) ([]dto.Song, error) {
+	shards, err := database.FanOut(ctx, r.config.ShardFanOutLimit, database.ShardTables(), func(ctx context.Context, tableName string) ([]dto.Song, error) {
+		var songs []dto.Song
+		err := r.reader(ctx).Table(tableName).Unscoped().
+			Where("deleted_at IS NOT NULL").
+			Order("deleted_at DESC, id").
+			Limit(limit).
+			Find(&songs).Error
+		if err != nil {
+			r.logger.Errorf("ListTrash: error fetching trash from table %s: %v", tableName, err)
+			return nil, err
+		}
+		return songs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mergeShards(shards, trashLess, limit), nil
+}
+
+// RestoreSong возвращает песню из корзины, увеличивает её версию и записывает
+// восстановление в историю от имени actor. Группу, удалённую каскадом вместе с
+// песней, восстановление создаёт заново с прежним ID и названием.
+func (r *SongRepository) RestoreSong(ctx context.Context, songID uuid.UUID, actor string) (*dto.Song, error) {
+	tableName, err := r.LocateSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	song := new(dto.Song)
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table(tableName).Where("id = ? AND deleted_at IS NOT NULL", songID).
+			Updates(map[string]interface{}{
+				"deleted_at": nil,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return dto.ErrSongNotDeleted
+		}
+		if err := tx.Table(tableName).Where("id = ?", songID).Take(song).Error; err != nil {
+			return err
+		}
+		if err := restoreGroup(tx, song); err != nil {
+			return err
+		}
+		return r.saveSongRevision(tx, song, dto.RevisionDiff{}, dto.RevisionRestore, actor)
+	})
+	if isDuplicateSong(err) {
+		return nil, dto.ErrSongExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return song, nil
+}
+
+// restoreGroup создаёт группу песни, если её удалили. Прежний ID нужен, так
+// как по нему песня лежит в своём шарде. Если название группы заняла другая
+// группа, возвращается dto.ErrGroupNameTaken.
+func restoreGroup(tx *gorm.DB, song *dto.Song) error {
+	result := tx.Exec("INSERT INTO groups (id, name) VALUES (?, ?) ON CONFLICT DO NOTHING", song.GroupID, song.Group)
+	if result.Error != nil {
+		return fmt.Errorf("не удалось восстановить группу: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&dto.Group{}).Where("id = ?", song.GroupID).Count(&count).Error; err != nil {
+		return fmt.Errorf("ошибка при поиске группы: %w", err)
+	}
+	if count == 0 {
+		return dto.ErrGroupNameTaken
+	}
+	return nil
+}
+
+// PurgeTrash окончательно удаляет песни, попавшие в корзину раньше before,
+// вместе с их записями в song_locations и синхронизированными текстами.
+// История песен сохраняется. Каждый шард очищается своей транзакцией.
+func (r *SongRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for _, tableName := range database.ShardTables() {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var ids []uuid.UUID
+			err := tx.Raw("DELETE FROM "+tableName+" WHERE deleted_at < ? RETURNING id", before).Scan(&ids).Error
+			if err != nil || len(ids) == 0 {
+				return err
+			}
+			if err := tx.Where("song_id IN ?", ids).Delete(&dto.SongLocation{}).Error; err != nil {
+				return err
+			}
+			if _, err := r.deleteSyncedLines(tx, ids...); err != nil {
+				return err
+			}
+			purged += int64(len(ids))
+			return nil
+		})
+		if err != nil {
+			return purged, fmt.Errorf("не удалось очистить корзину %s: %w", tableName, err)
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,42 @@
+package song_service
+
+import (
+	"context"
+
+	dto "root/module/song/dto"
+)
+
+// findExistingSong ищет песню вне корзины с тем же названием в группе с точно
+// таким названием без учёта регистра и крайних пробелов в названии песни, как
+// в уникальном ключе шарда. Возвращает nil, если группы или песни нет.
+func (s *SongService) findExistingSong(ctx context.Context, group, song string) (*dto.Song, error) {
+	groupID, err := s.repo.FindGroupID(ctx, group)
+	if err != nil || groupID == 0 {
+		return nil, err
+	}
+	return s.repo.FindSong(ctx, groupID, song)
+}
+
+// FindNearDuplicates возвращает пары песен одной группы вне корзины, названия
+// которых похожи не меньше чем на threshold по триграммам pg_trgm, начиная с
+// самых похожих. Если задан groupID, проверяется только эта группа.
+func (s *SongService) FindNearDuplicates(ctx context.Context, groupID *int, threshold float64, offset, limit int) ([]dto.DuplicateCandidate, error) {
+	s.logger.Info("FindNearDuplicates: started")
+	defer s.logger.Info("FindNearDuplicates: completed")
+
+	if offset > maxPageOffset {
+		return nil, dto.ErrOffsetTooLarge
+	}
+
+	merged, err := s.repo.FindNearDuplicates(ctx, groupID, threshold, offset+limit)
+	if err != nil {
+		s.logger.Errorf("FindNearDuplicates: failed to search duplicates: %v", err)
+		return nil, err
+	}
+	if offset > len(merged) {
+		offset = len(merged)
+	}
+
+	s.logger.Infof("FindNearDuplicates: returning %d pairs (threshold: %v, offset: %d, limit: %d)", len(merged[offset:]), threshold, offset, limit)
+	return merged[offset:], nil
+}
@@ -0,0 +1,123 @@
+package song_service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	dto "root/module/song/dto"
+)
+
+// ExportSongs пишет в w все песни, подходящие под filter, в порядке filter.Sort.
+// Репозиторий отдаёт песни потоком из согласованного снимка, поэтому выгрузка
+// не держит в памяти всю выборку. Если ошибка случилась после начала записи,
+// w уже содержит часть выгрузки.
+func (s *SongService) ExportSongs(ctx context.Context, filter *dto.SongFilter, format dto.ExportFormat, w io.Writer) error {
+	s.logger.Info("ExportSongs: started")
+	defer s.logger.Info("ExportSongs: completed")
+
+	out, err := newExportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = s.repo.ExportSongs(ctx, filter, func(song *dto.Song) error {
+		if err := out.Write(dto.NewExportSong(song)); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("ExportSongs: export interrupted after %d songs: %v", count, err)
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	s.logger.Infof("ExportSongs: exported %d songs", count)
+	return nil
+}
+
+// exportWriter последовательно записывает песни в выбранном формате. Close
+// дописывает окончание выгрузки и сбрасывает буферы.
+type exportWriter interface {
+	Write(song *dto.ExportSong) error
+	Close() error
+}
+
+func newExportWriter(format dto.ExportFormat, w io.Writer) (exportWriter, error) {
+	switch format {
+	case dto.ExportCSV:
+		out := csv.NewWriter(w)
+		if err := out.Write(dto.ExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportWriter{out: out}, nil
+	case dto.ExportNDJSON:
+		return &jsonExportWriter{w: w, enc: newExportEncoder(w)}, nil
+	case dto.ExportJSON:
+		return &jsonExportWriter{w: w, enc: newExportEncoder(w), array: true}, nil
+	}
+	return nil, dto.ErrUnknownExportFormat
+}
+
+func newExportEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+type csvExportWriter struct {
+	out *csv.Writer
+}
+
+func (c *csvExportWriter) Write(song *dto.ExportSong) error {
+	return c.out.Write(song.Record())
+}
+
+func (c *csvExportWriter) Close() error {
+	c.out.Flush()
+	return c.out.Error()
+}
+
+// jsonExportWriter пишет по объекту на строку. В режиме array строки
+// оборачиваются в JSON-массив, так что незавершённая выгрузка не разбирается
+// как корректный JSON.
+type jsonExportWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	array bool
+	count int
+}
+
+func (j *jsonExportWriter) Write(song *dto.ExportSong) error {
+	if j.array {
+		sep := ","
+		if j.count == 0 {
+			sep = "["
+		}
+		if _, err := io.WriteString(j.w, sep); err != nil {
+			return err
+		}
+	}
+	j.count++
+	return j.enc.Encode(song)
+}
+
+func (j *jsonExportWriter) Close() error {
+	if !j.array {
+		return nil
+	}
+	end := "]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
@@ -2,273 +2,368 @@ package song_service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"regexp"
+	"io"
 	"root/config"
+	job_dto "root/module/job/dto"
+	job_service "root/module/job/service"
+	song_client "root/module/song/client"
 	dto "root/module/song/dto"
+	song_lyrics "root/module/song/lyrics"
 	song_repository "root/module/song/repository"
 	"root/shared/logger"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type ISongService interface {
-	GetSongs(filters map[string]string, offset, limit int) ([]map[string]interface{}, error)
-	GetSongText(songID string, offset, limit int) ([]string, error)
-	DeleteSong(songID string) error
-	UpdateSong(songID string, data map[string]interface{}) error
-	AddSong(group, song string) error
+	GetSongs(ctx context.Context, filter *dto.SongFilter, page dto.PageRequest) (*dto.SongPage, error)
+	SearchSongs(ctx context.Context, query string, offset, limit int) ([]dto.SongSearchHit, error)
+	GetSongText(ctx context.Context, songID string, page, limit int) (*dto.SongLyricsPage, error)
+	DeleteSong(ctx context.Context, songID string, ifMatch *dto.IfMatch, actor string) error
+	UpdateSong(ctx context.Context, songID string, patch *dto.SongPatch, ifMatch *dto.IfMatch, actor string) (*dto.Song, error)
+	AddSong(ctx context.Context, group, song, actor string) (*job_dto.Job, *dto.Song, error)
+	ProcessJob(ctx context.Context, job *job_dto.Job) (uuid.UUID, error)
+	InvalidateSongDetails(ctx context.Context, group, song string) error
+	GetTrash(ctx context.Context, offset, limit int) ([]dto.Song, error)
+	RestoreSong(ctx context.Context, songID, actor string) (*dto.Song, error)
+	GetSongHistory(ctx context.Context, songID string, offset, limit int) ([]dto.SongRevision, error)
+	RevertSong(ctx context.Context, songID, revision string, ifMatch *dto.IfMatch, actor string) (*dto.Song, error)
+	ImportSongs(ctx context.Context, format dto.ImportFormat, body io.Reader, dryRun bool, actor string) (*dto.ImportReport, error)
+	ExportSongs(ctx context.Context, filter *dto.SongFilter, format dto.ExportFormat, w io.Writer) error
+	GetSyncedLyrics(ctx context.Context, songID string) (*dto.SyncedLyrics, error)
+	GetSyncedLyricsAt(ctx context.Context, songID string, at time.Duration) (*dto.SyncedLyricsAt, error)
+	SaveSyncedLyrics(ctx context.Context, songID, lrc string) (*dto.SyncedLyrics, error)
+	DeleteSyncedLyrics(ctx context.Context, songID string) error
+	FindNearDuplicates(ctx context.Context, groupID *int, threshold float64, offset, limit int) ([]dto.DuplicateCandidate, error)
+	GetShardStats(ctx context.Context, top int, warnShare float64) (*dto.ShardReport, error)
+	StartTrashPurge(ctx context.Context)
+	Wait()
 }
 
 type SongService struct {
-	repo   song_repository.ISongRepository
-	logger *logger.Logger
-	config *config.Config
-	db     *gorm.DB
+	repo         song_repository.ISongRepository
+	jobService   job_service.IJobService
+	musicClient  song_client.IMusicClient
+	detailsCache song_client.IDetailsCache
+	lyrics       song_lyrics.Parser
+	logger       *logger.Logger
+	config       *config.Config
+
+	wg sync.WaitGroup
 }
 
-func NewSongService(logger *logger.Logger, config *config.Config, db *gorm.DB, repo song_repository.ISongRepository) ISongService {
+func NewSongService(logger *logger.Logger, config *config.Config, repo song_repository.ISongRepository, jobService job_service.IJobService, musicClient song_client.IMusicClient, detailsCache song_client.IDetailsCache) ISongService {
 	return &SongService{
-		logger: logger,
-		config: config,
-		db:     db,
-		repo:   repo,
+		logger:       logger,
+		config:       config,
+		repo:         repo,
+		jobService:   jobService,
+		musicClient:  musicClient,
+		detailsCache: detailsCache,
+		lyrics:       song_lyrics.NewAutoParser(),
 	}
 }
 
-func (s *SongService) GetSongs(filters map[string]string, offset, limit int) ([]map[string]interface{}, error) {
+func (s *SongService) GetSongs(ctx context.Context, filter *dto.SongFilter, page dto.PageRequest) (*dto.SongPage, error) {
 	s.logger.Info("GetSongs: started")
 	defer s.logger.Info("GetSongs: completed")
 
-	var allSongs []map[string]interface{}
-
-	for i := 1; i < 4; i++ {
-		tableName := fmt.Sprintf("songs_%d", i)
-		s.logger.Infof("GetSongs: checking table %s", tableName)
-
-		var songs []map[string]interface{}
-		query := s.db.Table(tableName)
+	if page.Limit <= 0 {
+		page.Limit = defaultPageLimit
+	}
+	if page.Limit > maxPageLimit {
+		page.Limit = maxPageLimit
+	}
 
-		for field, value := range filters {
-			field = strings.ToLower(field)
-			escapedField := fmt.Sprintf(`"%s"`, field)
-			query = query.Where(fmt.Sprintf("%s LIKE ?", escapedField), "%"+value+"%")
+	var after *dto.SongCursor
+	if page.Cursor != "" {
+		if page.Offset > 0 {
+			return nil, dto.ErrCursorAndOffset
 		}
-
-		s.logger.Infof("GetSongs: executing query for table %s", tableName)
-		if err := query.Find(&songs).Error; err != nil {
-			s.logger.Errorf("GetSongs: error fetching songs from table %s: %v", tableName, err)
+		cursor, err := decodeCursor(page.Cursor, filter.Sort)
+		if err != nil {
+			s.logger.Warnf("GetSongs: invalid cursor %q", page.Cursor)
 			return nil, err
 		}
+		after = cursor
+	}
+	if page.Offset > maxPageOffset {
+		return nil, dto.ErrOffsetTooLarge
+	}
+
+	// Лишняя строка нужна, чтобы понять, есть ли следующая страница.
+	fetch := page.Offset + page.Limit + 1
 
-		allSongs = append(allSongs, songs...)
-		s.logger.Infof("GetSongs: found %d songs in table %s", len(songs), tableName)
+	merged, err := s.repo.ListSongs(ctx, filter, after, fetch)
+	if err != nil {
+		s.logger.Errorf("GetSongs: failed to fetch songs: %v", err)
+		return nil, err
 	}
 
-	start := offset
-	if start > len(allSongs) {
-		start = len(allSongs)
+	start := page.Offset
+	if start > len(merged) {
+		start = len(merged)
 	}
-	end := offset + limit
-	if end > len(allSongs) {
-		end = len(allSongs)
+	end := start + page.Limit
+	if end > len(merged) {
+		end = len(merged)
 	}
 
-	s.logger.Infof("GetSongs: returning %d songs (offset: %d, limit: %d)", len(allSongs[start:end]), offset, limit)
-	return allSongs[start:end], nil
+	result := &dto.SongPage{Songs: merged[start:end]}
+	if len(merged) > end && end > start {
+		result.NextCursor = encodeCursor(filter.Sort, &merged[end-1])
+	}
+
+	s.logger.Infof("GetSongs: returning %d songs (offset: %d, limit: %d)", len(result.Songs), page.Offset, page.Limit)
+	return result, nil
 }
 
-func (s *SongService) GetSongText(songID string, offset, limit int) ([]string, error) {
-	s.logger.Info("GetSongText: started")
-	defer s.logger.Info("GetSongText: completed")
+func (s *SongService) SearchSongs(ctx context.Context, query string, offset, limit int) ([]dto.SongSearchHit, error) {
+	s.logger.Info("SearchSongs: started")
+	defer s.logger.Info("SearchSongs: completed")
 
-	songText := new(dto.SongText)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, dto.ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if offset > maxPageOffset {
+		return nil, dto.ErrOffsetTooLarge
+	}
 
-	for i := 0; i < 4; i++ {
-		tableName := fmt.Sprintf("songs_%d", i)
-		s.logger.Infof("GetSongText: checking table %s", tableName)
+	merged, err := s.repo.SearchSongs(ctx, query, offset+limit)
+	if err != nil {
+		s.logger.Errorf("SearchSongs: search failed: %v", err)
+		return nil, err
+	}
+	if offset > len(merged) {
+		offset = len(merged)
+	}
 
-		if err := s.db.Table(tableName).Select("text").Where("id = ?", songID).Scan(&songText).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				s.logger.Infof("GetSongText: song not found in table %s", tableName)
-				continue
-			}
-			s.logger.Errorf("GetSongText: error fetching song text from table %s: %v", tableName, err)
-			return nil, err
-		}
+	s.logger.Infof("SearchSongs: returning %d hits (offset: %d, limit: %d)", len(merged[offset:]), offset, limit)
+	return merged[offset:], nil
+}
 
-		if songText.Text != "" {
-			s.logger.Infof("GetSongText: song text found in table %s", tableName)
-			break
-		}
+// GetSongText возвращает страницу page размером limit из секций текста песни
+// и текущую версию песни для ETag. Первая страница есть всегда, даже у песни
+// без текста; страница за концом текста возвращает ErrLyricsPageNotFound.
+func (s *SongService) GetSongText(ctx context.Context, songID string, page, limit int) (*dto.SongLyricsPage, error) {
+	s.logger.Info("GetSongText: started")
+	defer s.logger.Info("GetSongText: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
 	}
 
-	if songText.Text == "" {
-		s.logger.Error("GetSongText: song not found in any table")
-		return nil, errors.New("song not found")
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		s.logger.Errorf("GetSongText: failed to fetch song %s: %v", songID, err)
+		return nil, err
 	}
 
-	s.logger.Infof("GetSongText: song text found: %s", songText.Text)
+	// Текст с метками LRC делится на секции по обычному тексту без меток
+	text := song.Text
+	if song_lyrics.IsLRC(text) {
+		if lines, err := song_lyrics.ParseLRC(text); err == nil {
+			text = song_lyrics.PlainText(lines)
+		}
+	}
 
-	sections := splitSongIntoSections(songText.Text)
+	sections := s.lyrics.Parse(text)
 	s.logger.Infof("GetSongText: split song into %d sections", len(sections))
 
-	start := (offset - 1) * limit
-	if start >= len(sections) {
-		s.logger.Infof("GetSongText: offset %d is out of bounds, returning empty slice", offset)
-		return []string{}, nil
-	}
-
-	end := start + limit
-	if end > len(sections) {
-		end = len(sections)
+	pages := (len(sections) + limit - 1) / limit
+	if page > max(pages, 1) {
+		s.logger.Warnf("GetSongText: page %d is out of range for %d sections", page, len(sections))
+		return nil, dto.ErrLyricsPageNotFound
 	}
-
-	s.logger.Infof("GetSongText: returning %d sections (offset: %d, limit: %d)", len(sections[start:end]), offset, limit)
-	return sections[start:end], nil
+	start := (page - 1) * limit
+	end := min(start+limit, len(sections))
+
+	s.logger.Infof("GetSongText: returning %d sections (page: %d, limit: %d)", end-start, page, limit)
+	return &dto.SongLyricsPage{
+		SongID:        song.ID,
+		Song:          song.Song,
+		Group:         song.Group,
+		TotalSections: len(sections),
+		Page:          page,
+		PageSize:      limit,
+		HasNext:       end < len(sections),
+		Sections:      sections[start:end],
+		Version:       song.Version,
+	}, nil
 }
 
-func (s *SongService) DeleteSong(songID string) error {
+// DeleteSong переносит песню в корзину, откуда её можно восстановить. Если
+// задан ifMatch и версия не совпадает, возвращается ErrVersionMismatch.
+// Удаление записывается в историю от имени actor.
+func (s *SongService) DeleteSong(ctx context.Context, songID string, ifMatch *dto.IfMatch, actor string) error {
 	s.logger.Info("DeleteSong: started")
 	defer s.logger.Info("DeleteSong: completed")
 
-	for i := 1; i <= 4; i++ {
-		tableName := fmt.Sprintf("songs_%d", i)
-		s.logger.Infof("DeleteSong: checking table %s", tableName)
-
-		result := s.db.Table(tableName).Where("id = ?", songID).Delete(&dto.Song{})
-		if result.Error != nil {
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				s.logger.Infof("DeleteSong: song not found in table %s", tableName)
-				continue
-			}
-			s.logger.Errorf("DeleteSong: error deleting song from table %s: %v", tableName, result.Error)
-			return result.Error
-		}
+	id, err := parseSongID(songID)
+	if err != nil {
+		return err
+	}
 
-		if result.RowsAffected > 0 {
-			s.logger.Infof("DeleteSong: song deleted from table %s", tableName)
-			return nil
-		}
+	if err := s.repo.DeleteSong(ctx, id, ifMatch, actor); err != nil {
+		s.logger.Errorf("DeleteSong: failed to delete song %s: %v", songID, err)
+		return err
 	}
 
-	s.logger.Error("DeleteSong: song not found in any table")
-	return errors.New("song not found")
+	s.logger.Infof("DeleteSong: song %s moved to trash", songID)
+	return nil
 }
 
-func (s *SongService) UpdateSong(songID string, data map[string]interface{}) error {
+// UpdateSong применяет к песне проверенный patch и увеличивает её версию. Смена
+// группы переносит песню в шард новой группы. Если задан ifMatch и версия не
+// совпадает, возвращается ErrVersionMismatch. Изменение записывается в историю
+// от имени actor.
+func (s *SongService) UpdateSong(ctx context.Context, songID string, patch *dto.SongPatch, ifMatch *dto.IfMatch, actor string) (*dto.Song, error) {
 	s.logger.Info("UpdateSong: started")
 	defer s.logger.Info("UpdateSong: completed")
 
-	for i := 1; i <= 4; i++ {
-		tableName := fmt.Sprintf("songs_%d", i)
-		s.logger.Infof("UpdateSong: checking table %s", tableName)
-
-		result := s.db.Table(tableName).Where("id = ?", songID).Updates(data)
-		if result.Error != nil {
-			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-				s.logger.Infof("UpdateSong: song not found in table %s", tableName)
-				continue
-			}
-			s.logger.Errorf("UpdateSong: error updating song in table %s: %v", tableName, result.Error)
-			return result.Error
-		}
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateSong(ctx, id, patch, ifMatch, actor, dto.RevisionUpdate)
+}
 
-		if result.RowsAffected > 0 {
-			s.logger.Infof("UpdateSong: song updated in table %s", tableName)
-			return nil
-		}
+// updateSong - общий путь изменения песни для UpdateSong и RevertSong.
+func (s *SongService) updateSong(ctx context.Context, id uuid.UUID, patch *dto.SongPatch, ifMatch *dto.IfMatch, actor string, action dto.RevisionAction) (*dto.Song, error) {
+	song, err := s.repo.UpdateSong(ctx, id, patch, ifMatch, action, actor)
+	if errors.Is(err, dto.ErrSongExists) {
+		s.logger.Warnf("UpdateSong: song %s would duplicate another song of its group", id)
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Errorf("UpdateSong: error updating song %s: %v", id, err)
+		return nil, err
 	}
 
-	s.logger.Error("UpdateSong: song not found in any table")
-	return errors.New("song not found")
+	s.logger.Infof("UpdateSong: song %s updated", id)
+	return song, nil
 }
 
-func (s *SongService) AddSong(group, song string) error {
+// AddSong ставит задачу на обогащение и добавление песни в очередь. Обращение к
+// внешнему API и запись в БД выполняет пул воркеров через ProcessJob. Если
+// такая песня в группе уже есть, задача не ставится и возвращается эта песня.
+func (s *SongService) AddSong(ctx context.Context, group, song, actor string) (*job_dto.Job, *dto.Song, error) {
 	s.logger.Info("AddSong: started")
 	defer s.logger.Info("AddSong: completed")
 
-	externalAPI := fmt.Sprintf(s.config.ExternalApi, group, song)
-	s.logger.Infof("AddSong: calling external API: %s", externalAPI)
+	group, song = strings.TrimSpace(group), strings.TrimSpace(song)
 
-	resp, err := http.Get(externalAPI)
+	existing, err := s.findExistingSong(ctx, group, song)
 	if err != nil {
-		s.logger.Errorf("AddSong: failed to call external API: %v", err)
-		return errors.New("failed to call external API")
+		s.logger.Errorf("AddSong: failed to look up existing song: %v", err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		s.logger.Errorf("AddSong: external API returned status code %d", resp.StatusCode)
-		return errors.New("external API error")
+	if existing != nil {
+		s.logger.Infof("AddSong: song %s - %s already exists as %s", group, song, existing.ID)
+		return nil, existing, nil
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	job, err := s.jobService.Enqueue(ctx, group, song, actor)
+	return job, nil, err
+}
+
+// ProcessJob выполняет задачу, поставленную AddSong: запрашивает детали песни во
+// внешнем API и сохраняет песню в шард её группы. Отсутствие песни во внешнем
+// API и некорректный ответ - постоянные ошибки, остальные задача повторяет.
+func (s *SongService) ProcessJob(ctx context.Context, job *job_dto.Job) (uuid.UUID, error) {
+	s.logger.Info("ProcessJob: started")
+	defer s.logger.Info("ProcessJob: completed")
+
+	group, song := job.Group, job.Song
+
+	// Песню могли добавить после постановки задачи: внешний API не нужен
+	existing, err := s.findExistingSong(ctx, group, song)
 	if err != nil {
-		s.logger.Errorf("AddSong: failed to read API response body: %v", err)
-		return errors.New("failed to read API response body")
+		s.logger.Errorf("ProcessJob: failed to look up existing song: %v", err)
+		return uuid.Nil, err
 	}
-
-	songDetails := new(dto.SongDetails)
-	if err := json.Unmarshal(body, &songDetails); err != nil {
-		s.logger.Errorf("AddSong: failed to parse API response: %v", err)
-		return errors.New("failed to parse API response")
+	if existing != nil {
+		s.logger.Infof("ProcessJob: song %s - %s already exists as %s", group, song, existing.ID)
+		return existing.ID, nil
 	}
 
-	s.logger.Infof("AddSong: fetched song details: %+v", songDetails)
+	s.logger.Infof("ProcessJob: fetching details for %s - %s", group, song)
 
-	groupID, err := s.repo.CheckTable(context.Background(), songDetails.Group)
+	songDetails, err := s.musicClient.GetSongDetails(ctx, group, song)
 	if err != nil {
-		s.logger.Errorf("AddSong: failed to check group table: %v", err)
-		return err
+		s.logger.Errorf("ProcessJob: failed to fetch song details: %v", err)
+		if errors.Is(err, song_client.ErrNotFound) || errors.Is(err, song_client.ErrMalformed) {
+			return uuid.Nil, job_dto.Permanent(err)
+		}
+		return uuid.Nil, err
 	}
 
-	s.logger.Infof("AddSong: group ID: %d", groupID)
+	s.logger.Infof("ProcessJob: fetched song details: releaseDate=%s, link=%s", songDetails.ReleaseDate, songDetails.Link)
 
-	err = s.db.Create(&dto.Song{
-		ID:      uuid.New(),
-		GroupID: groupID,
-		Group:   songDetails.Group,
-		Song:    songDetails.Song,
-	}).Error
+	newSong, err := songDetails.ToSong(group, song)
 	if err != nil {
-		s.logger.Errorf("AddSong: failed to create song: %v", err)
-		return fmt.Errorf("failed to create song: %w", err)
+		s.logger.Errorf("ProcessJob: %v", err)
+		return uuid.Nil, job_dto.Permanent(err)
 	}
 
-	s.logger.Info("AddSong: song created successfully")
-	return nil
-}
-
-func splitSongIntoSections(songText string) []string {
-	re := regexp.MustCompile(`(?i)\[?(Куплет \d+|Припев)\]?\s*\n`)
-	matches := re.FindAllStringIndex(songText, -1)
+	groupID, err := s.repo.CheckTable(ctx, group)
+	if err != nil {
+		s.logger.Errorf("ProcessJob: failed to check group table: %v", err)
+		return uuid.Nil, err
+	}
 
-	var sections []string
-	start := 0
+	s.logger.Infof("ProcessJob: group ID: %d", groupID)
+	newSong.GroupID = groupID
 
-	for _, match := range matches {
-		if start < match[0] {
-			section := strings.TrimSpace(songText[start:match[0]])
-			if section != "" {
-				sections = append(sections, section)
-			}
+	err = s.repo.CreateSongs(ctx, []*dto.Song{newSong}, job.Actor)
+	if errors.Is(err, dto.ErrSongExists) {
+		// Параллельная задача успела создать ту же песню
+		if existing, findErr := s.findExistingSong(ctx, group, song); findErr == nil && existing != nil {
+			s.logger.Infof("ProcessJob: song %s - %s was created concurrently as %s", group, song, existing.ID)
+			return existing.ID, nil
 		}
-		start = match[1]
+	}
+	if err != nil {
+		s.logger.Errorf("ProcessJob: failed to create song: %v", err)
+		return uuid.Nil, fmt.Errorf("failed to create song: %w", err)
 	}
 
-	if start < len(songText) {
-		section := strings.TrimSpace(songText[start:])
-		if section != "" {
-			sections = append(sections, section)
-		}
+	s.logger.Info("ProcessJob: song created successfully")
+	return newSong.ID, nil
+}
+
+// InvalidateSongDetails сбрасывает закэшированный ответ внешнего API, чтобы
+// следующее добавление песни запросило детали заново.
+func (s *SongService) InvalidateSongDetails(ctx context.Context, group, song string) error {
+	s.logger.Info("InvalidateSongDetails: started")
+	defer s.logger.Info("InvalidateSongDetails: completed")
+
+	if err := s.detailsCache.Invalidate(ctx, group, song); err != nil {
+		s.logger.Errorf("InvalidateSongDetails: %v", err)
+		return err
 	}
 
-	return sections
+	s.logger.Infof("InvalidateSongDetails: cache invalidated for %s - %s", group, song)
+	return nil
+}
+
+func parseSongID(songID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(songID)
+	if err != nil {
+		return uuid.Nil, dto.ErrInvalidSongID
+	}
+	return id, nil
 }
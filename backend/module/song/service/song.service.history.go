@@ -0,0 +1,72 @@
+package song_service
+
+import (
+	"context"
+	"strconv"
+
+	dto "root/module/song/dto"
+)
+
+// GetSongHistory возвращает ревизии песни, начиная с последней. История
+// доступна и для песен, удалённых окончательно.
+func (s *SongService) GetSongHistory(ctx context.Context, songID string, offset, limit int) ([]dto.SongRevision, error) {
+	s.logger.Info("GetSongHistory: started")
+	defer s.logger.Info("GetSongHistory: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	revisions, err := s.repo.GetSongRevisions(ctx, id, offset, limit)
+	if err != nil {
+		s.logger.Errorf("GetSongHistory: failed to fetch history of song %s: %v", songID, err)
+		return nil, err
+	}
+
+	s.logger.Infof("GetSongHistory: returning %d revisions of song %s", len(revisions), songID)
+	return revisions, nil
+}
+
+// RevertSong возвращает метаданные и текст песни к состоянию ревизии через
+// обычный путь изменения: с проверкой If-Match, переносом между шардами и
+// новой ревизией с действием revert.
+func (s *SongService) RevertSong(ctx context.Context, songID, revision string, ifMatch *dto.IfMatch, actor string) (*dto.Song, error) {
+	s.logger.Info("RevertSong: started")
+	defer s.logger.Info("RevertSong: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(revision)
+	if err != nil || version < 1 {
+		return nil, dto.ErrInvalidRevision
+	}
+
+	target, err := s.repo.GetSongRevision(ctx, id, version)
+	if err != nil {
+		s.logger.Errorf("RevertSong: failed to fetch revision %d of song %s: %v", version, songID, err)
+		return nil, err
+	}
+
+	patch, err := target.Snapshot.Patch()
+	if err != nil {
+		s.logger.Errorf("RevertSong: %v", err)
+		return nil, err
+	}
+
+	song, err := s.updateSong(ctx, id, patch, ifMatch, actor, dto.RevisionRevert)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("RevertSong: song %s reverted to revision %d", songID, version)
+	return song, nil
+}
@@ -0,0 +1,418 @@
+package song_service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	dto "root/module/song/dto"
+)
+
+const (
+	// importBatchSize - число строк, которые проверяются и записываются вместе.
+	importBatchSize = 500
+	// maxImportLineSize ограничивает строку NDJSON вместе с текстом песни.
+	maxImportLineSize = 1 << 20
+)
+
+// importColumns - колонки CSV и ключи NDJSON, group и song обязательны.
+var importColumns = []string{"group", "song", "release_date", "text", "link", "enrich"}
+
+// ImportSongs читает строки CSV или NDJSON из потока и записывает их пачками
+// в шарды их групп. Ошибка строки не прерывает импорт и попадает в отчёт;
+// импорт прерывается только нечитаемым потоком или заголовком CSV. В режиме
+// dryRun строки проверяются, но ни группы, ни песни не создаются.
+func (s *SongService) ImportSongs(ctx context.Context, format dto.ImportFormat, body io.Reader, dryRun bool, actor string) (*dto.ImportReport, error) {
+	s.logger.Info("ImportSongs: started")
+	defer s.logger.Info("ImportSongs: completed")
+
+	reader, err := newImportReader(format, body)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &songImport{
+		service: s,
+		ctx:     ctx,
+		dryRun:  dryRun,
+		actor:   actor,
+		groups:  map[string]int{},
+		seen:    map[dto.SongKey]int{},
+	}
+	report := &dto.ImportReport{DryRun: dryRun, Rows: []dto.ImportRowResult{}}
+	batch := make([]importItem, 0, importBatchSize)
+
+	for row := 1; ; row++ {
+		data, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			batch = append(batch, importItem{row: row, reason: rowErr.reason})
+		} else if err != nil {
+			s.logger.Errorf("ImportSongs: failed to read row %d: %v", row, err)
+			return nil, fmt.Errorf("%w: row %d: %v", dto.ErrInvalidImport, row, err)
+		} else {
+			batch = append(batch, importItem{row: row, data: data})
+		}
+
+		if len(batch) == importBatchSize {
+			imp.flush(batch, report)
+			batch = batch[:0]
+		}
+	}
+	imp.flush(batch, report)
+
+	s.logger.Infof("ImportSongs: created=%d skipped=%d failed=%d dry_run=%t", report.Created, report.Skipped, report.Failed, dryRun)
+	return report, nil
+}
+
+// importItem - прочитанная строка импорта. Непустой reason означает, что
+// строку не удалось разобрать.
+type importItem struct {
+	row    int
+	data   dto.ImportRow
+	reason string
+	song   *dto.Song
+}
+
+// songImport - состояние одного импорта между пачками.
+type songImport struct {
+	service *SongService
+	ctx     context.Context
+	dryRun  bool
+	actor   string
+
+	// groups кэширует ID групп по названию. В режиме dryRun новые группы
+	// получают отрицательные временные ID.
+	groups map[string]int
+	// seen - строки, уже принятые в этом импорте, для поиска дубликатов.
+	seen map[dto.SongKey]int
+}
+
+// flush проверяет пачку строк и записывает принятые песни одной транзакцией.
+func (imp *songImport) flush(batch []importItem, report *dto.ImportReport) {
+	if len(batch) == 0 {
+		return
+	}
+	s, ctx := imp.service, imp.ctx
+
+	results := make([]dto.ImportRowResult, len(batch))
+	prepared := []*importItem{}
+
+	for i := range batch {
+		item := &batch[i]
+		results[i] = dto.ImportRowResult{Row: item.row, Status: dto.ImportFailed, Reason: item.reason}
+		if item.reason != "" {
+			continue
+		}
+
+		song, err := imp.prepare(ctx, &item.data)
+		if err != nil {
+			results[i].Reason = err.Error()
+			continue
+		}
+
+		key := dto.NewSongKey(song.GroupID, song.Song)
+		if row, ok := imp.seen[key]; ok {
+			results[i].Status = dto.ImportSkipped
+			results[i].Reason = fmt.Sprintf("duplicate of row %d", row)
+			continue
+		}
+		imp.seen[key] = item.row
+
+		item.song = song
+		prepared = append(prepared, item)
+	}
+
+	existing, err := imp.existing(ctx, prepared)
+	if err != nil {
+		s.logger.Errorf("ImportSongs: failed to check duplicates: %v", err)
+	}
+
+	created := []*importItem{}
+	for i := range batch {
+		item := &batch[i]
+		if item.song == nil {
+			continue
+		}
+		switch {
+		case err != nil:
+			results[i].Reason = "failed to check duplicates"
+			item.song = nil
+		case existing[dto.NewSongKey(item.song.GroupID, item.song.Song)]:
+			results[i].Status = dto.ImportSkipped
+			results[i].Reason = "song already exists"
+			item.song = nil
+		default:
+			created = append(created, item)
+		}
+	}
+
+	if !imp.dryRun && len(created) > 0 {
+		if err := imp.write(ctx, created); err != nil {
+			s.logger.Errorf("ImportSongs: failed to write batch: %v", err)
+			reason := "failed to write batch"
+			if errors.Is(err, dto.ErrSongExists) {
+				reason = "a song of the batch was added concurrently, retry the import"
+			}
+			for i := range batch {
+				if song := batch[i].song; song != nil {
+					results[i].Reason = reason
+					delete(imp.seen, dto.NewSongKey(song.GroupID, song.Song))
+					batch[i].song = nil
+				}
+			}
+		}
+	}
+
+	for i := range batch {
+		if song := batch[i].song; song != nil {
+			results[i].Status = dto.ImportCreated
+			results[i].Reason = ""
+			if !imp.dryRun {
+				id := song.ID
+				results[i].SongID = &id
+			}
+		}
+		report.Add(results[i])
+	}
+}
+
+// prepare проверяет строку, при необходимости обогащает её и определяет группу.
+func (imp *songImport) prepare(ctx context.Context, row *dto.ImportRow) (*dto.Song, error) {
+	s := imp.service
+
+	if err := row.Normalize(); err != nil {
+		return nil, err
+	}
+	if row.Enrich && (row.ReleaseDate == "" || row.Text == "" || row.Link == "") {
+		details, err := s.musicClient.GetSongDetails(ctx, row.Group, row.Song)
+		if err != nil {
+			return nil, fmt.Errorf("enrichment failed: %v", err)
+		}
+		row.Merge(details)
+	}
+
+	song, err := row.ToSong()
+	if err != nil {
+		return nil, err
+	}
+
+	groupID, ok := imp.groups[row.Group]
+	if !ok {
+		if imp.dryRun {
+			groupID, err = s.repo.FindGroupID(ctx, row.Group)
+			if err == nil && groupID == 0 {
+				groupID = -len(imp.groups) - 1
+			}
+		} else {
+			groupID, err = s.repo.CheckTable(ctx, row.Group)
+		}
+		if err != nil {
+			s.logger.Errorf("ImportSongs: failed to resolve group %q: %v", row.Group, err)
+			return nil, errors.New("failed to resolve group")
+		}
+		imp.groups[row.Group] = groupID
+	}
+	song.GroupID = groupID
+	return song, nil
+}
+
+// existing возвращает ключи песен пачки, которые уже есть вне корзины. Группы
+// с временными ID ещё не существуют, и их песни не проверяются.
+func (imp *songImport) existing(ctx context.Context, items []*importItem) (map[dto.SongKey]bool, error) {
+	keys := make([]dto.SongKey, 0, len(items))
+	for _, item := range items {
+		if item.song.GroupID > 0 {
+			keys = append(keys, dto.NewSongKey(item.song.GroupID, item.song.Song))
+		}
+	}
+	if len(keys) == 0 {
+		return map[dto.SongKey]bool{}, nil
+	}
+	return imp.service.repo.ExistingSongs(ctx, keys)
+}
+
+// write записывает песни пачки вместе с их ревизиями создания атомарно.
+func (imp *songImport) write(ctx context.Context, items []*importItem) error {
+	songs := make([]*dto.Song, 0, len(items))
+	for _, item := range items {
+		songs = append(songs, item.song)
+	}
+	return imp.service.repo.CreateSongs(ctx, songs, imp.actor)
+}
+
+// importRowError - ошибка разбора одной строки, импорт продолжается.
+type importRowError struct {
+	reason string
+}
+
+func (e *importRowError) Error() string {
+	return e.reason
+}
+
+// importReader построчно читает тело импорта и возвращает io.EOF в конце.
+type importReader interface {
+	next() (dto.ImportRow, error)
+}
+
+func newImportReader(format dto.ImportFormat, body io.Reader) (importReader, error) {
+	switch format {
+	case dto.ImportCSV:
+		return newCSVImportReader(body)
+	case dto.ImportNDJSON:
+		return newNDJSONImportReader(body), nil
+	default:
+		return nil, dto.ErrUnknownImportFormat
+	}
+}
+
+type csvImportReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+// newCSVImportReader читает заголовок CSV. Неизвестные колонки и отсутствие
+// group или song отклоняют весь импорт.
+func newCSVImportReader(body io.Reader) (*csvImportReader, error) {
+	r := csv.NewReader(body)
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body", dto.ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: header: %v", dto.ErrInvalidImport, err)
+	}
+
+	columns := make([]string, len(header))
+	present := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		known := false
+		for _, column := range importColumns {
+			known = known || name == column
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: unknown column %q, allowed: %s", dto.ErrInvalidImport, name, strings.Join(importColumns, ", "))
+		}
+		if present[name] {
+			return nil, fmt.Errorf("%w: duplicate column %q", dto.ErrInvalidImport, name)
+		}
+		present[name] = true
+		columns[i] = name
+	}
+	if !present["group"] || !present["song"] {
+		return nil, fmt.Errorf("%w: columns group and song are required", dto.ErrInvalidImport)
+	}
+
+	return &csvImportReader{r: r, columns: columns}, nil
+}
+
+func (c *csvImportReader) next() (dto.ImportRow, error) {
+	var row dto.ImportRow
+
+	record, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return row, &importRowError{reason: parseErr.Err.Error()}
+		}
+		return row, err
+	}
+
+	for i, value := range record {
+		switch c.columns[i] {
+		case "group":
+			row.Group = value
+		case "song":
+			row.Song = value
+		case "release_date":
+			row.ReleaseDate = value
+		case "text":
+			row.Text = value
+		case "link":
+			row.Link = value
+		case "enrich":
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			enrich, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return row, &importRowError{reason: "enrich must be true or false"}
+			}
+			row.Enrich = enrich
+		}
+	}
+	return row, nil
+}
+
+type ndjsonImportReader struct {
+	r *bufio.Reader
+}
+
+// utf8BOM - метка порядка байтов, которую добавляют некоторые редакторы.
+var utf8BOM = []byte("\ufeff")
+
+// newNDJSONImportReader пропускает BOM в начале тела, как и CSV-заголовок.
+func newNDJSONImportReader(body io.Reader) *ndjsonImportReader {
+	r := bufio.NewReader(body)
+	if prefix, err := r.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		r.Discard(len(utf8BOM))
+	}
+	return &ndjsonImportReader{r: r}
+}
+
+// next читает следующую непустую строку NDJSON. Строки длиннее
+// maxImportLineSize пропускаются целиком и считаются ошибкой строки.
+func (n *ndjsonImportReader) next() (dto.ImportRow, error) {
+	var row dto.ImportRow
+	for {
+		line, tooLong, err := n.readLine()
+		if err != nil && !(errors.Is(err, io.EOF) && (len(line) > 0 || tooLong)) {
+			return row, err
+		}
+		if tooLong {
+			return row, &importRowError{reason: fmt.Sprintf("line is longer than %d bytes", maxImportLineSize)}
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return dto.ImportRow{}, &importRowError{reason: "invalid JSON: " + err.Error()}
+		}
+		return row, nil
+	}
+}
+
+// readLine читает строку до '\n', не накапливая больше maxImportLineSize байт.
+func (n *ndjsonImportReader) readLine() (line []byte, tooLong bool, err error) {
+	for {
+		chunk, err := n.r.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > maxImportLineSize {
+				tooLong, line = true, nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return line, tooLong, err
+	}
+}
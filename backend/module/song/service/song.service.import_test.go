@@ -0,0 +1,191 @@
+package song_service
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"root/module/song/dto"
+)
+
+// readResult - строка или ошибка строки, прочитанная importReader.
+type readResult struct {
+	row dto.ImportRow
+	err string
+}
+
+// readAll читает импорт до io.EOF. Ошибки строк попадают в результат, любая
+// другая ошибка останавливает тест.
+func readAll(t *testing.T, reader importReader) []readResult {
+	t.Helper()
+	var results []readResult
+	for i := 0; i < 100; i++ {
+		row, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return results
+		}
+		var rowErr *importRowError
+		switch {
+		case errors.As(err, &rowErr):
+			results = append(results, readResult{err: rowErr.reason})
+		case err != nil:
+			t.Fatalf("next() error = %v", err)
+		default:
+			results = append(results, readResult{row: row})
+		}
+	}
+	t.Fatal("reader did not reach EOF")
+	return nil
+}
+
+func TestCSVImportReaderHeader(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{"required columns", "group,song\n", ""},
+		{"all columns in any order", "enrich,link,text,release_date,song,group\n", ""},
+		{"case and spaces", " Group , SONG \n", ""},
+		{"bom", "\ufeffgroup,song\n", ""},
+		{"empty body", "", "empty body"},
+		{"missing song", "group,text\n", "columns group and song are required"},
+		{"unknown column", "group,song,artist\n", `unknown column "artist"`},
+		{"duplicate column", "group,song,Group\n", `duplicate column "group"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newCSVImportReader(strings.NewReader(tc.body))
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, dto.ErrInvalidImport) || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("err = %v, want ErrInvalidImport with %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestCSVImportReaderRows(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want []readResult
+	}{
+		{
+			name: "columns map by header",
+			body: "song,group,enrich,release_date\nUprising,Muse,true,16.07.2006\n",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising", ReleaseDate: "16.07.2006", Enrich: true}}},
+		},
+		{
+			name: "no trailing newline",
+			body: "group,song\nMuse,Uprising",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising"}}},
+		},
+		{
+			name: "quoted multiline text",
+			body: "group,song,text\nMuse,Uprising,\"Paranoia is in bloom,\nthe PR transmissions\"\n",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom,\nthe PR transmissions"}}},
+		},
+		{
+			name: "empty enrich is false",
+			body: "group,song,enrich\nMuse,Uprising,\n",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising"}}},
+		},
+		{
+			name: "bad rows do not stop the import",
+			body: "group,song,enrich\nMuse,Uprising,maybe\nMuse\nMuse,Resistance,false\n",
+			want: []readResult{
+				{err: "enrich must be true or false"},
+				{err: "wrong number of fields"},
+				{row: dto.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := newCSVImportReader(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := readAll(t, reader); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rows = %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONImportReader(t *testing.T) {
+	long := `{"group":"Muse","song":"Long","text":"` + strings.Repeat("a", maxImportLineSize) + `"}`
+	cases := []struct {
+		name string
+		body string
+		want []readResult
+	}{
+		{
+			name: "rows and blank lines",
+			body: "{\"group\":\"Muse\",\"song\":\"Uprising\",\"enrich\":true}\n\n  \r\n{\"group\":\"Muse\",\"song\":\"Resistance\"}\n",
+			want: []readResult{
+				{row: dto.ImportRow{Group: "Muse", Song: "Uprising", Enrich: true}},
+				{row: dto.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{
+			name: "no trailing newline",
+			body: "{\"group\":\"Muse\",\"song\":\"Uprising\"}",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising"}}},
+		},
+		{
+			name: "bom",
+			body: "\ufeff{\"group\":\"Muse\",\"song\":\"Uprising\"}\n",
+			want: []readResult{{row: dto.ImportRow{Group: "Muse", Song: "Uprising"}}},
+		},
+		{
+			name: "invalid json and unknown keys are row errors",
+			body: "{\"group\":\n{\"group\":\"Muse\",\"song\":\"Uprising\",\"artist\":\"x\"}\n{\"group\":\"Muse\",\"song\":\"Resistance\"}\n",
+			want: []readResult{
+				{err: "invalid JSON: unexpected EOF"},
+				{err: `invalid JSON: json: unknown field "artist"`},
+				{row: dto.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{
+			name: "line over the limit is skipped",
+			body: long + "\n{\"group\":\"Muse\",\"song\":\"Resistance\"}\n",
+			want: []readResult{
+				{err: "line is longer than 1048576 bytes"},
+				{row: dto.ImportRow{Group: "Muse", Song: "Resistance"}},
+			},
+		},
+		{
+			name: "last line over the limit without newline",
+			body: "{\"group\":\"Muse\",\"song\":\"Resistance\"}\n" + long,
+			want: []readResult{
+				{row: dto.ImportRow{Group: "Muse", Song: "Resistance"}},
+				{err: "line is longer than 1048576 bytes"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := newImportReader(dto.ImportNDJSON, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := readAll(t, reader); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("rows = %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewImportReaderUnknownFormat(t *testing.T) {
+	if _, err := newImportReader(dto.ImportFormat("xml"), strings.NewReader("")); !errors.Is(err, dto.ErrUnknownImportFormat) {
+		t.Errorf("err = %v, want ErrUnknownImportFormat", err)
+	}
+}
@@ -0,0 +1,47 @@
+package song_service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	dto "root/module/song/dto"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+	// maxPageOffset ограничивает offset-пагинацию: каждый шард отдаёт offset+limit
+	// строк, поэтому большие смещения нужно проходить курсором.
+	maxPageOffset = 1000
+)
+
+// encodeCursor кодирует позицию после song в непрозрачный курсор.
+func encodeCursor(sort dto.SongSort, song *dto.Song) string {
+	raw, _ := json.Marshal(dto.NewSongCursor(sort, song))
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он выдан для той же сортировки.
+func decodeCursor(cursor string, sort dto.SongSort) (*dto.SongCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, dto.ErrInvalidCursor
+	}
+
+	c := new(dto.SongCursor)
+	if err := json.Unmarshal(raw, c); err != nil || c.ID == uuid.Nil {
+		return nil, dto.ErrInvalidCursor
+	}
+	if c.Sort != sort.Field || c.Desc != sort.Desc {
+		return nil, dto.ErrInvalidCursor
+	}
+	if c.Sort == dto.SortByReleaseDate {
+		if _, err := time.Parse(dto.DateLayout, c.Value); err != nil {
+			return nil, dto.ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,76 @@
+package song_service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	dto "root/module/song/dto"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	released, _ := time.Parse(dto.DateLayout, "2006-07-16")
+	song := &dto.Song{ID: uuid.New(), Group: "Muse", Song: "Knights of Cydonia", ReleaseDate: released}
+
+	cases := []struct {
+		sort  dto.SongSort
+		value string
+	}{
+		{dto.SongSort{Field: dto.SortByID}, ""},
+		{dto.SongSort{Field: dto.SortByGroup}, "Muse"},
+		{dto.SongSort{Field: dto.SortBySong, Desc: true}, "Knights of Cydonia"},
+		{dto.SongSort{Field: dto.SortByReleaseDate}, "2006-07-16"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.sort.Field, func(t *testing.T) {
+			cursor, err := decodeCursor(encodeCursor(tc.sort, song), tc.sort)
+			if err != nil {
+				t.Fatalf("decodeCursor error = %v", err)
+			}
+			want := dto.SongCursor{Sort: tc.sort.Field, Desc: tc.sort.Desc, Value: tc.value, ID: song.ID}
+			if *cursor != want {
+				t.Errorf("cursor = %+v, want %+v", *cursor, want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	id := uuid.New()
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	byGroup := dto.SongSort{Field: dto.SortByGroup}
+	byDate := dto.SongSort{Field: dto.SortByReleaseDate}
+	valid := encodeCursor(byGroup, &dto.Song{ID: id, Group: "Muse"})
+
+	cases := []struct {
+		name   string
+		cursor string
+		sort   dto.SongSort
+	}{
+		{"not base64", "!!!", byGroup},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"group"}`)), byGroup},
+		{"truncated", valid[:len(valid)-3], byGroup},
+		{"not json", encode("group:Muse"), byGroup},
+		{"wrong json type", encode(`["group","Muse"]`), byGroup},
+		{"missing id", encode(`{"s":"group","v":"Muse"}`), byGroup},
+		{"nil id", encode(`{"s":"group","v":"Muse","id":"00000000-0000-0000-0000-000000000000"}`), byGroup},
+		{"bad id", encode(`{"s":"group","v":"Muse","id":"42"}`), byGroup},
+		{"other sort field", valid, dto.SongSort{Field: dto.SortBySong}},
+		{"other direction", valid, dto.SongSort{Field: dto.SortByGroup, Desc: true}},
+		{"tampered sort field", encode(`{"s":"song","v":"Muse","id":"` + id.String() + `"}`), byGroup},
+		{"bad release date", encode(`{"s":"release_date","v":"16.07.2006","id":"` + id.String() + `"}`), byDate},
+		{"empty release date", encode(`{"s":"release_date","id":"` + id.String() + `"}`), byDate},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := decodeCursor(tc.cursor, tc.sort); !errors.Is(err, dto.ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tc.cursor, err)
+			}
+		})
+	}
+}
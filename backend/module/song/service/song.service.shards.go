@@ -0,0 +1,52 @@
+package song_service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dto "root/module/song/dto"
+)
+
+// GetShardStats собирает для каждой таблицы songs_N количество песен, размер
+// таблицы и индексов, top самых больших групп и время последнего изменения.
+// Шарды, доля строк которых больше warnShare, попадают в предупреждения;
+// warnShare = 0 берёт порог из SHARD_SKEW_WARN_SHARE.
+func (s *SongService) GetShardStats(ctx context.Context, top int, warnShare float64) (*dto.ShardReport, error) {
+	s.logger.Info("GetShardStats: started")
+	defer s.logger.Info("GetShardStats: completed")
+
+	if warnShare == 0 {
+		warnShare = s.config.ShardSkewWarnShare
+	}
+
+	shards, err := s.repo.GetShardStats(ctx, top)
+	if err != nil {
+		s.logger.Errorf("GetShardStats: failed to collect shard stats: %v", err)
+		return nil, err
+	}
+
+	report := &dto.ShardReport{Shards: shards, WarnShare: warnShare, Warnings: []string{}, GeneratedAt: time.Now()}
+	var largest int64
+	for i := range shards {
+		report.TotalRows += shards[i].Rows
+		largest = max(largest, shards[i].Rows)
+	}
+	if report.TotalRows == 0 {
+		return report, nil
+	}
+
+	for i := range shards {
+		shards[i].Share = float64(shards[i].Rows) / float64(report.TotalRows)
+		if shards[i].Share > warnShare {
+			warning := fmt.Sprintf("%s holds %.1f%% of songs, more than %.1f%%", shards[i].Table, shards[i].Share*100, warnShare*100)
+			report.Warnings = append(report.Warnings, warning)
+			s.logger.Warnf("GetShardStats: %s", warning)
+		}
+	}
+	report.MaxShare = float64(largest) / float64(report.TotalRows)
+	report.Skew = report.MaxShare * float64(len(shards))
+
+	s.logger.Infof("GetShardStats: %d songs in %d shards, skew %.2f", report.TotalRows, len(shards), report.Skew)
+	return report, nil
+}
@@ -0,0 +1,130 @@
+package song_service
+
+import (
+	"context"
+	"time"
+
+	dto "root/module/song/dto"
+	song_lyrics "root/module/song/lyrics"
+
+	"github.com/google/uuid"
+)
+
+// GetSyncedLyrics возвращает синхронизированный текст песни: загруженный LRC,
+// а если его нет - строки из текста песни, размеченного метками [mm:ss.xx].
+func (s *SongService) GetSyncedLyrics(ctx context.Context, songID string) (*dto.SyncedLyrics, error) {
+	s.logger.Info("GetSyncedLyrics: started")
+	defer s.logger.Info("GetSyncedLyrics: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, lines, err := s.syncedLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("GetSyncedLyrics: song %s has %d synced lines from %s", id, len(lines), source)
+	return dto.NewSyncedLyrics(id, source, lines), nil
+}
+
+// GetSyncedLyricsAt возвращает строку, звучащую в момент at, и следующую за ней.
+func (s *SongService) GetSyncedLyricsAt(ctx context.Context, songID string, at time.Duration) (*dto.SyncedLyricsAt, error) {
+	s.logger.Info("GetSyncedLyricsAt: started")
+	defer s.logger.Info("GetSyncedLyricsAt: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, lines, err := s.syncedLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.SyncedLyricsAt{SongID: id, AtMs: at.Milliseconds()}
+	index := song_lyrics.LineAt(lines, at)
+	if index >= 0 {
+		result.Line = dto.NewSyncedLyricsLine(index, lines[index])
+	}
+	if index+1 < len(lines) {
+		result.Next = dto.NewSyncedLyricsLine(index+1, lines[index+1])
+	}
+	return result, nil
+}
+
+// SaveSyncedLyrics разбирает LRC и заменяет им загруженный синхронизированный
+// текст песни. Текст самой песни не меняется.
+func (s *SongService) SaveSyncedLyrics(ctx context.Context, songID, lrc string) (*dto.SyncedLyrics, error) {
+	s.logger.Info("SaveSyncedLyrics: started")
+	defer s.logger.Info("SaveSyncedLyrics: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := song_lyrics.ParseLRC(lrc)
+	if err != nil {
+		s.logger.Warnf("SaveSyncedLyrics: invalid LRC for song %s: %v", id, err)
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceSyncedLines(ctx, id, dto.NewSongSyncedLines(id, lines)); err != nil {
+		s.logger.Errorf("SaveSyncedLyrics: failed to save synced lyrics for song %s: %v", id, err)
+		return nil, err
+	}
+
+	s.logger.Infof("SaveSyncedLyrics: saved %d synced lines for song %s", len(lines), id)
+	return dto.NewSyncedLyrics(id, dto.SyncedSourceUploaded, lines), nil
+}
+
+// DeleteSyncedLyrics удаляет загруженный синхронизированный текст песни.
+func (s *SongService) DeleteSyncedLyrics(ctx context.Context, songID string) error {
+	s.logger.Info("DeleteSyncedLyrics: started")
+	defer s.logger.Info("DeleteSyncedLyrics: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteSyncedLines(ctx, id)
+	if err != nil {
+		s.logger.Errorf("DeleteSyncedLyrics: failed to delete synced lyrics for song %s: %v", id, err)
+		return err
+	}
+	if deleted == 0 {
+		return dto.ErrSyncedNotFound
+	}
+
+	s.logger.Infof("DeleteSyncedLyrics: deleted %d synced lines for song %s", deleted, id)
+	return nil
+}
+
+// syncedLines читает синхронизированный текст живой песни и сообщает его источник.
+func (s *SongService) syncedLines(ctx context.Context, id uuid.UUID) (string, []song_lyrics.SyncedLine, error) {
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		s.logger.Errorf("syncedLines: failed to fetch song %s: %v", id, err)
+		return "", nil, err
+	}
+
+	rows, err := s.repo.GetSyncedLines(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rows) > 0 {
+		return dto.SyncedSourceUploaded, dto.ToSyncedLines(rows), nil
+	}
+
+	if song_lyrics.IsLRC(song.Text) {
+		if lines, err := song_lyrics.ParseLRC(song.Text); err == nil {
+			return dto.SyncedSourceText, lines, nil
+		}
+	}
+	return "", nil, dto.ErrSyncedNotFound
+}
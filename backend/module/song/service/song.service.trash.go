@@ -0,0 +1,102 @@
+package song_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dto "root/module/song/dto"
+)
+
+// GetTrash возвращает песни из корзины всех шардов, начиная с удалённых последними.
+func (s *SongService) GetTrash(ctx context.Context, offset, limit int) ([]dto.Song, error) {
+	s.logger.Info("GetTrash: started")
+	defer s.logger.Info("GetTrash: completed")
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if offset > maxPageOffset {
+		return nil, dto.ErrOffsetTooLarge
+	}
+
+	merged, err := s.repo.ListTrash(ctx, offset+limit)
+	if err != nil {
+		s.logger.Errorf("GetTrash: failed to fetch trash: %v", err)
+		return nil, err
+	}
+	if offset > len(merged) {
+		offset = len(merged)
+	}
+
+	s.logger.Infof("GetTrash: returning %d songs (offset: %d, limit: %d)", len(merged[offset:]), offset, limit)
+	return merged[offset:], nil
+}
+
+// RestoreSong возвращает песню из корзины, увеличивает её версию и записывает
+// восстановление в историю от имени actor.
+func (s *SongService) RestoreSong(ctx context.Context, songID, actor string) (*dto.Song, error) {
+	s.logger.Info("RestoreSong: started")
+	defer s.logger.Info("RestoreSong: completed")
+
+	id, err := parseSongID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	song, err := s.repo.RestoreSong(ctx, id, actor)
+	if errors.Is(err, dto.ErrSongExists) {
+		s.logger.Warnf("RestoreSong: song %s duplicates a live song of its group", songID)
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Errorf("RestoreSong: failed to restore song %s: %v", songID, err)
+		return nil, err
+	}
+
+	s.logger.Infof("RestoreSong: song %s restored", id)
+	return song, nil
+}
+
+// PurgeTrash окончательно удаляет песни, пролежавшие в корзине дольше
+// SONG_TRASH_RETENTION, вместе с их синхронизированными текстами.
+func (s *SongService) PurgeTrash(ctx context.Context) (int64, error) {
+	return s.repo.PurgeTrash(ctx, time.Now().Add(-s.config.SongTrashRetention))
+}
+
+// StartTrashPurge запускает фоновую очистку корзины раз в
+// SONG_TRASH_PURGE_INTERVAL до отмены ctx.
+func (s *SongService) StartTrashPurge(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.SongTrashPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := s.PurgeTrash(ctx)
+			switch {
+			case err != nil && !errors.Is(err, context.Canceled):
+				s.logger.Errorf("StartTrashPurge: failed to purge trash: %v", err)
+			case purged > 0:
+				s.logger.Infof("StartTrashPurge: purged %d songs from trash", purged)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	s.logger.Infof("StartTrashPurge: purging songs older than %v every %v", s.config.SongTrashRetention, s.config.SongTrashPurgeInterval)
+}
+
+// Wait блокируется до остановки фоновой очистки корзины.
+func (s *SongService) Wait() {
+	s.wg.Wait()
+}
@@ -0,0 +1,149 @@
+package song_service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"root/config"
+	job_dto "root/module/job/dto"
+	dto "root/module/song/dto"
+	song_repository "root/module/song/repository"
+	"root/shared/logger"
+)
+
+type fakeMusicClient struct {
+	calls int
+}
+
+func (f *fakeMusicClient) GetSongDetails(ctx context.Context, group, song string) (*dto.SongDetails, error) {
+	f.calls++
+	return &dto.SongDetails{
+		ReleaseDate: "16.07.2006",
+		Text:        "Ooh baby, don't you know I suffer?\n\nOoh baby, can you hear me moan?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}, nil
+}
+
+func newTestService(t *testing.T) (ISongService, *fakeMusicClient) {
+	t.Helper()
+	client := &fakeMusicClient{}
+	cfg := &config.Config{ShardSkewWarnShare: 0.5}
+	return NewSongService(logger.GetLogger(), cfg, song_repository.NewMemoryRepository(), nil, client, nil), client
+}
+
+func TestSongLifecycle(t *testing.T) {
+	ctx := context.Background()
+	service, client := newTestService(t)
+
+	id, err := service.ProcessJob(ctx, &job_dto.Job{Group: "Muse", Song: "Supermassive Black Hole", Actor: "alice"})
+	if err != nil {
+		t.Fatalf("ProcessJob error = %v", err)
+	}
+	songID := id.String()
+
+	// Повторное добавление находит песню без внешнего API и очереди задач
+	job, existing, err := service.AddSong(ctx, "Muse", " supermassive black hole ", "bob")
+	if err != nil || job != nil || existing == nil || existing.ID != id {
+		t.Fatalf("AddSong = %v, %v, %v; want existing song %s", job, existing, err, id)
+	}
+	if client.calls != 1 {
+		t.Errorf("music client calls = %d, want 1", client.calls)
+	}
+
+	lyrics, err := service.GetSongText(ctx, songID, 1, 10)
+	if err != nil {
+		t.Fatalf("GetSongText error = %v", err)
+	}
+	if lyrics.TotalSections != 2 || lyrics.Version != 1 {
+		t.Errorf("lyrics = %+v", lyrics)
+	}
+
+	title := "Supermassive Black Hole (Live)"
+	patch := &dto.SongPatch{Song: &title}
+	if _, err := service.UpdateSong(ctx, songID, patch, &dto.IfMatch{Versions: []int{5}}, "bob"); !errors.Is(err, dto.ErrVersionMismatch) {
+		t.Fatalf("UpdateSong stale error = %v, want ErrVersionMismatch", err)
+	}
+	song, err := service.UpdateSong(ctx, songID, patch, &dto.IfMatch{Versions: []int{1}}, "bob")
+	if err != nil || song.Version != 2 || song.Song != title {
+		t.Fatalf("UpdateSong = %+v, %v", song, err)
+	}
+
+	if err := service.DeleteSong(ctx, songID, nil, "bob"); err != nil {
+		t.Fatalf("DeleteSong error = %v", err)
+	}
+	if _, err := service.GetSongText(ctx, songID, 1, 10); !errors.Is(err, dto.ErrSongNotFound) {
+		t.Fatalf("GetSongText after delete error = %v, want ErrSongNotFound", err)
+	}
+	trash, err := service.GetTrash(ctx, 0, 10)
+	if err != nil || len(trash) != 1 {
+		t.Fatalf("GetTrash = %v, %v", trash, err)
+	}
+
+	if _, err := service.RestoreSong(ctx, songID, "alice"); err != nil {
+		t.Fatalf("RestoreSong error = %v", err)
+	}
+	song, err = service.RevertSong(ctx, songID, "1", nil, "alice")
+	if err != nil || song.Song != "Supermassive Black Hole" || song.Version != 5 {
+		t.Fatalf("RevertSong = %+v, %v", song, err)
+	}
+
+	history, err := service.GetSongHistory(ctx, songID, 0, 10)
+	if err != nil {
+		t.Fatalf("GetSongHistory error = %v", err)
+	}
+	actions := []dto.RevisionAction{dto.RevisionRevert, dto.RevisionRestore, dto.RevisionDelete, dto.RevisionUpdate, dto.RevisionCreate}
+	if len(history) != len(actions) {
+		t.Fatalf("history has %d revisions, want %d", len(history), len(actions))
+	}
+	for i, action := range actions {
+		if history[i].Action != action || history[i].Version != len(actions)-i {
+			t.Errorf("revision %d = %s v%d, want %s v%d", i, history[i].Action, history[i].Version, action, len(actions)-i)
+		}
+	}
+}
+
+func TestGetSongsPages(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService(t)
+
+	body := strings.NewReader(`{"group":"Muse","song":"Uprising","release_date":"2009-09-07","text":"a","link":"https://example.com/1"}
+{"group":"Muse","song":"Starlight","release_date":"2006-09-04","text":"b","link":"https://example.com/2"}
+{"group":"Muse","song":"starlight","release_date":"2006-09-04","text":"b","link":"https://example.com/2"}
+{"group":"Placebo","song":"Meds","release_date":"2006-03-13","text":"c","link":"https://example.com/3"}
+{"group":"Placebo","song":"Special K","release_date":"2001-01-01","text":"d","link":"https://example.com/4"}
+`)
+	report, err := service.ImportSongs(ctx, dto.ImportNDJSON, body, false, "import")
+	if err != nil {
+		t.Fatalf("ImportSongs error = %v", err)
+	}
+	if report.Created != 4 || report.Skipped != 1 {
+		t.Fatalf("import report = %+v", report)
+	}
+
+	filter, err := dto.ParseSongFilter(map[string]string{"sort": "release_date:desc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	page := dto.PageRequest{Limit: 3}
+	for {
+		result, err := service.GetSongs(ctx, filter, page)
+		if err != nil {
+			t.Fatalf("GetSongs error = %v", err)
+		}
+		for _, song := range result.Songs {
+			titles = append(titles, song.Song)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+
+	want := "Uprising,Starlight,Meds,Special K"
+	if got := strings.Join(titles, ","); got != want {
+		t.Errorf("songs = %s, want %s", got, want)
+	}
+}
@@ -2,39 +2,60 @@ package song_module
 
 import (
 	"root/config"
+	"root/database"
+	job_service "root/module/job/service"
+	song_client "root/module/song/client"
 	song_controller "root/module/song/controller"
 	song_repo "root/module/song/repository"
 	song_service "root/module/song/service"
 	"root/shared/logger"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
 type SongModule struct {
 	songController song_controller.ISongController
 	songService    song_service.ISongService
 	songRepository song_repo.ISongRepository
+	musicClient    *song_client.CachedClient
+	jobService     job_service.IJobService
 	logger         *logger.Logger
 	config         *config.Config
-	db             *gorm.DB
+	cluster        *database.Cluster
 }
 
-func NewSongModule(logger *logger.Logger, config *config.Config, db *gorm.DB) *SongModule {
+func NewSongModule(logger *logger.Logger, config *config.Config, cluster *database.Cluster, jobService job_service.IJobService) *SongModule {
 	return &SongModule{
-		logger: logger,
-		config: config,
-		db:     db,
+		logger:     logger,
+		config:     config,
+		cluster:    cluster,
+		jobService: jobService,
 	}
 }
 
 func (m *SongModule) SongRepository() song_repo.ISongRepository {
 	if m.songRepository == nil {
-		m.songRepository = song_repo.NewSongRepository(m.logger, m.db)
+		m.songRepository = song_repo.NewSongRepository(m.logger, m.config, m.cluster)
 	}
 	return m.songRepository
 }
 
+// MusicClient возвращает клиент внешнего API, обёрнутый кэшем. Постоянный
+// уровень кэша в Postgres включается SONG_DETAILS_CACHE_PERSISTENT,
+// инвалидации расходятся по экземплярам через LISTEN/NOTIFY.
+func (m *SongModule) MusicClient() *song_client.CachedClient {
+	if m.musicClient == nil {
+		var store song_client.IDetailsStore
+		if m.config.SongDetailsCachePersistent {
+			store = song_client.NewPostgresStore(m.cluster.Writer())
+		}
+		inner := song_client.NewMusicClient(m.logger, song_client.NewConfig(m.config))
+		bus := song_client.NewPostgresBus(m.logger, m.cluster.Writer(), m.config.DatabaseUrl)
+		m.musicClient = song_client.NewCachedClient(m.logger, song_client.NewCacheConfig(m.config), inner, store, bus)
+	}
+	return m.musicClient
+}
+
 func (m *SongModule) SongController() song_controller.ISongController {
 	if m.songController == nil {
 		m.songController = song_controller.NewSongController(m.logger, m.SongService())
@@ -44,7 +65,7 @@ func (m *SongModule) SongController() song_controller.ISongController {
 
 func (m *SongModule) SongService() song_service.ISongService {
 	if m.songService == nil {
-		m.songService = song_service.NewSongService(m.logger, m.config, m.db, m.SongRepository())
+		m.songService = song_service.NewSongService(m.logger, m.config, m.SongRepository(), m.jobService, m.MusicClient(), m.MusicClient())
 	}
 	return m.songService
 }
@@ -57,24 +78,98 @@ func (m *SongModule) InitRoutes(router fiber.Router) {
 		return m.SongController().GetSongs(c)
 	})
 
+	//полнотекстовый поиск (до /:id, иначе search попадёт в id)
+	song.Get("/search", func(c *fiber.Ctx) error {
+		return m.SongController().SearchSongs(c)
+	})
+
+	//корзина удалённых песен (до /:id)
+	song.Get("/trash", func(c *fiber.Ctx) error {
+		return m.SongController().GetTrash(c)
+	})
+
+	//потоковая выгрузка в CSV, NDJSON или JSON (до /:id)
+	song.Get("/export", func(c *fiber.Ctx) error {
+		return m.SongController().ExportSongs(c)
+	})
+
 	//получить по id
 	song.Get("/:id", func(c *fiber.Ctx) error {
 		return m.SongController().GetSongText(c)
 	})
 
-	//создать запись(через api)
+	//поставить задачу на создание записи(через api)
 	song.Post("/", func(c *fiber.Ctx) error {
 		return m.SongController().AddSong(c)
 	})
 
-	//изменить по id
+	//массовый импорт из CSV или NDJSON
+	song.Post("/import", func(c *fiber.Ctx) error {
+		return m.SongController().ImportSongs(c)
+	})
+
+	//изменить по id (PUT оставлен для совместимости и работает как PATCH)
+	song.Patch("/:id", func(c *fiber.Ctx) error {
+		return m.SongController().UpdateSong(c)
+	})
 	song.Put("/:id", func(c *fiber.Ctx) error {
 		return m.SongController().UpdateSong(c)
 	})
 
-	//удалить по id
+	//удалить по id (в корзину)
 	song.Delete("/:id", func(c *fiber.Ctx) error {
 		return m.SongController().DeleteSong(c)
 	})
 
+	//история изменений
+	song.Get("/:id/history", func(c *fiber.Ctx) error {
+		return m.SongController().GetSongHistory(c)
+	})
+
+	//откатить к ревизии
+	song.Post("/:id/revert/:revision", func(c *fiber.Ctx) error {
+		return m.SongController().RevertSong(c)
+	})
+
+	//восстановить из корзины
+	song.Post("/:id/restore", func(c *fiber.Ctx) error {
+		return m.SongController().RestoreSong(c)
+	})
+
+	//синхронизированный текст (LRC); ?at= - строка в момент воспроизведения
+	song.Get("/:id/lyrics/synced", func(c *fiber.Ctx) error {
+		return m.SongController().GetSyncedLyrics(c)
+	})
+
+	//загрузить синхронизированный текст
+	song.Put("/:id/lyrics/synced", func(c *fiber.Ctx) error {
+		return m.SongController().SaveSyncedLyrics(c)
+	})
+
+	//удалить синхронизированный текст
+	song.Delete("/:id/lyrics/synced", func(c *fiber.Ctx) error {
+		return m.SongController().DeleteSyncedLyrics(c)
+	})
+
+}
+
+func (m *SongModule) InitAdminRoutes(router fiber.Router) {
+	cache := router.Group("/cache")
+
+	//сбросить кэш ответа внешнего API для группы и песни
+	cache.Delete("/song-details", func(c *fiber.Ctx) error {
+		return m.SongController().InvalidateSongDetails(c)
+	})
+
+	songs := router.Group("/songs")
+
+	//пары песен с похожими названиями
+	songs.Get("/duplicates", func(c *fiber.Ctx) error {
+		return m.SongController().GetNearDuplicates(c)
+	})
+
+	//статистика и перекос шардов
+	songs.Get("/shards", func(c *fiber.Ctx) error {
+		return m.SongController().GetShardStats(c)
+	})
 }